@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log/slog"
 	"main/internal/app"
 	"main/internal/config"
+	"main/internal/lib/logger/ctxlog"
+	"main/internal/lib/logger/sl"
+	"main/internal/tracing"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 const (
@@ -26,14 +32,57 @@ func main() {
 		}
 	}()
 
+	// wallet [-config-path=...] migrate <command> управляет схемой базы и завершается
+	if args := flag.Args(); len(args) > 0 && args[0] == "migrate" {
+		code := runMigrate(log, cfg, args[1:])
+		if logFile != nil {
+			logFile.Close()
+		}
+		os.Exit(code)
+	}
+
+	// Конфиг целиком не логируем: в нем DSN базы с паролем
 	log.Info("starting wallet",
+		slog.String("env", cfg.Env),
 		slog.String("dev", cfg.Dev),
-		slog.Any("cfg", cfg),
-		slog.Int("port", cfg.GRPC.Port))
+		slog.Int("grpc_port", cfg.GRPC.Port),
+		slog.Int("gateway_port", cfg.Gateway.Port),
+		slog.Int("metrics_port", cfg.Metrics.Port),
+		slog.Bool("tls", cfg.GRPC.TLS.CertFile != ""),
+		slog.Int("db_replicas", len(cfg.Database.Replicas)),
+		slog.String("storage", sl.RedactString(cfg.Storage)))
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		ServiceName: cfg.Tracing.ServiceName,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	application := app.New(log, cfg.GRPC, cfg.Gateway.Port, cfg.Metrics.Port, cfg.Storage, cfg.Database, cfg.Token, cfg.AdminEmails, cfg.Rates, cfg.Outbox, cfg.Webhooks, cfg.Health)
 
-	application := app.New(log, cfg.GRPC.Port, cfg.Storage, cfg.Token)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	go application.GRPCSrv.MustRun()
+	go application.Gateway.MustRun()
+	if application.Metrics != nil {
+		go application.Metrics.MustRun()
+	}
+	go application.Health.Run(ctx)
+	if application.Certificates != nil {
+		go application.Certificates.Run(ctx)
+	}
+	if application.Replicas != nil {
+		go application.Replicas.Run(ctx)
+	}
+	go application.RatesRefresher.Run(ctx)
+	go application.OutboxRelay.Run(ctx)
+	go application.Webhooks.Run(ctx)
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
@@ -41,10 +90,25 @@ func main() {
 	sign := <-stop
 	log.Info("Application stopped", slog.String("signal", sign.String()))
 
+	// Сначала перестаем быть готовыми, затем останавливаем серверы
+	application.Health.Shutdown()
+	cancel()
+	application.Gateway.Stop()
+	if application.Metrics != nil {
+		application.Metrics.Stop()
+	}
 	application.GRPCSrv.Stop()
+
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFlush()
+	if err := shutdownTracing(flushCtx); err != nil {
+		log.Error("failed to flush traces", slog.String("error", err.Error()))
+	}
 	log.Info("Application stopped")
 }
 
+// setupLogger возвращает логгер и открытый для него файл, который нужно закрыть
+// при завершении; nil — лог пишется в stdout
 func setupLogger(env string) (*os.File, *slog.Logger) {
 	var log *slog.Logger
 	var logFile *os.File
@@ -53,26 +117,29 @@ func setupLogger(env string) (*os.File, *slog.Logger) {
 	if env == envDev {
 		logFile, err = os.OpenFile("app.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 		if err != nil {
-			fmt.Printf("Не удалось открыть файл для логов: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Не удалось открыть файл для логов: %v\n", err)
 			os.Exit(1)
 		}
-	} else {
-		logFile = os.Stdout
 	}
 
 	switch env {
 	case envLocal:
 		log = slog.New(
-			slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			newHandler(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: sl.Redact})),
 		)
 	case envDev:
 		log = slog.New(
-			slog.NewJSONHandler(logFile, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			newHandler(slog.NewJSONHandler(logFile, &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: sl.Redact})),
 		)
 	default:
 		log = slog.New(
-			slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
+			newHandler(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo, ReplaceAttr: sl.Redact})),
 		)
 	}
 	return logFile, log
 }
+
+// newHandler добавляет к записям атрибуты запроса и трассировки из контекста
+func newHandler(h slog.Handler) slog.Handler {
+	return ctxlog.NewHandler(tracing.NewLogHandler(h))
+}
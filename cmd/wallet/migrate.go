@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"main/internal/config"
+	"main/internal/storage/postgresql"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = `usage: wallet [-config-path=...] migrate <command>
+
+commands:
+  up            накатить все недостающие миграции
+  down          откатить последнюю миграцию
+  status        показать примененные и ожидающие миграции
+  to <version>  привести схему к версии (0 — пустая схема)
+`
+
+// runMigrate выполняет подкоманду migrate и возвращает код завершения
+func runMigrate(log *slog.Logger, cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	m, closeDB, err := postgresql.OpenMigrator(log, cfg.Storage)
+	if err != nil {
+		log.Error("failed to open database", slog.String("error", err.Error()))
+		return 1
+	}
+	defer closeDB()
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		err = m.Up(ctx)
+	case "down":
+		err = m.Down(ctx)
+	case "to":
+		if len(args) != 2 {
+			fmt.Fprint(os.Stderr, migrateUsage)
+			return 2
+		}
+		version, perr := strconv.ParseInt(args[1], 10, 64)
+		if perr != nil || version < 0 {
+			fmt.Fprintf(os.Stderr, "invalid version %q\n", args[1])
+			return 2
+		}
+		err = m.To(ctx, version)
+	case "status":
+		err = printMigrationStatus(ctx, m)
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+	if err != nil {
+		log.Error("migration failed", slog.String("command", args[0]), slog.String("error", err.Error()))
+		return 1
+	}
+	return 0
+}
+
+func printMigrationStatus(ctx context.Context, m *postgresql.Migrator) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, st := range statuses {
+		status, appliedAt := "pending", "-"
+		if st.Applied {
+			status, appliedAt = "applied", st.AppliedAt.Format(time.RFC3339)
+		}
+		name := st.Name
+		if st.Version > m.Latest() {
+			name += " (unknown to this build)"
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", st.Version, name, status, appliedAt)
+	}
+	return w.Flush()
+}
@@ -1,38 +1,214 @@
 package app
 
 import (
+	"context"
+	"crypto/tls"
+	"fmt"
 	"log/slog"
+	gatewayapp "main/internal/app/gateway"
 	grpcapp "main/internal/app/grpc"
+	metricsapp "main/internal/app/metrics"
 
+	"main/internal/config"
+	"main/internal/events"
+	"main/internal/health"
+	"main/internal/lib/logger/sl"
+	"main/internal/lib/tlsconfig"
+	"main/internal/metrics"
+	"main/internal/outbox"
+	"main/internal/rates"
 	"main/internal/services/auth"
+	"main/internal/services/currency"
 	exchangewall "main/internal/services/exchange"
+	"main/internal/services/portfolio"
+	"main/internal/services/quarantine"
+	"main/internal/services/quotes"
 	walletuser "main/internal/services/walletUser"
+	"main/internal/services/webhook"
+	"main/internal/storage/cache"
 	"main/internal/storage/postgresql"
+	"main/internal/webhooks"
+	"main/proto/user"
 	"time"
 )
 
 type App struct {
-	GRPCSrv *grpcapp.App
+	GRPCSrv        *grpcapp.App
+	Gateway        *gatewayapp.App
+	Health         *health.Monitor
+	Metrics        *metricsapp.App      // nil, если метрики выключены
+	Certificates   *tlsconfig.Reloader  // nil, если TLS выключен
+	Replicas       *postgresql.Replicas // nil, если реплики не настроены
+	RatesRefresher *rates.Refresher
+	OutboxRelay    *outbox.Relay
+	Webhooks       *webhooks.Dispatcher
 }
 
 func New(
 	log *slog.Logger,
-	grpcPort int,
+	grpcCfg config.GRPCConfig,
+	gatewayPort int,
+	metricsPort int,
 	storagePath string,
+	dbCfg config.DBConfig,
 	tokenTTL time.Duration,
+	adminEmails []string,
+	ratesCfg config.RatesConfig,
+	outboxCfg config.OutboxConfig,
+	webhookCfg config.WebhookConfig,
+	healthCfg config.HealthConfig,
 ) *App {
-	storage, err := postgresql.New(storagePath)
+	engine := rates.NewEngine(ratesCfg.Pivot, ratesCfg.Spread)
+	guard := rates.NewGuard(ratesCfg.MaxDeviation, ratesCfg.MaxDivergence)
+	hub := rates.NewHub(engine)
+	bus := events.NewBus(0)
+
+	storage, err := postgresql.New(log, storagePath, postgresql.Options{
+		MaxOpenConns:         dbCfg.MaxOpenConns,
+		MaxIdleConns:         dbCfg.MaxIdleConns,
+		ConnMaxLifetime:      dbCfg.ConnMaxLifetime,
+		ConnMaxIdleTime:      dbCfg.ConnMaxIdleTime,
+		StatementTimeout:     dbCfg.StatementTimeout,
+		Replicas:             dbCfg.Replicas,
+		ReplicaMaxLag:        dbCfg.ReplicaMaxLag,
+		ReplicaCheckInterval: dbCfg.ReplicaCheckInterval,
+		ReadYourWrites:       dbCfg.ReadYourWrites,
+	})
+	if err != nil {
+		panic(err)
+	}
+	if err := metrics.RegisterRates(hub); err != nil {
+		panic(err)
+	}
+
+	// Сервисы читают валюты и курсы через кеш; он сбрасывается при их изменении
+	repo := cache.New(storage, ratesCfg.CacheTTL, ratesCfg.CacheLoadTimeout)
+
+	quotesService := quotes.New(log, repo, engine, guard, hub, rates.DefaultSources(ratesCfg.FetchTimeout))
+	refreshRates(log, quotesService)
+
+	authService := auth.New(log, repo, repo, tokenTTL, adminEmails)
+	walService := walletuser.NewWallet(log, repo, bus, bus, tokenTTL)
+	exchService := exchangewall.NewExchange(log, repo, quotesService, bus, hub, engine.Pivot(), ratesCfg.Fee, tokenTTL)
+	currService := currency.New(log, repo, repo)
+	quarService := quarantine.New(log, repo, repo, quotesService)
+	hookService := webhook.New(log, repo, repo)
+	portService := portfolio.New(log, repo, quotesService, ratesCfg.StaleAfter)
+
+	grpcOpts := grpcapp.Options{
+		Heartbeat:   ratesCfg.Heartbeat,
+		Timeout:     grpcCfg.Timeout,
+		Timeouts:    grpcCfg.MethodTimeouts,
+		Reflection:  grpcCfg.Reflection,
+		ClientRoles: grpcCfg.TLS.ClientRoles,
+	}
+	var certs *tlsconfig.Reloader
+	var gatewayTLS func() *tls.Config
+	if grpcCfg.TLS.CertFile != "" {
+		certs, err = tlsconfig.New(log, tlsconfig.Options{
+			CertFile:       grpcCfg.TLS.CertFile,
+			KeyFile:        grpcCfg.TLS.KeyFile,
+			ClientCAFile:   grpcCfg.TLS.ClientCAFile,
+			ClientAuth:     grpcCfg.TLS.ClientAuth,
+			MinVersion:     grpcCfg.TLS.MinVersion,
+			CipherSuites:   grpcCfg.TLS.CipherSuites,
+			ReloadInterval: grpcCfg.TLS.ReloadInterval,
+			CAFile:         grpcCfg.TLS.CAFile,
+			ServerName:     grpcCfg.TLS.ServerName,
+			ClientCertFile: grpcCfg.TLS.GatewayCertFile,
+			ClientKeyFile:  grpcCfg.TLS.GatewayKeyFile,
+		})
+		if err != nil {
+			panic(err)
+		}
+		grpcOpts.TLS = certs.ServerConfig()
+		gatewayTLS = certs.ClientConfig
+	}
+
+	grpcApp := grpcapp.New(log, authService, walService, portService, exchService, currService, currService, quarService, hookService,
+		grpcOpts, grpcCfg.Port)
+
+	monitor := health.NewMonitor(log, grpcApp.Health(), storage, health.Options{
+		Interval:    healthCfg.Interval,
+		Timeout:     healthCfg.Timeout,
+		RatesMaxAge: healthCfg.RatesMaxAge,
+	}, health.Services{
+		Storage: grpcApp.Services(),
+		Rates:   []string{user.ExchangeService_ServiceDesc.ServiceName},
+	})
+
+	gateway, err := gatewayapp.New(log, gatewayPort, grpcCfg.Port, gatewayTLS, monitor)
 	if err != nil {
 		panic(err)
 	}
 
-	authService := auth.New(log, storage, storage, tokenTTL)
-	walService := walletuser.NewWallet(log, storage, storage, storage, tokenTTL)
-	exchService := exchangewall.NewExchange(log, storage, storage, tokenTTL)
+	var metricsApp *metricsapp.App
+	if metricsPort > 0 {
+		metricsApp = metricsapp.New(log, metricsPort)
+	}
 
-	grpcApp := grpcapp.New(log, authService, walService, exchService, grpcPort)
+	publisher, err := newPublisher(log, outboxCfg)
+	if err != nil {
+		panic(err)
+	}
 
 	return &App{
-		GRPCSrv: grpcApp,
+		GRPCSrv:        grpcApp,
+		Gateway:        gateway,
+		Health:         monitor,
+		Metrics:        metricsApp,
+		Certificates:   certs,
+		Replicas:       storage.Replicas(),
+		RatesRefresher: rates.NewRefresher(log, quotesService, ratesCfg.RefreshInterval),
+		OutboxRelay: outbox.NewRelay(log, storage, publisher, outbox.Options{
+			Interval:    outboxCfg.Interval,
+			BatchSize:   outboxCfg.BatchSize,
+			MaxAttempts: outboxCfg.MaxAttempts,
+			BackoffBase: outboxCfg.BackoffBase,
+			BackoffMax:  outboxCfg.BackoffMax,
+		}),
+		Webhooks: webhooks.NewDispatcher(log, storage, nil, webhooks.Options{
+			Interval:    webhookCfg.Interval,
+			Timeout:     webhookCfg.Timeout,
+			MaxAttempts: webhookCfg.MaxAttempts,
+			BackoffBase: webhookCfg.BackoffBase,
+			BackoffMax:  webhookCfg.BackoffMax,
+			BatchSize:   webhookCfg.BatchSize,
+		}),
+	}
+}
+
+// refreshRates обновляет курсы при запуске
+func refreshRates(log *slog.Logger, q *quotes.Quotes) {
+	const op = "app.refreshRates"
+	log = log.With(slog.String("op", op))
+
+	ctx := context.Background()
+	if err := q.UpdateExchangeRates(ctx); err != nil {
+		log.Error("failed to update exchange rates", sl.Err(err))
+		// Подписчики получат последние сохраненные курсы
+		if err := q.Publish(ctx); err != nil {
+			log.Error("failed to publish rates", sl.Err(err))
+		}
+		return
+	}
+	log.Info("exchange rates updated")
+}
+
+func newPublisher(log *slog.Logger, cfg config.OutboxConfig) (outbox.Publisher, error) {
+	switch cfg.Publisher {
+	case "", "log":
+		return outbox.NewLogPublisher(log), nil
+	case "file":
+		return outbox.NewFilePublisher(cfg.FilePath)
+	case "nats":
+		return outbox.DialNATS(cfg.NATSURL, cfg.NATSSubject)
+	case "kafka":
+		if len(cfg.KafkaBrokers) == 0 {
+			return nil, fmt.Errorf("outbox: kafka_brokers is empty")
+		}
+		return outbox.NewKafkaPublisher(outbox.NewKafkaWriter(cfg.KafkaBrokers, cfg.KafkaTopic)), nil
+	default:
+		return nil, fmt.Errorf("outbox: unknown publisher %q", cfg.Publisher)
 	}
 }
@@ -0,0 +1,251 @@
+package gatewayapp
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"main/proto"
+	"main/proto/user"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	openAPIPath     = "/openapi.json"
+	tokenParam      = "token"
+	livenessPath    = "/healthz"
+	readinessPath   = "/readyz"
+	shutdownTimeout = 5 * time.Second
+)
+
+// App — HTTP/JSON gateway к gRPC API. Запросы проксируются в gRPC-сервер
+// того же процесса, поэтому проверки и авторизация у REST и gRPC общие.
+type App struct {
+	log        *slog.Logger
+	conn       *grpc.ClientConn
+	httpServer *http.Server
+	port       int
+}
+
+// Probe сообщает состояние экземпляра для HTTP-проверок оркестратора
+type Probe interface {
+	Live() bool
+	Ready() bool
+}
+
+// New создает gateway на порту port, проксирующий запросы на gRPC-порт grpcPort.
+// clientTLS равен nil, если gRPC-сервер работает без TLS; иначе он вызывается
+// для каждого соединения, чтобы перевыпущенные сертификаты применялись без перезапуска.
+func New(log *slog.Logger, port int, grpcPort int, clientTLS func() *tls.Config, probe Probe) (*App, error) {
+	const op = "gatewayapp.New"
+
+	creds := insecure.NewCredentials()
+	if clientTLS != nil {
+		creds = reloadingCreds{TransportCredentials: credentials.NewTLS(clientTLS()), config: clientTLS}
+	}
+	conn, err := grpc.NewClient(
+		fmt.Sprintf("localhost:%d", grpcPort),
+		grpc.WithTransportCredentials(creds),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	handler, err := newHandler(context.Background(), conn, probe)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &App{
+		log:  log,
+		conn: conn,
+		httpServer: &http.Server{
+			Addr:              fmt.Sprintf(":%d", port),
+			Handler:           otelhttp.NewHandler(handler, "gateway"),
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+		port: port,
+	}, nil
+}
+
+// newHandler собирает маршруты gateway поверх соединения conn с gRPC-сервером
+func newHandler(ctx context.Context, conn *grpc.ClientConn, probe Probe) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(headerMatcher),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeaderMatcher),
+	)
+	if err := user.RegisterAuthHandler(ctx, mux, conn); err != nil {
+		return nil, err
+	}
+	if err := user.RegisterFinancialServiceHandler(ctx, mux, conn); err != nil {
+		return nil, err
+	}
+	if err := user.RegisterExchangeServiceHandler(ctx, mux, conn); err != nil {
+		return nil, err
+	}
+
+	handler := http.NewServeMux()
+	handler.HandleFunc(openAPIPath, serveOpenAPI)
+	handler.HandleFunc(livenessPath, serveProbe(probe.Live))
+	handler.HandleFunc(readinessPath, serveProbe(probe.Ready))
+	handler.Handle("/", withoutQueryToken(mux))
+	return handler, nil
+}
+
+// headerMatcher дополняет стандартные правила: Accept-Language и X-Request-Id
+// передаются как есть, чтобы язык ответа и идентификатор запроса работали
+// так же, как для gRPC-клиентов. Authorization grpc-gateway передает в metadata сам.
+func headerMatcher(key string) (string, bool) {
+	switch {
+	case strings.EqualFold(key, "Accept-Language"):
+		return "accept-language", true
+	case strings.EqualFold(key, "X-Request-Id"):
+		return "x-request-id", true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// outgoingHeaderMatcher возвращает идентификатор запроса заголовком X-Request-Id
+func outgoingHeaderMatcher(key string) (string, bool) {
+	if key == "x-request-id" {
+		return "X-Request-Id", true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
+
+// withoutQueryToken отбрасывает токен из параметров URL: адреса запросов попадают
+// в журналы доступа, поэтому токен принимается только заголовком Authorization
+// (или в теле POST-запроса)
+func withoutQueryToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if query := r.URL.Query(); query.Has(tokenParam) {
+			query.Del(tokenParam)
+			r.URL.RawQuery = query.Encode()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// openAPI — спецификация без параметра token в URL, который gateway не принимает.
+// protoc-gen-openapiv2 выводит его для GET-маршрутов из поля запроса.
+var openAPI = withoutTokenParam(proto.OpenAPI)
+
+func withoutTokenParam(spec []byte) []byte {
+	var doc map[string]any
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return spec
+	}
+	paths, _ := doc["paths"].(map[string]any)
+	for _, path := range paths {
+		operations, _ := path.(map[string]any)
+		for _, operation := range operations {
+			op, _ := operation.(map[string]any)
+			params, _ := op["parameters"].([]any)
+			if params == nil {
+				continue
+			}
+			kept := params[:0]
+			for _, param := range params {
+				if p, _ := param.(map[string]any); p["name"] == tokenParam && p["in"] == "query" {
+					continue
+				}
+				kept = append(kept, param)
+			}
+			op["parameters"] = kept
+		}
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return spec
+	}
+	return out
+}
+
+func serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPI)
+}
+
+func serveProbe(ok func() bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !ok() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}
+}
+
+func (a *App) MustRun() {
+	if err := a.Run(); err != nil {
+		panic(err)
+	}
+}
+
+func (a *App) Run() error {
+	const op = "gatewayapp.App.Run"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("port", a.port))
+
+	l, err := net.Listen("tcp", a.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("http gateway is starting", slog.String("addr", l.Addr().String()))
+
+	if err := a.httpServer.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (a *App) Stop() {
+	const op = "gatewayapp.Stop"
+
+	log := a.log.With(slog.String("op", op))
+	log.Info("http gateway is stopping", slog.Int("port", a.port))
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := a.httpServer.Shutdown(ctx); err != nil {
+		log.Error("http gateway shutdown", slog.String("error", err.Error()))
+	}
+	if err := a.conn.Close(); err != nil {
+		log.Error("grpc client close", slog.String("error", err.Error()))
+	}
+}
+
+// reloadingCreds берет TLS-конфигурацию заново для каждого соединения
+type reloadingCreds struct {
+	credentials.TransportCredentials
+	config func() *tls.Config
+}
+
+func (c reloadingCreds) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return credentials.NewTLS(c.config()).ClientHandshake(ctx, authority, conn)
+}
+
+func (c reloadingCreds) Clone() credentials.TransportCredentials {
+	return c
+}
@@ -0,0 +1,253 @@
+package gatewayapp
+
+import (
+	"context"
+	"encoding/json"
+	"main/proto/user"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestHeaderMatcher(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+		ok     bool
+	}{
+		{"Accept-Language", "accept-language", true},
+		{"accept-language", "accept-language", true},
+		{"X-Request-Id", "x-request-id", true},
+		{"X-REQUEST-ID", "x-request-id", true},
+		{"Grpc-Metadata-Tenant", "Tenant", true},
+		{"Authorization", runtime.MetadataPrefix + "Authorization", true},
+		{"X-Forwarded-Secret", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			got, ok := headerMatcher(tt.header)
+			if got != tt.want || ok != tt.ok {
+				t.Fatalf("headerMatcher(%q) = %q, %v; want %q, %v", tt.header, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestOutgoingHeaderMatcher(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"x-request-id", "X-Request-Id"},
+		{"retry-after", runtime.MetadataHeaderPrefix + "retry-after"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got, ok := outgoingHeaderMatcher(tt.key); got != tt.want || !ok {
+				t.Fatalf("outgoingHeaderMatcher(%q) = %q, %v; want %q, true", tt.key, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestServeProbe(t *testing.T) {
+	for _, ok := range []bool{true, false} {
+		rec := httptest.NewRecorder()
+		serveProbe(func() bool { return ok })(rec, httptest.NewRequest(http.MethodGet, readinessPath, nil))
+		want := http.StatusOK
+		if !ok {
+			want = http.StatusServiceUnavailable
+		}
+		if rec.Code != want {
+			t.Fatalf("probe %v: status = %d, want %d", ok, rec.Code, want)
+		}
+	}
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	rec := httptest.NewRecorder()
+	serveOpenAPI(rec, httptest.NewRequest(http.MethodGet, openAPIPath, nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("status = %d, content type = %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	var spec struct {
+		Swagger string `json:"swagger"`
+		Paths   map[string]map[string]struct {
+			Parameters []struct {
+				Name string `json:"name"`
+				In   string `json:"in"`
+			} `json:"parameters"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := spec.Paths["/v1/wallet/balance"]["get"]; spec.Swagger != "2.0" || !ok {
+		t.Fatalf("spec has no balance route: %s", rec.Body.String())
+	}
+	// Токен в URL не принимается, поэтому и в спецификации его нет
+	for path, operations := range spec.Paths {
+		for method, op := range operations {
+			for _, p := range op.Parameters {
+				if p.Name == tokenParam && p.In == "query" {
+					t.Fatalf("%s %s documents the token query parameter", method, path)
+				}
+			}
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	serveOpenAPI(rec, httptest.NewRequest(http.MethodPost, openAPIPath, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+// financial запоминает, что gRPC-сервер получил от gateway
+type financial struct {
+	user.UnimplementedFinancialServiceServer
+
+	mu       sync.Mutex
+	token    string
+	metadata metadata.MD
+}
+
+func (f *financial) record(ctx context.Context, token string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.token = token
+	f.metadata, _ = metadata.FromIncomingContext(ctx)
+}
+
+func (f *financial) GetBalance(ctx context.Context, req *user.GetBalanceRequest) (*user.BalanceResponse, error) {
+	f.record(ctx, req.GetToken())
+	md, _ := metadata.FromIncomingContext(ctx)
+	if len(md.Get("authorization")) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "token required")
+	}
+	grpc.SetHeader(ctx, metadata.Pairs("x-request-id", strings.Join(md.Get("x-request-id"), "")))
+	return &user.BalanceResponse{BalanceExact: map[string]string{"USD": "10.5"}}, nil
+}
+
+func (f *financial) Deposit(ctx context.Context, req *user.DepositRequest) (*user.WithdrawDepositResponse, error) {
+	f.record(ctx, req.GetToken())
+	if req.GetCurrency() != "USD" || req.GetAmountExact() != "5" {
+		return nil, status.Error(codes.InvalidArgument, "bad request")
+	}
+	return &user.WithdrawDepositResponse{NewBalanceExact: map[string]string{"USD": "15.5"}}, nil
+}
+
+type probe struct{ live, ready bool }
+
+func (p probe) Live() bool  { return p.live }
+func (p probe) Ready() bool { return p.ready }
+
+// serve поднимает gRPC-сервер на bufconn и возвращает gateway поверх него
+func serve(t *testing.T, srv *financial) http.Handler {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	user.RegisterFinancialServiceServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	handler, err := newHandler(context.Background(), conn, probe{live: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return handler
+}
+
+func TestGatewayRoundTrip(t *testing.T) {
+	srv := &financial{}
+	handler := serve(t, srv)
+
+	t.Run("get with headers", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/wallet/balance?token=leaked", nil)
+		req.Header.Set("Authorization", "Bearer abc")
+		req.Header.Set("Accept-Language", "en")
+		req.Header.Set("X-Request-Id", "req-1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			BalanceExact map[string]string `json:"balanceExact"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.BalanceExact["USD"] != "10.5" {
+			t.Fatalf("body = %s", rec.Body.String())
+		}
+		if rec.Header().Get("X-Request-Id") != "req-1" {
+			t.Fatalf("X-Request-Id = %q, want req-1", rec.Header().Get("X-Request-Id"))
+		}
+
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		if srv.token != "" {
+			t.Fatalf("token from URL reached the server: %q", srv.token)
+		}
+		for key, want := range map[string]string{"authorization": "Bearer abc", "accept-language": "en", "x-request-id": "req-1"} {
+			if got := srv.metadata.Get(key); len(got) != 1 || got[0] != want {
+				t.Fatalf("metadata %s = %v, want %q", key, got, want)
+			}
+		}
+	})
+
+	t.Run("post body", func(t *testing.T) {
+		body := `{"token":"from-body","currency":"USD","amountExact":"5"}`
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/wallet/deposit", strings.NewReader(body)))
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"15.5"`) {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+		}
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		if srv.token != "from-body" {
+			t.Fatalf("token = %q, want the one from the body", srv.token)
+		}
+	})
+
+	t.Run("grpc status to http", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/wallet/balance", nil))
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusUnauthorized, rec.Body.String())
+		}
+	})
+
+	t.Run("probes", func(t *testing.T) {
+		for path, want := range map[string]int{livenessPath: http.StatusOK, readinessPath: http.StatusServiceUnavailable} {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			if rec.Code != want {
+				t.Fatalf("%s status = %d, want %d", path, rec.Code, want)
+			}
+		}
+	})
+}
@@ -1,19 +1,42 @@
 package grpcapp
 
 import (
+	"crypto/tls"
 	"fmt"
 	"log/slog"
+	admingrpc "main/internal/grpc/admin"
 	authgrpc "main/internal/grpc/auth"
 	exchangegrpc "main/internal/grpc/exchange"
+	"main/internal/grpc/interceptors"
 	walletgrpc "main/internal/grpc/wallet"
+	webhookgrpc "main/internal/grpc/webhook"
 	"net"
+	"sort"
+	"time"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
+// Options — параметры gRPC-сервера
+type Options struct {
+	Heartbeat   time.Duration            // Интервал heartbeat потока курсов по умолчанию
+	Timeout     time.Duration            // Дедлайн unary-вызова, 0 — без ограничения
+	Timeouts    map[string]time.Duration // Дедлайны отдельных методов по полному имени
+	Reflection  bool                     // Регистрировать server reflection
+	TLS         *tls.Config              // nil — без шифрования
+	ClientRoles map[string]string        // Роли внутренних клиентов по идентификатору сертификата mTLS
+}
+
 type App struct {
 	log        *slog.Logger
 	gRPCServer *grpc.Server
+	health     *grpchealth.Server
+	services   []string
 	port       int
 }
 
@@ -21,20 +44,76 @@ func New(
 	log *slog.Logger,
 	auth authgrpc.Auth,
 	wall walletgrpc.Wallet,
+	portfolio walletgrpc.Portfolio,
 	exchange exchangegrpc.Exchange,
+	currencies exchangegrpc.Currencies,
+	admin admingrpc.Admin,
+	quarantine admingrpc.Quarantine,
+	webhooks webhookgrpc.Webhooks,
+	opts Options,
 	port int,
 ) *App {
-	gRPCServer := grpc.NewServer()
+	serverOpts := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			interceptors.Metrics(),
+			interceptors.ClientIdentity(opts.ClientRoles),
+			interceptors.BearerToken(),
+			interceptors.Logging(log),
+			interceptors.Language(),
+			interceptors.Deadline(opts.Timeout, opts.Timeouts),
+		),
+		grpc.ChainStreamInterceptor(
+			interceptors.MetricsStream(),
+			interceptors.ClientIdentityStream(opts.ClientRoles),
+			interceptors.BearerTokenStream(),
+			interceptors.LoggingStream(log),
+			interceptors.LanguageStream(),
+			interceptors.DeadlineStream(opts.Timeouts),
+		),
+	}
+	if opts.TLS != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(opts.TLS)))
+	}
+	gRPCServer := grpc.NewServer(serverOpts...)
 	authgrpc.RegisterUser(gRPCServer, auth)
-	walletgrpc.FinancialService(gRPCServer, wall)
-	exchangegrpc.ExchangeWallet(gRPCServer, exchange)
+	walletgrpc.FinancialService(gRPCServer, wall, portfolio)
+	exchangegrpc.ExchangeWallet(gRPCServer, exchange, currencies, opts.Heartbeat)
+	admingrpc.AdminService(gRPCServer, admin, quarantine)
+	webhookgrpc.WebhookService(gRPCServer, webhooks)
+
+	// Бизнес-сервисы — все, что зарегистрировано до служебных
+	var services []string
+	for name := range gRPCServer.GetServiceInfo() {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(gRPCServer, healthServer)
+	if opts.Reflection {
+		reflection.Register(gRPCServer)
+	}
+
 	return &App{
 		log:        log,
 		gRPCServer: gRPCServer,
+		health:     healthServer,
+		services:   services,
 		port:       port,
 	}
 }
 
+// Health возвращает сервис grpc.health.v1, статусы в нем выставляет health.Monitor
+func (a *App) Health() *grpchealth.Server {
+	return a.health
+}
+
+// Services возвращает имена зарегистрированных бизнес-сервисов
+func (a *App) Services() []string {
+	return a.services
+}
+
 func (a *App) MustRun() {
 	if err := a.Run(); err != nil {
 		panic(err)
@@ -0,0 +1,76 @@
+package metricsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"main/internal/metrics"
+	"net"
+	"net/http"
+	"time"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// App отдает метрики Prometheus на отдельном порту, чтобы они не были
+// доступны через публичный REST gateway.
+type App struct {
+	log        *slog.Logger
+	httpServer *http.Server
+	port       int
+}
+
+func New(log *slog.Logger, port int) *App {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	return &App{
+		log: log,
+		httpServer: &http.Server{
+			Addr:              fmt.Sprintf(":%d", port),
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+		port: port,
+	}
+}
+
+func (a *App) MustRun() {
+	if err := a.Run(); err != nil {
+		panic(err)
+	}
+}
+
+func (a *App) Run() error {
+	const op = "metricsapp.App.Run"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("port", a.port))
+
+	l, err := net.Listen("tcp", a.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("metrics server is starting", slog.String("addr", l.Addr().String()))
+
+	if err := a.httpServer.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (a *App) Stop() {
+	const op = "metricsapp.Stop"
+
+	log := a.log.With(slog.String("op", op))
+	log.Info("metrics server is stopping", slog.Int("port", a.port))
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := a.httpServer.Shutdown(ctx); err != nil {
+		log.Error("metrics server shutdown", slog.String("error", err.Error()))
+	}
+}
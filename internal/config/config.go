@@ -13,13 +13,121 @@ type Config struct {
 	Dev          string        `yaml:"dev"`
 	Storage      string        `yaml:"storage_path" env-required:"true"`
 	LocalStorage string        `yaml:"local_storage_path"`
+	Database     DBConfig      `yaml:"database"`
 	Token        time.Duration `yaml:"token_ttl" env-required:"true"`
+	AdminEmails  []string      `yaml:"admin_emails"`
 	GRPC         GRPCConfig    `yaml:"grpc"`
+	Gateway      GatewayConfig `yaml:"gateway"`
+	Health       HealthConfig  `yaml:"health"`
+	Metrics      MetricsConfig `yaml:"metrics"`
+	Tracing      TracingConfig `yaml:"tracing"`
+	Rates        RatesConfig   `yaml:"rates"`
+	Outbox       OutboxConfig  `yaml:"outbox"`
+	Webhooks     WebhookConfig `yaml:"webhooks"`
 }
 
 type GRPCConfig struct {
-	Port    int           `yaml:"port"`
-	Timeout time.Duration `yaml:"timeout"`
+	Port       int           `yaml:"port"`
+	Timeout    time.Duration `yaml:"timeout" env-default:"5s"` // Дедлайн unary-вызова, 0 — без ограничения
+	Reflection bool          `yaml:"reflection"`               // Server reflection для grpcurl, в проде выключено
+	TLS        TLSConfig     `yaml:"tls"`
+
+	MethodTimeouts map[string]time.Duration `yaml:"method_timeouts"` // Полное имя метода (/user.ExchangeService/ExchangeCurrency) -> дедлайн
+}
+
+type TLSConfig struct {
+	CertFile     string   `yaml:"cert_file"` // Пусто — TLS выключен
+	KeyFile      string   `yaml:"key_file"`
+	MinVersion   string   `yaml:"min_version" env-default:"1.2"` // 1.2 или 1.3
+	CipherSuites []string `yaml:"cipher_suites"`                 // Имена Go (TLS_ECDHE_...), пусто — по умолчанию
+
+	ClientCAFile string            `yaml:"client_ca_file"` // CA клиентских сертификатов для mTLS
+	ClientAuth   string            `yaml:"client_auth"`    // none, request, require; с CA по умолчанию require
+	ClientRoles  map[string]string `yaml:"client_roles"`   // Идентификатор сертификата (URI/DNS SAN, CN) -> роль
+
+	ReloadInterval time.Duration `yaml:"reload_interval" env-default:"30s"` // Период проверки файлов сертификатов
+
+	// REST gateway подключается к gRPC через loopback
+	CAFile          string `yaml:"ca_file"`                             // CA сертификата сервера; пусто — client_ca_file
+	ServerName      string `yaml:"server_name" env-default:"localhost"` // Имя в сертификате сервера для проверки gateway
+	GatewayCertFile string `yaml:"gateway_cert_file"`                   // Сертификат gateway для mTLS, не указывайте его в client_roles
+	GatewayKeyFile  string `yaml:"gateway_key_file"`
+}
+
+type DBConfig struct {
+	MaxOpenConns     int           `yaml:"max_open_conns" env-default:"20"`
+	MaxIdleConns     int           `yaml:"max_idle_conns" env-default:"5"`
+	ConnMaxLifetime  time.Duration `yaml:"conn_max_lifetime" env-default:"30m"`
+	ConnMaxIdleTime  time.Duration `yaml:"conn_max_idle_time" env-default:"5m"`
+	StatementTimeout time.Duration `yaml:"statement_timeout" env-default:"30s"` // Предел одного запроса на стороне базы, 0 — без ограничения
+
+	Replicas             []string      `yaml:"replicas"`                                // DSN реплик для чтения, пусто — только primary
+	ReplicaMaxLag        time.Duration `yaml:"replica_max_lag" env-default:"1s"`        // Реплика с большим отставанием не используется
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" env-default:"5s"` // Период проверки отставания
+	ReadYourWrites       time.Duration `yaml:"read_your_writes" env-default:"5s"`       // Сколько после записи читать данные пользователя с primary
+}
+
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env-default:"none"`           // none, stdout, otlp
+	Endpoint    string  `yaml:"endpoint" env-default:"localhost:4317"` // OTLP/gRPC коллектор
+	Insecure    bool    `yaml:"insecure"`                              // Без TLS до коллектора
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`          // Доля трассируемых запросов
+	ServiceName string  `yaml:"service_name" env-default:"gw-exchanger"`
+}
+
+type MetricsConfig struct {
+	Port int `yaml:"port" env-default:"9090"` // Порт /metrics, 0 — метрики не отдаются
+}
+
+type HealthConfig struct {
+	Interval    time.Duration `yaml:"interval" env-default:"10s"`     // Период проверки зависимостей
+	Timeout     time.Duration `yaml:"timeout" env-default:"2s"`       // Таймаут одной проверки
+	RatesMaxAge time.Duration `yaml:"rates_max_age" env-default:"5m"` // Курсы старше — ExchangeService NOT_SERVING, 0 — не проверять
+}
+
+type GatewayConfig struct {
+	Port int `yaml:"port" env-default:"8080"` // Порт HTTP/JSON gateway
+}
+
+type RatesConfig struct {
+	Pivot  string  `yaml:"pivot" env-default:"USD"` // Опорная валюта для кросс-курсов
+	Spread float64 `yaml:"spread"`                  // Спред для котировок без bid/ask (0.002 = 0.2%)
+	Fee    float64 `yaml:"fee"`                     // Комиссия обмена от полученной суммы (0.001 = 0.1%)
+
+	MaxDeviation  float64 `yaml:"max_deviation" env-default:"0.1"`   // Допустимое отклонение от предыдущего курса (0.1 = 10%)
+	MaxDivergence float64 `yaml:"max_divergence" env-default:"0.02"` // Допустимое расхождение между источниками (0.02 = 2%)
+
+	RefreshInterval  time.Duration `yaml:"refresh_interval" env-default:"1m"`   // Период обновления курсов, 0 — отключено
+	FetchTimeout     time.Duration `yaml:"fetch_timeout" env-default:"10s"`     // Таймаут запроса к источнику курсов
+	CacheTTL         time.Duration `yaml:"cache_ttl" env-default:"5s"`          // Время жизни кеша котировок и валют, 0 — без кеша
+	CacheLoadTimeout time.Duration `yaml:"cache_load_timeout" env-default:"5s"` // Предел загрузки валют и котировок в кеш из базы
+	Heartbeat        time.Duration `yaml:"heartbeat" env-default:"15s"`         // Интервал heartbeat потока курсов
+	StaleAfter       time.Duration `yaml:"stale_after" env-default:"10m"`       // Курс старше отмечается в оценке портфеля как устаревший
+}
+
+type OutboxConfig struct {
+	Publisher string        `yaml:"publisher" env-default:"log"` // Куда публиковать события: log, file, nats, kafka
+	Interval  time.Duration `yaml:"interval" env-default:"1s"`   // Период опроса outbox
+	BatchSize int           `yaml:"batch_size" env-default:"100"`
+
+	MaxAttempts int           `yaml:"max_attempts" env-default:"10"` // Попыток до переноса в dead letter
+	BackoffBase time.Duration `yaml:"backoff_base" env-default:"1s"` // Задержка перед второй попыткой, далее удваивается
+	BackoffMax  time.Duration `yaml:"backoff_max" env-default:"5m"`
+
+	FilePath     string   `yaml:"file_path" env-default:"outbox.jsonl"`
+	NATSURL      string   `yaml:"nats_url" env-default:"nats://localhost:4222"`
+	NATSSubject  string   `yaml:"nats_subject" env-default:"wallet.events"` // Префикс subject, полный — <prefix>.<Type>
+	KafkaBrokers []string `yaml:"kafka_brokers"`
+	KafkaTopic   string   `yaml:"kafka_topic" env-default:"wallet-events"`
+}
+
+type WebhookConfig struct {
+	Interval    time.Duration `yaml:"interval" env-default:"1s"`      // Период опроса очереди доставок
+	Timeout     time.Duration `yaml:"timeout" env-default:"10s"`      // Таймаут запроса к получателю
+	MaxAttempts int           `yaml:"max_attempts" env-default:"8"`   // Попыток до переноса в dead letter
+	BackoffBase time.Duration `yaml:"backoff_base" env-default:"10s"` // Задержка перед второй попыткой, далее удваивается
+	BackoffMax  time.Duration `yaml:"backoff_max" env-default:"1h"`
+	BatchSize   int           `yaml:"batch_size" env-default:"50"`
 }
 
 func MustLoad() *Config {
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Виды изменений баланса
+const (
+	BalanceDeposit  = "deposit"
+	BalanceWithdraw = "withdraw"
+	BalanceExchange = "exchange"
+	BalanceTransfer = "transfer"
+)
+
+// WalletChange — изменение одного кошелька
+type WalletChange struct {
+	WalletID uuid.UUID
+	Currency string
+	Amount   decimal.Decimal // Изменение со знаком
+	Balance  decimal.Decimal // Баланс кошелька после операции
+}
+
+// BalanceEvent — событие об изменении кошельков пользователя после фиксации операции
+type BalanceEvent struct {
+	UserID   uuid.UUID
+	Kind     string
+	Changes  []WalletChange
+	Balances map[string]decimal.Decimal // Все балансы пользователя после операции
+	At       time.Time
+}
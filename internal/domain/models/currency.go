@@ -0,0 +1,53 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+type Currency struct {
+	Code            string          `json:"code" gorm:"primaryKey"`                          // ISO код валюты (USD, EUR, RUB)
+	Name            string          `json:"name"`                                            // Название валюты
+	Decimals        int             `json:"decimals" gorm:"default:2"`                       // Количество знаков после запятой
+	Enabled         bool            `json:"enabled" gorm:"default:true"`                     // Валюта доступна пользователям
+	DepositEnabled  bool            `json:"deposit_enabled" gorm:"default:true"`             // Разрешено пополнение
+	WithdrawEnabled bool            `json:"withdraw_enabled" gorm:"default:true"`            // Разрешен вывод
+	ExchangeEnabled bool            `json:"exchange_enabled" gorm:"default:true"`            // Разрешен обмен
+	Kind            string          `json:"kind" gorm:"default:fiat"`                        // Тип актива (fiat, crypto)
+	MinAmount       decimal.Decimal `json:"min_amount" gorm:"type:numeric(38,18);default:0"` // Минимальная сумма операции
+	ProviderID      string          `json:"provider_id"`                                     // Идентификатор актива у источника курсов (bitcoin, ethereum)
+}
+
+// Типы активов
+const (
+	KindFiat   = "fiat"
+	KindCrypto = "crypto"
+)
+
+// Операции, которые можно запретить для валюты
+const (
+	OperationDeposit  = "deposit"
+	OperationWithdraw = "withdraw"
+	OperationExchange = "exchange"
+	OperationTransfer = "transfer" // Перевод между кошельками пользователя, доступен для любой включенной валюты
+)
+
+// Allows сообщает, доступна ли операция для валюты
+func (c Currency) Allows(operation string) bool {
+	if !c.Enabled {
+		return false
+	}
+	switch operation {
+	case OperationDeposit:
+		return c.DepositEnabled
+	case OperationWithdraw:
+		return c.WithdrawEnabled
+	case OperationExchange:
+		return c.ExchangeEnabled
+	case OperationTransfer:
+		return true
+	}
+	return false
+}
+
+// Truncate отбрасывает знаки сверх точности актива
+func (c Currency) Truncate(amount decimal.Decimal) decimal.Decimal {
+	return amount.Truncate(int32(c.Decimals))
+}
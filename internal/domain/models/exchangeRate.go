@@ -1,6 +1,46 @@
 package models
 
-type ExchangeRate struct {
-	Currency  string  `json:"currency" gorm:"primaryKey"` // Валюта (например, USD)
-	RateToUSD float32 `json:"rate_to_usd"`                // Курс относительно базовой валюты USD
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// RateQuote — котировка пары: 1 Base = Bid/Ask единиц Quote
+type RateQuote struct {
+	Base      string          `json:"base" gorm:"primaryKey"`         // Базовый актив пары (например, BTC)
+	Quote     string          `json:"quote" gorm:"primaryKey"`        // Котируемый актив пары (например, EUR)
+	Bid       decimal.Decimal `json:"bid" gorm:"type:numeric(38,18)"` // Цена, по которой покупается Base
+	Ask       decimal.Decimal `json:"ask" gorm:"type:numeric(38,18)"` // Цена, по которой продается Base
+	Source    string          `json:"source"`                         // Источник котировки
+	UpdatedAt time.Time       `json:"updated_at"`                     // Время получения котировки
+}
+
+// Mid возвращает среднюю цену пары
+func (q RateQuote) Mid() decimal.Decimal {
+	return q.Bid.Add(q.Ask).Div(decimal.NewFromInt(2))
+}
+
+// Conversion — результат расчета курса обмена From -> To
+type Conversion struct {
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	Bid       decimal.Decimal `json:"bid"`        // Сколько To получит клиент за 1 From
+	Ask       decimal.Decimal `json:"ask"`        // Сколько To клиент заплатит за 1 From
+	Path      []string        `json:"path"`       // Цепочка валют, через которую посчитан курс
+	Sources   []string        `json:"sources"`    // Источники использованных котировок
+	UpdatedAt time.Time       `json:"updated_at"` // Время самой старой из использованных котировок
+}
+
+// Mid возвращает среднюю цену
+func (c Conversion) Mid() decimal.Decimal {
+	return c.Bid.Add(c.Ask).Div(decimal.NewFromInt(2))
+}
+
+// ExchangeResult — результат обмена валют
+type ExchangeResult struct {
+	Amount     decimal.Decimal            // Сколько получено в целевой валюте
+	Fee        decimal.Decimal            // Удержанная комиссия в целевой валюте
+	Balance    map[string]decimal.Decimal // Баланс после обмена
+	Conversion Conversion                 // Использованный курс
 }
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Типы доменных событий
+const (
+	EventWalletCredited    = "WalletCredited"
+	EventWalletDebited     = "WalletDebited"
+	EventCurrencyExchanged = "CurrencyExchanged"
+	EventWalletTransferred = "WalletTransferred"
+	EventUserRegistered    = "UserRegistered"
+)
+
+// OutboxEvent — доменное событие, записанное в outbox в одной транзакции с изменением данных.
+// Запись событий пользователя сериализуется блокировкой до коммита транзакции,
+// поэтому ID его событий растут в порядке коммита и Relay публикует их по порядку.
+type OutboxEvent struct {
+	ID            uint64     `json:"id" gorm:"primaryKey"`
+	AggregateID   uuid.UUID  `json:"aggregate_id" gorm:"type:uuid;index"` // Пользователь, к которому относится событие
+	Type          string     `json:"type"`
+	Payload       []byte     `json:"payload" gorm:"type:jsonb"`
+	CreatedAt     time.Time  `json:"created_at"`
+	PublishedAt   *time.Time `json:"published_at" gorm:"index"`
+	Attempts      int        `json:"attempts" gorm:"default:0"` // Неудачные попытки публикации
+	LastError     string     `json:"last_error"`
+	NextAttemptAt *time.Time `json:"next_attempt_at"` // Не публиковать раньше, NULL — сразу
+	DeadAt        *time.Time `json:"dead_at"`         // Перенесено в dead letter после MaxAttempts неудач
+}
+
+// WalletCredited — зачисление на кошелек
+type WalletCredited struct {
+	UserID   uuid.UUID       `json:"user_id"`
+	WalletID uuid.UUID       `json:"wallet_id"`
+	Currency string          `json:"currency"`
+	Amount   decimal.Decimal `json:"amount"`
+	Balance  decimal.Decimal `json:"balance"` // Баланс кошелька после операции
+}
+
+// WalletDebited — списание с кошелька
+type WalletDebited struct {
+	UserID   uuid.UUID       `json:"user_id"`
+	WalletID uuid.UUID       `json:"wallet_id"`
+	Currency string          `json:"currency"`
+	Amount   decimal.Decimal `json:"amount"`
+	Balance  decimal.Decimal `json:"balance"` // Баланс кошелька после операции
+}
+
+// CurrencyExchanged — обмен валют
+type CurrencyExchanged struct {
+	UserID       uuid.UUID       `json:"user_id"`
+	FromCurrency string          `json:"from_currency"`
+	ToCurrency   string          `json:"to_currency"`
+	Amount       decimal.Decimal `json:"amount"`   // Списано в исходной валюте
+	Received     decimal.Decimal `json:"received"` // Зачислено в целевой валюте
+	Fee          decimal.Decimal `json:"fee"`      // Комиссия в целевой валюте, уже вычтена из received
+	Rate         decimal.Decimal `json:"rate"`     // Использованный курс (bid)
+	Path         []string        `json:"path"`
+	Sources      []string        `json:"sources"`
+	FromWalletID uuid.UUID       `json:"from_wallet_id"`
+	ToWalletID   uuid.UUID       `json:"to_wallet_id"`
+}
+
+// WalletTransferred — перевод между кошельками пользователя в одной валюте
+type WalletTransferred struct {
+	UserID       uuid.UUID       `json:"user_id"`
+	Currency     string          `json:"currency"`
+	Amount       decimal.Decimal `json:"amount"`
+	FromWalletID uuid.UUID       `json:"from_wallet_id"`
+	ToWalletID   uuid.UUID       `json:"to_wallet_id"`
+}
+
+// UserRegistered — регистрация пользователя
+type UserRegistered struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+	Email    string    `json:"email"`
+	Language string    `json:"language,omitempty"`
+}
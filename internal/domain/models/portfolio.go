@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Portfolio — оценка всех кошельков пользователя в отчетной валюте
+type Portfolio struct {
+	Currency string          `json:"currency"` // Отчетная валюта
+	Total    decimal.Decimal `json:"total"`    // Сумма оценок валют, для которых нашелся курс
+	Holdings []Holding       `json:"holdings"` // Оценка по валютам, по убыванию стоимости
+	At       time.Time       `json:"at"`       // Момент, на который взяты курсы
+}
+
+// Holding — баланс пользователя в одной валюте и его оценка в отчетной валюте
+type Holding struct {
+	Currency      string          `json:"currency"`
+	Balance       decimal.Decimal `json:"balance"`         // Сумма всех кошельков в валюте
+	Rate          decimal.Decimal `json:"rate"`            // Средний курс: единиц отчетной валюты за 1 единицу валюты
+	Value         decimal.Decimal `json:"value"`           // Стоимость в отчетной валюте
+	Share         decimal.Decimal `json:"share"`           // Доля в портфеле, %
+	RateUpdatedAt time.Time       `json:"rate_updated_at"` // Время самой старой котировки, по которой посчитан курс
+	Stale         bool            `json:"stale"`           // Курс устарел или не найден; без курса валюта не входит в итог
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Причины задержки котировки
+const (
+	ReasonNonPositive = "non_positive" // Нулевая или отрицательная цена, bid больше ask
+	ReasonDeviation   = "deviation"    // Слишком сильное отклонение от предыдущего значения
+	ReasonDivergence  = "divergence"   // Источники расходятся в цене пары
+	ReasonStale       = "stale"        // Котировка старше уже сохраненной котировки пары
+)
+
+// Статусы задержанной котировки
+const (
+	QuarantinePending  = "pending"  // Ожидает решения, обмен по паре остановлен
+	QuarantineApproved = "approved" // Подтверждена администратором и применена
+	QuarantineRejected = "rejected" // Отклонена
+)
+
+// QuarantinedQuote — котировка, не прошедшая проверки и не попавшая в rate_quotes.
+// Пока по паре есть котировки в статусе pending, обмен по ней остановлен.
+type QuarantinedQuote struct {
+	ID          uint64          `json:"id" gorm:"primaryKey"`
+	Base        string          `json:"base" gorm:"index:idx_quarantine_pair"`
+	Quote       string          `json:"quote" gorm:"index:idx_quarantine_pair"`
+	Bid         decimal.Decimal `json:"bid" gorm:"type:numeric(38,18)"`
+	Ask         decimal.Decimal `json:"ask" gorm:"type:numeric(38,18)"`
+	Source      string          `json:"source"`
+	PreviousMid decimal.Decimal `json:"previous_mid" gorm:"type:numeric(38,18);default:0"` // Последняя принятая средняя цена
+	Reason      string          `json:"reason"`
+	Status      string          `json:"status" gorm:"index;default:pending"`
+	CreatedAt   time.Time       `json:"created_at"`
+	ResolvedAt  *time.Time      `json:"resolved_at"`
+	ResolvedBy  string          `json:"resolved_by"` // Email администратора
+}
+
+// RateQuote возвращает котировку для записи в rate_quotes
+func (q QuarantinedQuote) RateQuote() RateQuote {
+	return RateQuote{Base: q.Base, Quote: q.Quote, Bid: q.Bid, Ask: q.Ask, Source: q.Source, UpdatedAt: q.CreatedAt}
+}
@@ -7,5 +7,13 @@ type User struct {
 	Username string       `json:"username" gorm:"unique"`
 	Email    string       `json:"email" gorm:"unique"`
 	PassHash []byte       `json:"password"`
-	Balances []UserWallet `json:"balances" gorm:"foreignKey:UserID"`
+	Language string       `json:"language"`                 // Предпочитаемый язык сообщений (ru, en)
+	Role     string       `json:"role" gorm:"default:user"` // Роль пользователя (user, admin)
+	Balances []UserWallet `json:"balances" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
+
+// Роли пользователей
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
@@ -1,10 +1,28 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
 
+// UserWallet — кошелек пользователя. В каждой валюте у пользователя есть основной
+// кошелек без названия; дополнительные кошельки пользователь создает сам.
 type UserWallet struct {
-	ID       uuid.UUID `json:"id" gorm:"primaryKey"`
-	UserID   uuid.UUID `json:"user_id" gorm:"index"`                                                           // Внешний ключ на пользователя
-	Currency string    `json:"currency"`                                                                       // Валюта (USD, EUR, RUB)
-	Balance  float32   `json:"balance" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Баланс в конкретной валюте
+	ID       uuid.UUID       `json:"id" gorm:"primaryKey"`
+	UserID   uuid.UUID       `json:"user_id" gorm:"not null;index"`                                                                       // Внешний ключ на пользователя
+	Currency string          `json:"currency" gorm:"not null"`                                                                            // Валюта (USD, EUR, RUB)
+	Balance  decimal.Decimal `json:"balance" gorm:"type:numeric(38,18);not null;check:ck_user_wallets_balance_non_negative,balance >= 0"` // Баланс в конкретной валюте
+	Name     string          `json:"name" gorm:"not null;default:''"`                                                                     // Название дополнительного кошелька, у основного пусто
+	Archived bool            `json:"archived" gorm:"not null;default:false"`                                                              // Кошелек в архиве: операции по нему запрещены
+}
+
+// Main сообщает, что кошелек основной для своей валюты
+func (w UserWallet) Main() bool {
+	return w.Name == ""
+}
+
+// TransferResult — кошельки после перевода между ними
+type TransferResult struct {
+	From UserWallet
+	To   UserWallet
 }
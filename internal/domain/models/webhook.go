@@ -0,0 +1,78 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// События, на которые можно подписать webhook
+var WebhookEventTypes = []string{EventWalletCredited, EventWalletDebited, EventCurrencyExchanged, EventWalletTransferred}
+
+// Статусы доставки webhook
+const (
+	DeliveryPending   = "pending"   // Ожидает отправки или повторной попытки
+	DeliveryDelivered = "delivered" // Получатель ответил 2xx
+	DeliveryDead      = "dead"      // Попытки исчерпаны (dead letter)
+)
+
+// Webhook — подписка пользователя на события его счета
+type Webhook struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID     uuid.UUID `json:"user_id" gorm:"type:uuid;index"`
+	URL        string    `json:"url"`
+	EventTypes string    `json:"event_types"` // Типы событий через запятую, пусто — все
+	Secret     string    `json:"-"`           // Ключ подписи HMAC-SHA256
+	Active     bool      `json:"active" gorm:"default:true"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Types возвращает типы событий подписки
+func (w Webhook) Types() []string {
+	if w.EventTypes == "" {
+		return nil
+	}
+	return strings.Split(w.EventTypes, ",")
+}
+
+// Accepts проверяет, подписан ли webhook на событие
+func (w Webhook) Accepts(eventType string) bool {
+	return w.EventTypes == "" || slices.Contains(w.Types(), eventType)
+}
+
+// WebhookDelivery — отправка одного события на один webhook
+type WebhookDelivery struct {
+	ID             uint64     `json:"id" gorm:"primaryKey"`
+	WebhookID      uuid.UUID  `json:"webhook_id" gorm:"type:uuid;index"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;index"`
+	EventID        uint64     `json:"event_id"` // Событие outbox
+	EventType      string     `json:"event_type"`
+	Payload        []byte     `json:"payload" gorm:"type:jsonb"`
+	Status         string     `json:"status" gorm:"index;default:pending"`
+	Attempts       int        `json:"attempts" gorm:"default:0"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"index"`
+	LastStatusCode int        `json:"last_status_code"`
+	LastError      string     `json:"last_error"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
+
+// WebhookAttempt — запись журнала о попытке доставки
+type WebhookAttempt struct {
+	ID         uint64    `json:"id" gorm:"primaryKey"`
+	DeliveryID uint64    `json:"delivery_id" gorm:"index"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookJob — доставка вместе с адресом и ключом подписи
+type WebhookJob struct {
+	Delivery WebhookDelivery
+	URL      string
+	Secret   string
+}
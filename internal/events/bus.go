@@ -0,0 +1,123 @@
+package events
+
+import (
+	"main/internal/domain/models"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Размер очереди подписки по умолчанию
+const defaultBuffer = 16
+
+// Bus — внутрипроцессная шина событий об изменении баланса.
+// Сервисы публикуют события после фиксации операции, подписчики получают
+// только события своего пользователя. Публикация не блокируется: если клиент
+// не успевает читать, из его очереди вытесняется самое старое событие —
+// в каждом событии есть полный баланс, поэтому последнее состояние не теряется.
+type Bus struct {
+	buffer int
+
+	mu   sync.RWMutex
+	subs map[uuid.UUID]map[*Subscription]struct{}
+}
+
+func NewBus(buffer int) *Bus {
+	if buffer <= 0 {
+		buffer = defaultBuffer
+	}
+	return &Bus{
+		buffer: buffer,
+		subs:   make(map[uuid.UUID]map[*Subscription]struct{}),
+	}
+}
+
+// Publish доставляет событие подписчикам пользователя
+func (b *Bus) Publish(event models.BalanceEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subs[event.UserID] {
+		sub.deliver(event)
+	}
+}
+
+// Subscribe создает подписку на события пользователя
+func (b *Bus) Subscribe(userID uuid.UUID) *Subscription {
+	sub := &Subscription{
+		bus:    b,
+		userID: userID,
+		events: make(chan models.BalanceEvent, b.buffer),
+	}
+
+	b.mu.Lock()
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[*Subscription]struct{})
+	}
+	b.subs[userID][sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// Subscribers возвращает число активных подписок пользователя
+func (b *Bus) Subscribers(userID uuid.UUID) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs[userID])
+}
+
+func (b *Bus) unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.subs[sub.userID], sub)
+	if len(b.subs[sub.userID]) == 0 {
+		delete(b.subs, sub.userID)
+	}
+}
+
+// Subscription — подписка на события одного пользователя
+type Subscription struct {
+	bus    *Bus
+	userID uuid.UUID
+	events chan models.BalanceEvent
+
+	mu      sync.Mutex
+	dropped int
+}
+
+// Events возвращает канал событий
+func (s *Subscription) Events() <-chan models.BalanceEvent {
+	return s.events
+}
+
+// Dropped возвращает число событий, вытесненных из-за медленного чтения
+func (s *Subscription) Dropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Close отменяет подписку
+func (s *Subscription) Close() {
+	s.bus.unsubscribe(s)
+}
+
+func (s *Subscription) deliver(event models.BalanceEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		select {
+		case s.events <- event:
+			return
+		default:
+		}
+		// Очередь заполнена: освобождаем место под новое событие
+		select {
+		case <-s.events:
+			s.dropped++
+		default:
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package events_test
+
+import (
+	"main/internal/domain/models"
+	"main/internal/events"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func event(userID uuid.UUID, balance int64) models.BalanceEvent {
+	return models.BalanceEvent{
+		UserID:   userID,
+		Kind:     models.BalanceDeposit,
+		Balances: map[string]decimal.Decimal{"USD": decimal.NewFromInt(balance)},
+		At:       time.Now(),
+	}
+}
+
+func receive(t *testing.T, sub *events.Subscription) models.BalanceEvent {
+	t.Helper()
+	select {
+	case e := <-sub.Events():
+		return e
+	default:
+		t.Fatal("no event")
+		return models.BalanceEvent{}
+	}
+}
+
+func TestBusFanOut(t *testing.T) {
+	bus := events.NewBus(0)
+	alice, bob := uuid.New(), uuid.New()
+	first := bus.Subscribe(alice)
+	second := bus.Subscribe(alice)
+	other := bus.Subscribe(bob)
+	defer first.Close()
+	defer second.Close()
+	defer other.Close()
+
+	bus.Publish(event(alice, 10))
+
+	// Событие получают все подписки пользователя и только они
+	for _, sub := range []*events.Subscription{first, second} {
+		if e := receive(t, sub); !e.Balances["USD"].Equal(decimal.NewFromInt(10)) {
+			t.Fatalf("event = %+v", e)
+		}
+	}
+	select {
+	case e := <-other.Events():
+		t.Fatalf("other user received %+v", e)
+	default:
+	}
+}
+
+func TestBusSlowSubscriber(t *testing.T) {
+	bus := events.NewBus(2)
+	userID := uuid.New()
+	sub := bus.Subscribe(userID)
+	defer sub.Close()
+
+	// Публикация не блокируется: из полной очереди вытесняются старые события
+	done := make(chan struct{})
+	go func() {
+		for i := range int64(5) {
+			bus.Publish(event(userID, i))
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a slow subscriber")
+	}
+
+	if sub.Dropped() != 3 {
+		t.Fatalf("dropped = %d, want 3", sub.Dropped())
+	}
+	for _, want := range []int64{3, 4} {
+		if e := receive(t, sub); !e.Balances["USD"].Equal(decimal.NewFromInt(want)) {
+			t.Fatalf("event balance = %s, want %d", e.Balances["USD"], want)
+		}
+	}
+}
+
+func TestBusClose(t *testing.T) {
+	bus := events.NewBus(0)
+	userID := uuid.New()
+	closed := bus.Subscribe(userID)
+	open := bus.Subscribe(userID)
+	defer open.Close()
+
+	closed.Close()
+	if bus.Subscribers(userID) != 1 {
+		t.Fatalf("subscribers = %d, want 1", bus.Subscribers(userID))
+	}
+	bus.Publish(event(userID, 1))
+	select {
+	case e := <-closed.Events():
+		t.Fatalf("closed subscription received %+v", e)
+	default:
+	}
+	receive(t, open)
+
+	// Повторное закрытие и публикация без подписчиков безопасны
+	closed.Close()
+	open.Close()
+	bus.Publish(event(userID, 2))
+	if bus.Subscribers(userID) != 0 {
+		t.Fatalf("subscribers = %d, want 0", bus.Subscribers(userID))
+	}
+}
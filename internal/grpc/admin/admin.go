@@ -0,0 +1,146 @@
+package admin
+
+import (
+	"context"
+	"main/internal/domain/models"
+	"main/internal/grpc/convert"
+	"main/internal/grpc/grpcerr"
+	"main/internal/lib/i18n"
+	"main/internal/lib/identity"
+	"main/proto/user"
+
+	"google.golang.org/grpc"
+)
+
+type Admin interface {
+	AddCurrency(ctx context.Context, token string, currency models.Currency) (string, models.Currency, error)
+	SetCurrencyEnabled(ctx context.Context, token string, code string, enabled bool) (string, models.Currency, error)
+}
+
+type Quarantine interface {
+	ListQuarantinedQuotes(ctx context.Context, token string, all bool) ([]models.QuarantinedQuote, error)
+	ResolveQuarantinedQuote(ctx context.Context, token string, id uint64, approve bool) (string, models.QuarantinedQuote, error)
+}
+
+type adminAPI struct {
+	user.UnimplementedAdminServiceServer
+	admin      Admin
+	quarantine Quarantine
+}
+
+func AdminService(gRPC *grpc.Server, admin Admin, quarantine Quarantine) {
+	user.RegisterAdminServiceServer(gRPC, &adminAPI{admin: admin, quarantine: quarantine})
+}
+
+func (a *adminAPI) AddCurrency(
+	ctx context.Context,
+	req *user.AddCurrencyRequest,
+) (*user.CurrencyResponse, error) {
+	if missingToken(ctx, req.GetToken()) {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
+	}
+	if req.GetCurrency().GetCode() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrCurrencyCodeEmpty)
+	}
+
+	model, err := convert.CurrencyModel(req.GetCurrency())
+	if err != nil {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrInvalidAmount)
+	}
+
+	message, currency, err := a.admin.AddCurrency(ctx, req.GetToken(), model)
+	if err != nil {
+		return nil, grpcerr.Status(ctx, err)
+	}
+
+	return &user.CurrencyResponse{
+		Message:  message,
+		Currency: convert.Currency(currency),
+	}, nil
+}
+
+func (a *adminAPI) DisableCurrency(
+	ctx context.Context,
+	req *user.CurrencyStatusRequest,
+) (*user.CurrencyResponse, error) {
+	return a.setEnabled(ctx, req, false)
+}
+
+func (a *adminAPI) EnableCurrency(
+	ctx context.Context,
+	req *user.CurrencyStatusRequest,
+) (*user.CurrencyResponse, error) {
+	return a.setEnabled(ctx, req, true)
+}
+
+func (a *adminAPI) setEnabled(
+	ctx context.Context,
+	req *user.CurrencyStatusRequest,
+	enabled bool,
+) (*user.CurrencyResponse, error) {
+	if missingToken(ctx, req.GetToken()) {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
+	}
+	if req.GetCode() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrCurrencyCodeEmpty)
+	}
+
+	message, currency, err := a.admin.SetCurrencyEnabled(ctx, req.GetToken(), req.GetCode(), enabled)
+	if err != nil {
+		return nil, grpcerr.Status(ctx, err)
+	}
+
+	return &user.CurrencyResponse{
+		Message:  message,
+		Currency: convert.Currency(currency),
+	}, nil
+}
+
+func (a *adminAPI) ListQuarantinedQuotes(
+	ctx context.Context,
+	req *user.QuarantineListRequest,
+) (*user.QuarantineListResponse, error) {
+	if missingToken(ctx, req.GetToken()) {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
+	}
+
+	quotes, err := a.quarantine.ListQuarantinedQuotes(ctx, req.GetToken(), req.GetAll())
+	if err != nil {
+		return nil, grpcerr.Status(ctx, err)
+	}
+
+	return &user.QuarantineListResponse{
+		Quotes: convert.QuarantinedQuotes(quotes),
+	}, nil
+}
+
+func (a *adminAPI) ResolveQuarantinedQuote(
+	ctx context.Context,
+	req *user.ResolveQuoteRequest,
+) (*user.ResolveQuoteResponse, error) {
+	if missingToken(ctx, req.GetToken()) {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
+	}
+	if req.GetId() == 0 {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrQuoteIDEmpty)
+	}
+
+	message, quote, err := a.quarantine.ResolveQuarantinedQuote(ctx, req.GetToken(), req.GetId(), req.GetApprove())
+	if err != nil {
+		return nil, grpcerr.Status(ctx, err)
+	}
+
+	return &user.ResolveQuoteResponse{
+		Message: message,
+		Quote:   convert.QuarantinedQuote(quote),
+	}, nil
+}
+
+// missingToken сообщает, что вызывающий не передал токен и не опознан по сертификату mTLS
+func missingToken(ctx context.Context, token string) bool {
+	if token != "" {
+		return false
+	}
+	_, ok := identity.From(ctx)
+	return !ok
+}
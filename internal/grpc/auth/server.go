@@ -2,13 +2,11 @@ package auth
 
 import (
 	"context"
-	"errors"
-	"main/internal/storage"
+	"main/internal/grpc/grpcerr"
+	"main/internal/lib/i18n"
+	user "main/proto/user"
 
-	user "github.com/Vesoboy/proto-exchange/v2/user"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type Auth interface {
@@ -38,18 +36,15 @@ func (s *serverAPI) LoginUser(
 	req *user.LoginRequest,
 ) (*user.LoginResponse, error) {
 	if req.GetEmail() == "" {
-		return nil, status.Error(codes.InvalidArgument, "email is empty")
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrEmailEmpty)
 	}
 	if req.GetPassword() == "" {
-		return nil, status.Error(codes.InvalidArgument, "password is empty")
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrPasswordEmpty)
 	}
 
 	token, err := s.auth.LoginUser(ctx, req.GetEmail(), req.GetPassword())
 	if err != nil {
-		if errors.Is(err, storage.ErrInvalidCredentials) {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
-		}
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, grpcerr.Status(ctx, err)
 	}
 
 	return &user.LoginResponse{
@@ -63,24 +58,21 @@ func (s *serverAPI) RegisterUser(
 ) (*user.RegisterResponse, error) {
 
 	if req.GetUsername() == "" {
-		return nil, status.Error(codes.InvalidArgument, "username is empty")
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrUsernameEmpty)
 	}
 	if req.GetEmail() == "" {
-		return nil, status.Error(codes.InvalidArgument, "email is empty")
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrEmailEmpty)
 	}
 	if req.GetPassword() == "" {
-		return nil, status.Error(codes.InvalidArgument, "password is empty")
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrPasswordEmpty)
 	}
 
-	uid, err := s.auth.RegisterUser(ctx, req.GetUsername(), req.GetEmail(), req.GetPassword())
+	message, err := s.auth.RegisterUser(ctx, req.GetUsername(), req.GetEmail(), req.GetPassword())
 	if err != nil {
-		if errors.Is(err, storage.ErrUserExists) {
-			return nil, status.Error(codes.AlreadyExists, err.Error())
-		}
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, grpcerr.Status(ctx, err)
 	}
 
 	return &user.RegisterResponse{
-		Message: uid,
+		Message: message,
 	}, nil
 }
@@ -0,0 +1,71 @@
+package convert
+
+import (
+	"main/internal/domain/models"
+	"main/proto/user"
+)
+
+// BalanceEvent переводит событие изменения баланса в сообщение API
+func BalanceEvent(e models.BalanceEvent, kind user.BalanceEvent_Kind) *user.BalanceEvent {
+	approx, exact := Balances(e.Balances)
+	msg := &user.BalanceEvent{
+		Kind:         kind,
+		Balance:      approx,
+		BalanceExact: exact,
+		Timestamp:    e.At.Unix(),
+	}
+	for _, c := range e.Changes {
+		msg.Changes = append(msg.Changes, &user.WalletChange{
+			WalletId: c.WalletID.String(),
+			Currency: c.Currency,
+			Amount:   c.Amount.String(),
+			Balance:  c.Balance.String(),
+		})
+	}
+	return msg
+}
+
+// Wallet переводит кошелек пользователя в сообщение API
+func Wallet(w models.UserWallet) *user.UserWallet {
+	return &user.UserWallet{
+		Id:       w.ID.String(),
+		Currency: w.Currency,
+		Name:     w.Name,
+		Balance:  w.Balance.String(),
+		Archived: w.Archived,
+	}
+}
+
+// Wallets переводит список кошельков пользователя
+func Wallets(ws []models.UserWallet) []*user.UserWallet {
+	res := make([]*user.UserWallet, 0, len(ws))
+	for _, w := range ws {
+		res = append(res, Wallet(w))
+	}
+	return res
+}
+
+// Portfolio переводит оценку портфеля в сообщение API
+func Portfolio(p models.Portfolio) *user.PortfolioResponse {
+	res := &user.PortfolioResponse{
+		Currency: p.Currency,
+		Total:    p.Total.String(),
+		Holdings: make([]*user.Holding, 0, len(p.Holdings)),
+		At:       p.At.Unix(),
+	}
+	for _, h := range p.Holdings {
+		holding := &user.Holding{
+			Currency: h.Currency,
+			Balance:  h.Balance.String(),
+			Rate:     h.Rate.String(),
+			Value:    h.Value.String(),
+			Share:    h.Share.String(),
+			Stale:    h.Stale,
+		}
+		if !h.RateUpdatedAt.IsZero() {
+			holding.RateUpdatedAt = h.RateUpdatedAt.Unix()
+		}
+		res.Holdings = append(res.Holdings, holding)
+	}
+	return res
+}
@@ -0,0 +1,57 @@
+package convert
+
+import (
+	"main/internal/domain/models"
+	"main/proto/user"
+
+	"github.com/shopspring/decimal"
+)
+
+// Currency переводит валюту справочника в сообщение API
+func Currency(c models.Currency) *user.Currency {
+	return &user.Currency{
+		Code:            c.Code,
+		Name:            c.Name,
+		Decimals:        int32(c.Decimals),
+		Enabled:         c.Enabled,
+		DepositEnabled:  c.DepositEnabled,
+		WithdrawEnabled: c.WithdrawEnabled,
+		ExchangeEnabled: c.ExchangeEnabled,
+		Kind:            c.Kind,
+		MinAmount:       c.MinAmount.String(),
+		ProviderId:      c.ProviderID,
+	}
+}
+
+// Currencies переводит список валют справочника в сообщения API
+func Currencies(cs []models.Currency) []*user.Currency {
+	res := make([]*user.Currency, 0, len(cs))
+	for _, c := range cs {
+		res = append(res, Currency(c))
+	}
+	return res
+}
+
+// CurrencyModel переводит сообщение API в валюту справочника
+func CurrencyModel(c *user.Currency) (models.Currency, error) {
+	minAmount := decimal.Zero
+	if c.GetMinAmount() != "" {
+		var err error
+		if minAmount, err = decimal.NewFromString(c.GetMinAmount()); err != nil {
+			return models.Currency{}, err
+		}
+	}
+
+	return models.Currency{
+		Code:            c.GetCode(),
+		Name:            c.GetName(),
+		Decimals:        int(c.GetDecimals()),
+		Enabled:         c.GetEnabled(),
+		DepositEnabled:  c.GetDepositEnabled(),
+		WithdrawEnabled: c.GetWithdrawEnabled(),
+		ExchangeEnabled: c.GetExchangeEnabled(),
+		Kind:            c.GetKind(),
+		MinAmount:       minAmount,
+		ProviderID:      c.GetProviderId(),
+	}, nil
+}
@@ -0,0 +1,31 @@
+package convert
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Amount возвращает сумму из запроса: десятичная строка имеет приоритет над float
+func Amount(exact string, approx float32) (decimal.Decimal, error) {
+	if exact = strings.TrimSpace(exact); exact != "" {
+		return decimal.NewFromString(exact)
+	}
+	return decimal.NewFromFloat32(approx), nil
+}
+
+// Money переводит сумму в приближенное и точное представления API
+func Money(amount decimal.Decimal) (float32, string) {
+	f, _ := amount.Float64()
+	return float32(f), amount.String()
+}
+
+// Balances переводит балансы (или курсы) в приближенное и точное представления API
+func Balances(balances map[string]decimal.Decimal) (map[string]float32, map[string]string) {
+	approx := make(map[string]float32, len(balances))
+	exact := make(map[string]string, len(balances))
+	for currency, amount := range balances {
+		approx[currency], exact[currency] = Money(amount)
+	}
+	return approx, exact
+}
@@ -0,0 +1,36 @@
+package convert
+
+import (
+	"main/internal/domain/models"
+	"main/proto/user"
+)
+
+// QuarantinedQuote переводит задержанную котировку в сообщение API
+func QuarantinedQuote(q models.QuarantinedQuote) *user.QuarantinedQuote {
+	msg := &user.QuarantinedQuote{
+		Id:          q.ID,
+		Base:        q.Base,
+		Quote:       q.Quote,
+		Bid:         q.Bid.String(),
+		Ask:         q.Ask.String(),
+		Source:      q.Source,
+		PreviousMid: q.PreviousMid.String(),
+		Reason:      q.Reason,
+		Status:      q.Status,
+		CreatedAt:   q.CreatedAt.Unix(),
+		ResolvedBy:  q.ResolvedBy,
+	}
+	if q.ResolvedAt != nil {
+		msg.ResolvedAt = q.ResolvedAt.Unix()
+	}
+	return msg
+}
+
+// QuarantinedQuotes переводит список задержанных котировок
+func QuarantinedQuotes(quotes []models.QuarantinedQuote) []*user.QuarantinedQuote {
+	result := make([]*user.QuarantinedQuote, 0, len(quotes))
+	for _, q := range quotes {
+		result = append(result, QuarantinedQuote(q))
+	}
+	return result
+}
@@ -0,0 +1,20 @@
+package convert
+
+import (
+	"main/internal/domain/models"
+	"main/proto/user"
+)
+
+// Quote переводит рассчитанный курс в сообщение API
+func Quote(c models.Conversion) *user.PairQuote {
+	return &user.PairQuote{
+		FromCurrency: c.From,
+		ToCurrency:   c.To,
+		Bid:          c.Bid.String(),
+		Ask:          c.Ask.String(),
+		Mid:          c.Mid().String(),
+		Path:         c.Path,
+		Sources:      c.Sources,
+		UpdatedAt:    c.UpdatedAt.Unix(),
+	}
+}
@@ -0,0 +1,59 @@
+package convert
+
+import (
+	"main/internal/domain/models"
+	"main/proto/user"
+)
+
+// Webhook переводит подписку в сообщение API. Ключ подписи отдается только при withSecret.
+func Webhook(w models.Webhook, withSecret bool) *user.Webhook {
+	msg := &user.Webhook{
+		Id:         w.ID.String(),
+		Url:        w.URL,
+		EventTypes: w.Types(),
+		Active:     w.Active,
+		CreatedAt:  w.CreatedAt.Unix(),
+	}
+	if withSecret {
+		msg.Secret = w.Secret
+	}
+	return msg
+}
+
+// Webhooks переводит список подписок без ключей подписи
+func Webhooks(ws []models.Webhook) []*user.Webhook {
+	res := make([]*user.Webhook, 0, len(ws))
+	for _, w := range ws {
+		res = append(res, Webhook(w, false))
+	}
+	return res
+}
+
+// Delivery переводит доставку webhook в сообщение API
+func Delivery(d models.WebhookDelivery) *user.WebhookDelivery {
+	msg := &user.WebhookDelivery{
+		Id:             d.ID,
+		WebhookId:      d.WebhookID.String(),
+		EventId:        d.EventID,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       int32(d.Attempts),
+		LastStatusCode: int32(d.LastStatusCode),
+		LastError:      d.LastError,
+		NextAttemptAt:  d.NextAttemptAt.Unix(),
+		CreatedAt:      d.CreatedAt.Unix(),
+	}
+	if d.DeliveredAt != nil {
+		msg.DeliveredAt = d.DeliveredAt.Unix()
+	}
+	return msg
+}
+
+// Deliveries переводит журнал доставок
+func Deliveries(ds []models.WebhookDelivery) []*user.WebhookDelivery {
+	res := make([]*user.WebhookDelivery, 0, len(ds))
+	for _, d := range ds {
+		res = append(res, Delivery(d))
+	}
+	return res
+}
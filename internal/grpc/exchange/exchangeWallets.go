@@ -2,13 +2,21 @@ package exchange
 
 import (
 	"context"
-	"errors"
-	"main/internal/storage"
+	"main/internal/domain/models"
+	"main/internal/grpc/convert"
+	"main/internal/grpc/grpcerr"
+	"main/internal/lib/i18n"
+	"main/internal/rates"
+	"main/proto/user"
+	"time"
 
-	"github.com/Vesoboy/proto-exchange/v2/user"
+	"github.com/shopspring/decimal"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultHeartbeat = 15 * time.Second
+	minHeartbeat     = time.Second
 )
 
 type Exchange interface {
@@ -17,25 +25,54 @@ type Exchange interface {
 		token string,
 		from_currency string,
 		to_currency string,
-		amount float32,
+		amount decimal.Decimal,
+		fromWalletID string,
+		toWalletID string,
 	) (string,
-		float32,
-		map[string]float32,
+		models.ExchangeResult,
 		error)
 
 	// Получение курсов обмена всех валют
 	GetExchangeRates(ctx context.Context,
 		token string,
-	) (string, map[string]float32, error)
+	) (string, map[string]decimal.Decimal, error)
+
+	// Курс пары валют
+	GetQuote(ctx context.Context,
+		token string,
+		from_currency string,
+		to_currency string,
+	) (models.Conversion, error)
+
+	// Подписка на курсы пар
+	SubscribeRates(ctx context.Context,
+		token string,
+		pairs []string,
+	) (*rates.Feed, error)
+
+	// Опорная валюта курсов
+	Pivot() string
+}
+
+// Currencies предоставляет справочник валют
+type Currencies interface {
+	ListCurrencies(ctx context.Context) ([]models.Currency, error)
 }
 
 type exchangeAPI struct {
 	user.UnimplementedExchangeServiceServer
-	exchange Exchange
+	exchange   Exchange
+	currencies Currencies
+	heartbeat  time.Duration
 }
 
-func ExchangeWallet(gRPC *grpc.Server, exchange Exchange) {
-	user.RegisterExchangeServiceServer(gRPC, &exchangeAPI{exchange: exchange})
+// ExchangeWallet регистрирует ExchangeService. heartbeat — интервал
+// heartbeat-сообщений потока курсов по умолчанию.
+func ExchangeWallet(gRPC *grpc.Server, exchange Exchange, currencies Currencies, heartbeat time.Duration) {
+	if heartbeat <= 0 {
+		heartbeat = defaultHeartbeat
+	}
+	user.RegisterExchangeServiceServer(gRPC, &exchangeAPI{exchange: exchange, currencies: currencies, heartbeat: heartbeat})
 }
 
 func (e *exchangeAPI) GetExchangeRates(
@@ -43,18 +80,19 @@ func (e *exchangeAPI) GetExchangeRates(
 	req *user.RatesRequest,
 ) (*user.ExchangeRatesResponse, error) {
 	if req.GetToken() == "" {
-		return nil, status.Error(codes.InvalidArgument, "Token is empty")
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
 	}
 
 	message, rates, err := e.exchange.GetExchangeRates(ctx, req.GetToken())
 	if err != nil {
-		if errors.Is(err, storage.ErrUserNotFound) {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
-		}
+		return nil, grpcerr.Status(ctx, err)
 	}
+	approx, exact := convert.Balances(rates)
 	return &user.ExchangeRatesResponse{
-		Message: message,
-		Rates:   rates,
+		Message:    message,
+		Rates:      approx,
+		RatesExact: exact,
+		Pivot:      e.exchange.Pivot(),
 	}, nil
 }
 
@@ -63,28 +101,130 @@ func (e *exchangeAPI) ExchangeCurrency(
 	req *user.ExchangeRequest,
 ) (*user.TransactionResponse, error) {
 	if req.GetToken() == "" {
-		return nil, status.Error(codes.InvalidArgument, "Token is empty")
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
 	}
 	if req.GetFromCurrency() == "" {
-		return nil, status.Error(codes.InvalidArgument, "FromCurrency is empty")
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrFromCurrencyEmpty)
 	}
 	if req.GetToCurrency() == "" {
-		return nil, status.Error(codes.InvalidArgument, "ToCurrency is empty")
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrToCurrencyEmpty)
 	}
-	if req.GetAmount() <= 0 {
-		return nil, status.Error(codes.InvalidArgument, "Amount is empty")
+	reqAmount, err := convert.Amount(req.GetAmountExact(), req.GetAmount())
+	if err != nil {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrInvalidAmount)
+	}
+	if !reqAmount.IsPositive() {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrAmountNotPositive)
 	}
 
-	message, amount, balance, err := e.exchange.ExchangeCurrency(ctx, req.GetToken(), req.GetFromCurrency(), req.GetToCurrency(), req.GetAmount())
+	message, result, err := e.exchange.ExchangeCurrency(ctx, req.GetToken(), req.GetFromCurrency(), req.GetToCurrency(), reqAmount,
+		req.GetFromWalletId(), req.GetToWalletId())
 	if err != nil {
-		if errors.Is(err, storage.ErrUserNotFound) {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
-		}
+		return nil, grpcerr.Status(ctx, err)
 	}
+
+	amountApprox, amountExact := convert.Money(result.Amount)
+	balanceApprox, balanceExact := convert.Balances(result.Balance)
 	return &user.TransactionResponse{
-		Message:       message,
-		AmountFromTo:  amount,
-		BalanceFromTo: balance,
+		Message:            message,
+		AmountFromTo:       amountApprox,
+		BalanceFromTo:      balanceApprox,
+		AmountFromToExact:  amountExact,
+		BalanceFromToExact: balanceExact,
+		Quote:              convert.Quote(result.Conversion),
 	}, nil
 
 }
+
+func (e *exchangeAPI) ListCurrencies(
+	ctx context.Context,
+	req *user.ListCurrenciesRequest,
+) (*user.ListCurrenciesResponse, error) {
+	currencies, err := e.currencies.ListCurrencies(ctx)
+	if err != nil {
+		return nil, grpcerr.Status(ctx, err)
+	}
+
+	return &user.ListCurrenciesResponse{
+		Currencies: convert.Currencies(currencies),
+	}, nil
+}
+
+func (e *exchangeAPI) GetQuote(
+	ctx context.Context,
+	req *user.QuoteRequest,
+) (*user.PairQuote, error) {
+	if req.GetToken() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
+	}
+	if req.GetFromCurrency() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrFromCurrencyEmpty)
+	}
+	if req.GetToCurrency() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrToCurrencyEmpty)
+	}
+
+	conv, err := e.exchange.GetQuote(ctx, req.GetToken(), req.GetFromCurrency(), req.GetToCurrency())
+	if err != nil {
+		return nil, grpcerr.Status(ctx, err)
+	}
+	return convert.Quote(conv), nil
+}
+
+func (e *exchangeAPI) SubscribeRates(
+	req *user.SubscribeRatesRequest,
+	stream grpc.ServerStreamingServer[user.RatesEvent],
+) error {
+	ctx := stream.Context()
+	if req.GetToken() == "" {
+		return grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
+	}
+
+	feed, err := e.exchange.SubscribeRates(ctx, req.GetToken(), req.GetPairs())
+	if err != nil {
+		return grpcerr.Status(ctx, err)
+	}
+	defer feed.Close()
+
+	heartbeat := e.heartbeat
+	if req.GetHeartbeatSeconds() > 0 {
+		heartbeat = max(time.Duration(req.GetHeartbeatSeconds())*time.Second, minHeartbeat)
+	}
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	if err := sendRates(stream, user.RatesEvent_SNAPSHOT, feed.Snapshot()); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := sendRates(stream, user.RatesEvent_HEARTBEAT, nil); err != nil {
+				return err
+			}
+		case <-feed.Updates():
+			changes := feed.Changes()
+			if len(changes) == 0 {
+				continue
+			}
+			if err := sendRates(stream, user.RatesEvent_UPDATE, changes); err != nil {
+				return err
+			}
+			ticker.Reset(heartbeat)
+		}
+	}
+}
+
+func sendRates(stream grpc.ServerStreamingServer[user.RatesEvent], kind user.RatesEvent_Kind, quotes []models.Conversion) error {
+	event := &user.RatesEvent{
+		Kind:      kind,
+		Timestamp: time.Now().Unix(),
+	}
+	for _, q := range quotes {
+		event.Quotes = append(event.Quotes, convert.Quote(q))
+	}
+	return stream.Send(event)
+}
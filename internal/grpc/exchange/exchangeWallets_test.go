@@ -0,0 +1,94 @@
+package exchange_test
+
+import (
+	"context"
+	"main/internal/domain/models"
+	"main/internal/grpc/exchange"
+	"main/internal/rates"
+	"main/proto/user"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeExchange отдает подписки хаба; остальные методы не используются
+type fakeExchange struct {
+	exchange.Exchange
+	hub *rates.Hub
+}
+
+func (f *fakeExchange) SubscribeRates(ctx context.Context, token string, pairs []string) (*rates.Feed, error) {
+	return f.hub.Subscribe(nil), nil
+}
+
+func (f *fakeExchange) Pivot() string { return "USD" }
+
+func dial(t *testing.T, register func(s *grpc.Server)) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	register(srv)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestSubscribeRates(t *testing.T) {
+	hub := rates.NewHub(rates.NewEngine("USD", 0))
+	book := func(bid string) rates.Book {
+		rate := decimal.RequireFromString(bid)
+		return rates.NewBook([]models.RateQuote{{Base: "USD", Quote: "EUR", Bid: rate, Ask: rate, UpdatedAt: time.Now()}})
+	}
+	hub.Publish(book("0.9"))
+	conn := dial(t, func(s *grpc.Server) {
+		exchange.ExchangeWallet(s, &fakeExchange{hub: hub}, nil, time.Hour)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := user.NewExchangeServiceClient(conn).SubscribeRates(ctx, &user.SubscribeRatesRequest{Token: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.GetKind() != user.RatesEvent_SNAPSHOT || len(event.GetQuotes()) != 1 {
+		t.Fatalf("first event = %v, want snapshot of USD/EUR", event)
+	}
+
+	hub.Publish(book("0.8"))
+	event, err = stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.GetKind() != user.RatesEvent_UPDATE || event.GetQuotes()[0].GetMid() != "0.8" {
+		t.Fatalf("second event = %v, want update to 0.8", event)
+	}
+
+	// Отмена на стороне клиента закрывает подписку на сервере
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for hub.Subscribers() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("subscribers = %d after cancel, want 0", hub.Subscribers())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
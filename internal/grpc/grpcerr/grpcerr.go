@@ -0,0 +1,63 @@
+package grpcerr
+
+import (
+	"context"
+	"errors"
+	"main/internal/lib/i18n"
+	"main/internal/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type mapping struct {
+	err  error
+	code codes.Code
+	key  i18n.Key
+}
+
+// Порядок важен: первая совпавшая ошибка определяет код ответа
+var mappings = []mapping{
+	{storage.ErrUserNotFound, codes.NotFound, i18n.ErrUserNotFound},
+	{storage.ErrUserExists, codes.AlreadyExists, i18n.ErrUserExists},
+	{storage.ErrInvalidCredentials, codes.InvalidArgument, i18n.ErrInvalidCredentials},
+	{storage.ErrInvalidToken, codes.Unauthenticated, i18n.ErrInvalidToken},
+	{storage.ErrInvalidCurrency, codes.InvalidArgument, i18n.ErrInvalidCurrency},
+	{storage.ErrInvalidAmount, codes.InvalidArgument, i18n.ErrInvalidAmount},
+	{storage.ErrAmountPrecision, codes.InvalidArgument, i18n.ErrAmountPrecision},
+	{storage.ErrAmountBelowMinimum, codes.InvalidArgument, i18n.ErrAmountBelowMinimum},
+	{storage.ErrInsufficientFunds, codes.FailedPrecondition, i18n.ErrInsufficientFunds},
+	{storage.ErrCurrencyNotFound, codes.NotFound, i18n.ErrCurrencyNotFound},
+	{storage.ErrCurrencyNotAllowed, codes.FailedPrecondition, i18n.ErrCurrencyNotAllowed},
+	{storage.ErrPermissionDenied, codes.PermissionDenied, i18n.ErrPermissionDenied},
+	{storage.ErrRateNotFound, codes.Unavailable, i18n.ErrRateNotFound},
+	{storage.ErrPairHalted, codes.Unavailable, i18n.ErrPairHalted},
+	{storage.ErrQuoteNotFound, codes.NotFound, i18n.ErrQuoteNotFound},
+	{storage.ErrInvalidPair, codes.InvalidArgument, i18n.ErrInvalidPair},
+	{storage.ErrInvalidWebhook, codes.InvalidArgument, i18n.ErrInvalidWebhook},
+	{storage.ErrWebhookNotFound, codes.NotFound, i18n.ErrWebhookNotFound},
+	{storage.ErrDeliveryNotFound, codes.NotFound, i18n.ErrDeliveryNotFound},
+	{storage.ErrWalletNotFound, codes.NotFound, i18n.ErrWalletNotFound},
+	{storage.ErrWalletExists, codes.AlreadyExists, i18n.ErrWalletExists},
+	{storage.ErrWalletArchived, codes.FailedPrecondition, i18n.ErrWalletArchived},
+	{storage.ErrWalletNotEmpty, codes.FailedPrecondition, i18n.ErrWalletNotEmpty},
+	{storage.ErrInvalidWallet, codes.InvalidArgument, i18n.ErrInvalidWallet},
+	// Запрос прерван по таймауту или отменен клиентом
+	{context.DeadlineExceeded, codes.DeadlineExceeded, i18n.ErrDeadlineExceeded},
+	{context.Canceled, codes.Canceled, i18n.ErrCanceled},
+}
+
+// Status переводит ошибку сервиса в статус gRPC с описанием на языке запроса
+func Status(ctx context.Context, err error) error {
+	for _, m := range mappings {
+		if errors.Is(err, m.err) {
+			return status.Error(m.code, i18n.Tc(ctx, m.key))
+		}
+	}
+	return status.Error(codes.Internal, i18n.Tc(ctx, i18n.ErrInternal))
+}
+
+// InvalidArgument возвращает статус InvalidArgument с переведенным описанием
+func InvalidArgument(ctx context.Context, key i18n.Key) error {
+	return status.Error(codes.InvalidArgument, i18n.Tc(ctx, key))
+}
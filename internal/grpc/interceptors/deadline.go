@@ -0,0 +1,87 @@
+package interceptors
+
+import (
+	"context"
+	"main/internal/grpc/grpcerr"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Deadline ограничивает каждый unary-вызов временем timeout. overrides задает
+// время отдельных методов по полному имени (/user.ExchangeService/ExchangeCurrency);
+// ноль снимает ограничение. Более короткий дедлайн клиента сохраняется. Через ctx
+// дедлайн доходит до запросов к базе и внешних HTTP-вызовов. Если вызов упал из-за
+// истекшего дедлайна или ухода клиента, вместо Internal возвращается
+// DeadlineExceeded или Canceled. Должен идти после Language, чтобы описание
+// ошибки было переведено.
+func Deadline(timeout time.Duration, overrides map[string]time.Duration) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		d := timeout
+		if override, ok := overrides[info.FullMethod]; ok {
+			d = override
+		}
+		if d > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			err = deadlineError(ctx, err)
+		}
+		return resp, err
+	}
+}
+
+// DeadlineStream ограничивает поток временем из overrides. Общий timeout к потокам
+// не применяется: подписки (WatchBalance, SubscribeRates) живут, пока подключен клиент.
+// Обработчики потоков завершаются без ошибки, когда закрывается их контекст, поэтому
+// поток, прерванный по дедлайну или уходу клиента, получает DeadlineExceeded или
+// Canceled, а не OK. Должен идти после LanguageStream.
+func DeadlineStream(overrides map[string]time.Duration) grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx := ss.Context()
+		if d := overrides[info.FullMethod]; d > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+			ss = &deadlineStream{ServerStream: ss, ctx: ctx}
+		}
+		return deadlineError(ctx, handler(srv, ss))
+	}
+}
+
+type deadlineStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *deadlineStream) Context() context.Context {
+	return s.ctx
+}
+
+// deadlineError заменяет ошибку вызова, прерванного закрытием ctx, на
+// DeadlineExceeded или Canceled. Ошибки с осмысленным кодом не меняются.
+func deadlineError(ctx context.Context, err error) error {
+	if ctx.Err() == nil {
+		return err
+	}
+	if code := status.Code(err); err == nil || code == codes.Internal || code == codes.Unknown {
+		return grpcerr.Status(ctx, ctx.Err())
+	}
+	return err
+}
@@ -0,0 +1,168 @@
+package interceptors_test
+
+import (
+	"context"
+	"errors"
+	"main/internal/grpc/interceptors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	exchangeMethod = "/user.ExchangeService/ExchangeCurrency"
+	refreshMethod  = "/user.AdminService/UpdateExchangeRates"
+	watchMethod    = "/user.FinancialService/WatchBalance"
+)
+
+// remaining возвращает время до дедлайна, который видит обработчик; 0 — дедлайна нет
+func remaining(t *testing.T, ctx context.Context, method string, timeout time.Duration, overrides map[string]time.Duration) time.Duration {
+	t.Helper()
+	var left time.Duration
+	_, err := interceptors.Deadline(timeout, overrides)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req any) (any, error) {
+		if deadline, ok := ctx.Deadline(); ok {
+			left = time.Until(deadline)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return left
+}
+
+func TestDeadline(t *testing.T) {
+	overrides := map[string]time.Duration{exchangeMethod: 10 * time.Second, refreshMethod: 0}
+	tests := []struct {
+		name    string
+		client  time.Duration // Дедлайн клиента, 0 — не передан
+		method  string
+		timeout time.Duration
+		want    time.Duration // 0 — без дедлайна
+	}{
+		{"missing client deadline", 0, watchMethod, 5 * time.Second, 5 * time.Second},
+		{"oversized client deadline", time.Hour, watchMethod, 5 * time.Second, 5 * time.Second},
+		{"shorter client deadline", time.Second, watchMethod, 5 * time.Second, time.Second},
+		{"method override", time.Hour, exchangeMethod, 5 * time.Second, 10 * time.Second},
+		{"override removes limit", 0, refreshMethod, 5 * time.Second, 0},
+		{"no timeout", 0, watchMethod, 0, 0},
+		{"no timeout keeps client deadline", time.Second, watchMethod, 0, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.client > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.client)
+				defer cancel()
+			}
+			got := remaining(t, ctx, tt.method, tt.timeout, overrides)
+			if tt.want == 0 {
+				if got != 0 {
+					t.Fatalf("deadline in %s, want none", got)
+				}
+				return
+			}
+			if got <= 0 || got > tt.want || got < tt.want-time.Second/2 {
+				t.Fatalf("deadline in %s, want about %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeadlineErrors(t *testing.T) {
+	// Обработчик дожидается закрытия контекста и возвращает err
+	waitAndFail := func(err error) grpc.UnaryHandler {
+		return func(ctx context.Context, req any) (any, error) {
+			<-ctx.Done()
+			return nil, err
+		}
+	}
+	tests := []struct {
+		name    string
+		ctx     func() (context.Context, context.CancelFunc)
+		handler grpc.UnaryHandler
+		want    codes.Code
+	}{
+		{
+			name:    "deadline, plain error",
+			ctx:     func() (context.Context, context.CancelFunc) { return context.Background(), func() {} },
+			handler: waitAndFail(errors.New("query canceled")),
+			want:    codes.DeadlineExceeded,
+		},
+		{
+			name:    "deadline, internal",
+			ctx:     func() (context.Context, context.CancelFunc) { return context.Background(), func() {} },
+			handler: waitAndFail(status.Error(codes.Internal, "internal")),
+			want:    codes.DeadlineExceeded,
+		},
+		{
+			name:    "deadline, meaningful code kept",
+			ctx:     func() (context.Context, context.CancelFunc) { return context.Background(), func() {} },
+			handler: waitAndFail(status.Error(codes.NotFound, "not found")),
+			want:    codes.NotFound,
+		},
+		{
+			name: "client canceled",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
+			},
+			handler: waitAndFail(errors.New("query canceled")),
+			want:    codes.Canceled,
+		},
+		{
+			name: "error before deadline unchanged",
+			ctx:  func() (context.Context, context.CancelFunc) { return context.Background(), func() {} },
+			handler: func(ctx context.Context, req any) (any, error) {
+				return nil, status.Error(codes.Internal, "internal")
+			},
+			want: codes.Internal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			_, err := interceptors.Deadline(20*time.Millisecond, nil)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: exchangeMethod}, tt.handler)
+			if status.Code(err) != tt.want {
+				t.Fatalf("code = %s (%v), want %s", status.Code(err), err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeadlineStream(t *testing.T) {
+	overrides := map[string]time.Duration{watchMethod: 20 * time.Millisecond}
+	// Обработчик подписки завершается без ошибки, когда закрывается контекст потока
+	subscribe := func(srv any, stream grpc.ServerStream) error {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-time.After(time.Second):
+			return nil
+		}
+	}
+
+	ss := &fakeStream{ctx: context.Background()}
+	err := interceptors.DeadlineStream(overrides)(nil, ss, &grpc.StreamServerInfo{FullMethod: watchMethod}, subscribe)
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("code = %s, want %s", status.Code(err), codes.DeadlineExceeded)
+	}
+
+	// Без записи в overrides поток не ограничивается
+	err = interceptors.DeadlineStream(overrides)(nil, ss, &grpc.StreamServerInfo{FullMethod: "/user.ExchangeService/SubscribeRates"},
+		func(srv any, stream grpc.ServerStream) error {
+			if _, ok := stream.Context().Deadline(); ok {
+				t.Fatal("stream without override has a deadline")
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
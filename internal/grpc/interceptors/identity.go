@@ -0,0 +1,87 @@
+package interceptors
+
+import (
+	"context"
+	"crypto/x509"
+	"main/internal/lib/identity"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// ClientIdentity сопоставляет проверенный клиентский сертификат mTLS с ролью из
+// roles и сохраняет ее в контексте (см. identity.From). Сертификат ищется по URI SAN,
+// затем DNS SAN, затем Common Name. Клиенты без проверенного сертификата или
+// с неизвестным именем проходят дальше и авторизуются токеном.
+func ClientIdentity(roles map[string]string) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		return handler(withIdentity(ctx, roles), req)
+	}
+}
+
+// ClientIdentityStream определяет роль клиента один раз при открытии потока
+func ClientIdentityStream(roles map[string]string) grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		return handler(srv, &identityStream{ServerStream: ss, ctx: withIdentity(ss.Context(), roles)})
+	}
+}
+
+type identityStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identityStream) Context() context.Context {
+	return s.ctx
+}
+
+func withIdentity(ctx context.Context, roles map[string]string) context.Context {
+	if len(roles) == 0 {
+		return ctx
+	}
+	cert := verifiedCert(ctx)
+	if cert == nil {
+		return ctx
+	}
+	for _, name := range names(cert) {
+		if role, ok := roles[name]; ok {
+			return identity.With(ctx, identity.Identity{Name: name, Role: role})
+		}
+	}
+	return ctx
+}
+
+func verifiedCert(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return info.State.VerifiedChains[0][0]
+}
+
+func names(cert *x509.Certificate) []string {
+	var result []string
+	for _, uri := range cert.URIs {
+		result = append(result, uri.String())
+	}
+	result = append(result, cert.DNSNames...)
+	if cert.Subject.CommonName != "" {
+		result = append(result, cert.Subject.CommonName)
+	}
+	return result
+}
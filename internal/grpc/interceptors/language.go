@@ -0,0 +1,88 @@
+package interceptors
+
+import (
+	"context"
+	"main/internal/lib/i18n"
+	"main/internal/lib/jwt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const acceptLanguageHeader = "accept-language"
+
+type tokenRequest interface {
+	GetToken() string
+}
+
+// Language выбирает язык ответа для каждого вызова: сначала по метаданным
+// accept-language, затем по языку из токена пользователя, иначе i18n.Default.
+func Language() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		if lang := negotiate(ctx, req); lang != "" {
+			ctx = i18n.WithLang(ctx, lang)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// LanguageStream выбирает язык ответа для потока. Язык из accept-language известен
+// сразу, а язык из токена — только после получения запроса, поэтому до этого
+// контекст потока несет язык по умолчанию.
+func LanguageStream() grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx := ss.Context()
+		if lang := negotiate(ctx, nil); lang != "" {
+			ctx = i18n.WithLang(ctx, lang)
+		}
+		return handler(srv, &languageStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type languageStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *languageStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *languageStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if _, ok := i18n.Lookup(s.ctx); !ok {
+		if lang := negotiate(s.ctx, m); lang != "" {
+			s.ctx = i18n.WithLang(s.ctx, lang)
+		}
+	}
+	return nil
+}
+
+func negotiate(ctx context.Context, req any) i18n.Lang {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, v := range md.Get(acceptLanguageHeader) {
+			if lang := i18n.Parse(v); lang != "" {
+				return lang
+			}
+		}
+	}
+
+	if r, ok := req.(tokenRequest); ok && r.GetToken() != "" {
+		if claims, err := jwt.ValidateToken(r.GetToken()); err == nil {
+			return i18n.Normalize(claims.Language)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package interceptors_test
+
+import (
+	"context"
+	"main/internal/domain/models"
+	"main/internal/grpc/interceptors"
+	"main/internal/lib/i18n"
+	"main/internal/lib/jwt"
+	"main/proto/user"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeStream отдает один запрос req и запоминает контекст
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+	req proto.Message
+}
+
+func (s *fakeStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeStream) RecvMsg(m any) error {
+	proto.Merge(m.(proto.Message), s.req)
+	return nil
+}
+
+// streamLang запускает поток через LanguageStream и возвращает язык обработчика
+// до и после получения запроса
+func streamLang(t *testing.T, ctx context.Context, req proto.Message) (before, after i18n.Lang) {
+	t.Helper()
+	ss := &fakeStream{ctx: ctx, req: req}
+	err := interceptors.LanguageStream()(nil, ss, &grpc.StreamServerInfo{}, func(srv any, stream grpc.ServerStream) error {
+		before = i18n.FromContext(stream.Context())
+		if err := stream.RecvMsg(&user.WatchBalanceRequest{}); err != nil {
+			return err
+		}
+		after = i18n.FromContext(stream.Context())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return before, after
+}
+
+func TestLanguageStream(t *testing.T) {
+	token, err := jwt.NewToken(models.User{ID: uuid.New(), Role: models.RoleUser, Language: "en"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &user.WatchBalanceRequest{Token: token}
+
+	// Язык из токена появляется после получения запроса
+	before, after := streamLang(t, context.Background(), req)
+	if before != i18n.Default || after != i18n.EN {
+		t.Fatalf("languages = %s, %s; want %s, %s", before, after, i18n.Default, i18n.EN)
+	}
+
+	// Заголовок accept-language важнее языка из токена
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("accept-language", "ru;q=0.9, de"))
+	before, after = streamLang(t, ctx, req)
+	if before != i18n.RU || after != i18n.RU {
+		t.Fatalf("languages = %s, %s; want %s", before, after, i18n.RU)
+	}
+}
@@ -0,0 +1,128 @@
+package interceptors
+
+import (
+	"context"
+	"log/slog"
+	"main/internal/lib/jwt"
+	"main/internal/lib/logger/ctxlog"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const requestIDHeader = "x-request-id"
+
+// Logging добавляет в контекст атрибуты запроса (request_id, method, peer и, если
+// есть токен, user_id), чтобы их несла каждая запись лога с этим контекстом, и
+// пишет итог вызова. request_id берется из метаданных x-request-id или создается
+// и возвращается клиенту в заголовке ответа. Должен идти после BearerToken,
+// чтобы токен уже был заполнен.
+func Logging(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		ctx = requestContext(ctx, info.FullMethod)
+		addUser(ctx, req)
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logResult(ctx, log, err, time.Since(start))
+		return resp, err
+	}
+}
+
+// LoggingStream пишет итог потока после его закрытия. Пользователь появляется
+// в атрибутах, когда поток получает первое сообщение с токеном.
+func LoggingStream(log *slog.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx := requestContext(ss.Context(), info.FullMethod)
+
+		start := time.Now()
+		err := handler(srv, &loggingStream{ServerStream: ss, ctx: ctx})
+		logResult(ctx, log, err, time.Since(start))
+		return err
+	}
+}
+
+type loggingStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *loggingStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *loggingStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	addUser(s.ctx, m)
+	return nil
+}
+
+func requestContext(ctx context.Context, method string) context.Context {
+	id := requestID(ctx)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, id))
+
+	attrs := []slog.Attr{
+		slog.String("request_id", id),
+		slog.String("method", method),
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		attrs = append(attrs, slog.String("peer", p.Addr.String()))
+	}
+	return ctxlog.New(ctx, attrs...)
+}
+
+func requestID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, v := range md.Get(requestIDHeader) {
+			// Берем только разумные идентификаторы клиента, чтобы не засорять лог
+			if v != "" && len(v) <= 128 {
+				return v
+			}
+		}
+	}
+	return uuid.NewString()
+}
+
+// addUser добавляет пользователя из токена запроса. Сам токен в лог не попадает.
+func addUser(ctx context.Context, req any) {
+	r, ok := req.(tokenRequest)
+	if !ok || r.GetToken() == "" {
+		return
+	}
+	if claims, err := jwt.ValidateToken(r.GetToken()); err == nil {
+		ctxlog.Add(ctx, slog.String("user_id", claims.UserID.String()))
+	}
+}
+
+func logResult(ctx context.Context, log *slog.Logger, err error, d time.Duration) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK, codes.Canceled:
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+	log.LogAttrs(ctx, level, "request handled",
+		slog.String("code", code.String()),
+		slog.Duration("duration", d),
+	)
+}
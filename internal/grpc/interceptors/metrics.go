@@ -0,0 +1,41 @@
+package interceptors
+
+import (
+	"context"
+	"main/internal/metrics"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Metrics записывает длительность и код ответа каждого unary-вызова
+func Metrics() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		metrics.ObserveRPC(info.FullMethod, status.Code(err), time.Since(start))
+		return resp, err
+	}
+}
+
+// MetricsStream записывает код завершения потока; длительность — все время
+// жизни потока, поэтому подписки попадают в верхние бакеты гистограммы.
+func MetricsStream() grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		metrics.ObserveRPC(info.FullMethod, status.Code(err), time.Since(start))
+		return err
+	}
+}
@@ -0,0 +1,88 @@
+package interceptors
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	authorizationHeader = "authorization"
+	bearerPrefix        = "bearer "
+	tokenField          = "token"
+)
+
+// BearerToken заполняет пустое поле token запроса из метаданных
+// "authorization: Bearer <token>". REST gateway передает заголовок Authorization
+// в эти метаданные, поэтому HTTP-клиенты могут не класть токен в тело запроса.
+// Токен из самого запроса важнее заголовка.
+func BearerToken() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		fillToken(ctx, req)
+		return handler(ctx, req)
+	}
+}
+
+// BearerTokenStream заполняет token в каждом сообщении, которое клиент шлет в поток
+func BearerTokenStream() grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		return handler(srv, &tokenStream{ServerStream: ss})
+	}
+}
+
+type tokenStream struct {
+	grpc.ServerStream
+}
+
+func (s *tokenStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	fillToken(s.Context(), m)
+	return nil
+}
+
+func fillToken(ctx context.Context, req any) {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return
+	}
+	r := msg.ProtoReflect()
+	field := r.Descriptor().Fields().ByName(tokenField)
+	if field == nil || field.Kind() != protoreflect.StringKind || field.IsList() {
+		return
+	}
+	if r.Get(field).String() != "" {
+		return
+	}
+	if token := bearer(ctx); token != "" {
+		r.Set(field, protoreflect.ValueOfString(token))
+	}
+}
+
+func bearer(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	for _, v := range md.Get(authorizationHeader) {
+		if len(v) > len(bearerPrefix) && strings.EqualFold(v[:len(bearerPrefix)], bearerPrefix) {
+			return strings.TrimSpace(v[len(bearerPrefix):])
+		}
+	}
+	return ""
+}
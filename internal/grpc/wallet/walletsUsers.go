@@ -2,28 +2,49 @@ package wallet
 
 import (
 	"context"
-	"errors"
-	"main/internal/storage"
+	"main/internal/domain/models"
+	"main/internal/events"
+	"main/internal/grpc/convert"
+	"main/internal/grpc/grpcerr"
+	"main/internal/lib/i18n"
+	"main/proto/user"
+	"time"
 
-	"github.com/Vesoboy/proto-exchange/v2/user"
+	"github.com/shopspring/decimal"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type Wallet interface {
-	GetBalance(ctx context.Context, token string) (map[string]float32, error)
-	Deposit(ctx context.Context, token string, amount float32, currency string) (string, map[string]float32, error)
-	Withdraw(ctx context.Context, token string, amount float32, currency string) (string, map[string]float32, error)
+	GetBalance(ctx context.Context, token string) (map[string]decimal.Decimal, []models.UserWallet, error)
+	Deposit(ctx context.Context, token string, amount decimal.Decimal, currency string, walletID string) (string, map[string]decimal.Decimal, error)
+	Withdraw(ctx context.Context, token string, amount decimal.Decimal, currency string, walletID string) (string, map[string]decimal.Decimal, error)
+	WatchBalance(ctx context.Context, token string) (*events.Subscription, map[string]decimal.Decimal, error)
+	CreateWallet(ctx context.Context, token string, currency string, name string) (string, models.UserWallet, error)
+	RenameWallet(ctx context.Context, token string, walletID string, name string) (string, models.UserWallet, error)
+	ArchiveWallet(ctx context.Context, token string, walletID string) (string, models.UserWallet, error)
+	Transfer(ctx context.Context, token string, currency string, fromWalletID string, toWalletID string, amount decimal.Decimal) (string, models.TransferResult, error)
+}
+
+type Portfolio interface {
+	GetPortfolio(ctx context.Context, token string, currency string, at time.Time) (models.Portfolio, error)
+}
+
+// Виды событий баланса в API
+var balanceKinds = map[string]user.BalanceEvent_Kind{
+	models.BalanceDeposit:  user.BalanceEvent_DEPOSIT,
+	models.BalanceWithdraw: user.BalanceEvent_WITHDRAW,
+	models.BalanceExchange: user.BalanceEvent_EXCHANGE,
+	models.BalanceTransfer: user.BalanceEvent_TRANSFER,
 }
 
 type walletAPI struct {
 	user.UnimplementedFinancialServiceServer
-	wallet Wallet
+	wallet    Wallet
+	portfolio Portfolio
 }
 
-func FinancialService(gRPC *grpc.Server, wallet Wallet) {
-	user.RegisterFinancialServiceServer(gRPC, &walletAPI{wallet: wallet})
+func FinancialService(gRPC *grpc.Server, wallet Wallet, portfolio Portfolio) {
+	user.RegisterFinancialServiceServer(gRPC, &walletAPI{wallet: wallet, portfolio: portfolio})
 }
 
 func (w *walletAPI) GetBalance(
@@ -31,23 +52,19 @@ func (w *walletAPI) GetBalance(
 	req *user.GetBalanceRequest,
 ) (*user.BalanceResponse, error) {
 	if req.GetToken() == "" {
-		return nil, status.Error(codes.InvalidArgument, "Token is empty")
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
 	}
 
-	balance, err := w.wallet.GetBalance(ctx, req.GetToken())
+	balance, wallets, err := w.wallet.GetBalance(ctx, req.GetToken())
 	if err != nil {
-		if errors.Is(err, storage.ErrUserNotFound) {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
-		}
-	}
-
-	responseBalance := make(map[string]float32)
-	for k, v := range balance {
-		responseBalance[k] = v
+		return nil, grpcerr.Status(ctx, err)
 	}
 
+	approx, exact := convert.Balances(balance)
 	return &user.BalanceResponse{
-		Balance: balance,
+		Balance:      approx,
+		BalanceExact: exact,
+		Wallets:      convert.Wallets(wallets),
 	}, nil
 }
 
@@ -56,26 +73,30 @@ func (w *walletAPI) Deposit(
 	req *user.DepositRequest,
 ) (*user.WithdrawDepositResponse, error) {
 
-	if req.GetAmount() <= 0 {
-		return nil, status.Error(codes.InvalidArgument, "username is empty")
+	amount, err := convert.Amount(req.GetAmountExact(), req.GetAmount())
+	if err != nil {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrInvalidAmount)
+	}
+	if !amount.IsPositive() {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrAmountNotPositive)
 	}
 	if req.GetToken() == "" {
-		return nil, status.Error(codes.InvalidArgument, "token is empty")
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
 	}
 	if req.GetCurrency() == "" {
-		return nil, status.Error(codes.InvalidArgument, "currency is empty")
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrCurrencyEmpty)
 	}
 
-	message, depositBalance, err := w.wallet.Deposit(ctx, req.GetToken(), req.GetAmount(), req.GetCurrency())
+	message, depositBalance, err := w.wallet.Deposit(ctx, req.GetToken(), amount, req.GetCurrency(), req.GetWalletId())
 	if err != nil {
-		if errors.Is(err, storage.ErrUserNotFound) {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
-		}
+		return nil, grpcerr.Status(ctx, err)
 	}
 
+	approx, exact := convert.Balances(depositBalance)
 	return &user.WithdrawDepositResponse{
-		Message:    message,
-		NewBalance: depositBalance,
+		Message:         message,
+		NewBalance:      approx,
+		NewBalanceExact: exact,
 	}, nil
 
 }
@@ -85,25 +106,179 @@ func (w *walletAPI) Withdraw(
 	req *user.WithdrawRequest,
 ) (*user.WithdrawDepositResponse, error) {
 
-	if req.GetAmount() <= 0 {
-		return nil, status.Error(codes.InvalidArgument, "username is empty")
+	amount, err := convert.Amount(req.GetAmountExact(), req.GetAmount())
+	if err != nil {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrInvalidAmount)
+	}
+	if !amount.IsPositive() {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrAmountNotPositive)
 	}
 	if req.GetToken() == "" {
-		return nil, status.Error(codes.InvalidArgument, "token is empty")
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
 	}
 	if req.GetCurrency() == "" {
-		return nil, status.Error(codes.InvalidArgument, "currency is empty")
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrCurrencyEmpty)
 	}
 
-	message, depositBalance, err := w.wallet.Withdraw(ctx, req.GetToken(), req.GetAmount(), req.GetCurrency())
+	message, depositBalance, err := w.wallet.Withdraw(ctx, req.GetToken(), amount, req.GetCurrency(), req.GetWalletId())
 	if err != nil {
-		if errors.Is(err, storage.ErrUserNotFound) {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
-		}
+		return nil, grpcerr.Status(ctx, err)
 	}
 
+	approx, exact := convert.Balances(depositBalance)
 	return &user.WithdrawDepositResponse{
+		Message:         message,
+		NewBalance:      approx,
+		NewBalanceExact: exact,
+	}, nil
+}
+
+func (w *walletAPI) WatchBalance(
+	req *user.WatchBalanceRequest,
+	stream grpc.ServerStreamingServer[user.BalanceEvent],
+) error {
+	ctx := stream.Context()
+	if req.GetToken() == "" {
+		return grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
+	}
+
+	sub, balance, err := w.wallet.WatchBalance(ctx, req.GetToken())
+	if err != nil {
+		return grpcerr.Status(ctx, err)
+	}
+	defer sub.Close()
+
+	approx, exact := convert.Balances(balance)
+	err = stream.Send(&user.BalanceEvent{
+		Kind:         user.BalanceEvent_SNAPSHOT,
+		Balance:      approx,
+		BalanceExact: exact,
+		Timestamp:    time.Now().Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-sub.Events():
+			if err := stream.Send(convert.BalanceEvent(event, balanceKinds[event.Kind])); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (w *walletAPI) CreateWallet(
+	ctx context.Context,
+	req *user.CreateWalletRequest,
+) (*user.WalletResponse, error) {
+	if req.GetToken() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
+	}
+	if req.GetCurrency() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrCurrencyEmpty)
+	}
+	if req.GetName() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrWalletNameEmpty)
+	}
+
+	message, wallet, err := w.wallet.CreateWallet(ctx, req.GetToken(), req.GetCurrency(), req.GetName())
+	if err != nil {
+		return nil, grpcerr.Status(ctx, err)
+	}
+	return &user.WalletResponse{Message: message, Wallet: convert.Wallet(wallet)}, nil
+}
+
+func (w *walletAPI) RenameWallet(
+	ctx context.Context,
+	req *user.RenameWalletRequest,
+) (*user.WalletResponse, error) {
+	if req.GetToken() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
+	}
+	if req.GetWalletId() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrWalletIDEmpty)
+	}
+	if req.GetName() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrWalletNameEmpty)
+	}
+
+	message, wallet, err := w.wallet.RenameWallet(ctx, req.GetToken(), req.GetWalletId(), req.GetName())
+	if err != nil {
+		return nil, grpcerr.Status(ctx, err)
+	}
+	return &user.WalletResponse{Message: message, Wallet: convert.Wallet(wallet)}, nil
+}
+
+func (w *walletAPI) ArchiveWallet(
+	ctx context.Context,
+	req *user.WalletRequest,
+) (*user.WalletResponse, error) {
+	if req.GetToken() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
+	}
+	if req.GetWalletId() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrWalletIDEmpty)
+	}
+
+	message, wallet, err := w.wallet.ArchiveWallet(ctx, req.GetToken(), req.GetWalletId())
+	if err != nil {
+		return nil, grpcerr.Status(ctx, err)
+	}
+	return &user.WalletResponse{Message: message, Wallet: convert.Wallet(wallet)}, nil
+}
+
+func (w *walletAPI) Transfer(
+	ctx context.Context,
+	req *user.TransferRequest,
+) (*user.TransferResponse, error) {
+	amount, err := convert.Amount(req.GetAmountExact(), req.GetAmount())
+	if err != nil {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrInvalidAmount)
+	}
+	if !amount.IsPositive() {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrAmountNotPositive)
+	}
+	if req.GetToken() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
+	}
+	if req.GetCurrency() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrCurrencyEmpty)
+	}
+
+	message, result, err := w.wallet.Transfer(ctx, req.GetToken(), req.GetCurrency(), req.GetFromWalletId(), req.GetToWalletId(), amount)
+	if err != nil {
+		return nil, grpcerr.Status(ctx, err)
+	}
+	return &user.TransferResponse{
 		Message:    message,
-		NewBalance: depositBalance,
+		FromWallet: convert.Wallet(result.From),
+		ToWallet:   convert.Wallet(result.To),
 	}, nil
 }
+
+func (w *walletAPI) GetPortfolio(
+	ctx context.Context,
+	req *user.PortfolioRequest,
+) (*user.PortfolioResponse, error) {
+	if req.GetToken() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
+	}
+	if req.GetCurrency() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrCurrencyEmpty)
+	}
+
+	// Нулевой момент — оценка по текущим курсам
+	var at time.Time
+	if req.GetAt() > 0 {
+		at = time.Unix(req.GetAt(), 0)
+	}
+	portfolio, err := w.portfolio.GetPortfolio(ctx, req.GetToken(), req.GetCurrency(), at)
+	if err != nil {
+		return nil, grpcerr.Status(ctx, err)
+	}
+	return convert.Portfolio(portfolio), nil
+}
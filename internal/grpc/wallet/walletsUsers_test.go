@@ -0,0 +1,97 @@
+package wallet_test
+
+import (
+	"context"
+	"main/internal/domain/models"
+	"main/internal/events"
+	"main/internal/grpc/wallet"
+	"main/proto/user"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeWallet подписывает на шину одного пользователя; остальные методы не используются
+type fakeWallet struct {
+	wallet.Wallet
+	bus    *events.Bus
+	userID uuid.UUID
+}
+
+func (f *fakeWallet) WatchBalance(ctx context.Context, token string) (*events.Subscription, map[string]decimal.Decimal, error) {
+	return f.bus.Subscribe(f.userID), map[string]decimal.Decimal{"USD": decimal.NewFromInt(100)}, nil
+}
+
+func dial(t *testing.T, register func(s *grpc.Server)) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	register(srv)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestWatchBalance(t *testing.T) {
+	bus := events.NewBus(0)
+	userID := uuid.New()
+	conn := dial(t, func(s *grpc.Server) {
+		wallet.FinancialService(s, &fakeWallet{bus: bus, userID: userID}, nil)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := user.NewFinancialServiceClient(conn).WatchBalance(ctx, &user.WatchBalanceRequest{Token: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.GetKind() != user.BalanceEvent_SNAPSHOT || event.GetBalanceExact()["USD"] != "100" {
+		t.Fatalf("first event = %v, want snapshot with 100 USD", event)
+	}
+
+	walletID := uuid.New()
+	bus.Publish(models.BalanceEvent{
+		UserID:   userID,
+		Kind:     models.BalanceDeposit,
+		Balances: map[string]decimal.Decimal{"USD": decimal.NewFromInt(110)},
+		Changes:  []models.WalletChange{{WalletID: walletID, Currency: "USD", Amount: decimal.NewFromInt(10), Balance: decimal.NewFromInt(110)}},
+		At:       time.Now(),
+	})
+	event, err = stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.GetKind() != user.BalanceEvent_DEPOSIT || len(event.GetChanges()) != 1 || event.GetChanges()[0].GetWalletId() != walletID.String() {
+		t.Fatalf("second event = %v, want deposit to %s", event, walletID)
+	}
+
+	// Отмена на стороне клиента закрывает подписку на сервере
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for bus.Subscribers(userID) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("subscribers = %d after cancel, want 0", bus.Subscribers(userID))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
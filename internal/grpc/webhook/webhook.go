@@ -0,0 +1,127 @@
+package webhook
+
+import (
+	"context"
+	"main/internal/domain/models"
+	"main/internal/grpc/convert"
+	"main/internal/grpc/grpcerr"
+	"main/internal/lib/i18n"
+	"main/proto/user"
+
+	"google.golang.org/grpc"
+)
+
+type Webhooks interface {
+	CreateWebhook(ctx context.Context, token string, url string, eventTypes []string, secret string) (string, models.Webhook, error)
+	ListWebhooks(ctx context.Context, token string) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, token string, id string) (string, models.Webhook, error)
+	ListDeliveries(ctx context.Context, token string, webhookID string, status string, limit int) ([]models.WebhookDelivery, error)
+	RetryDelivery(ctx context.Context, token string, id uint64) (models.WebhookDelivery, error)
+}
+
+type webhookAPI struct {
+	user.UnimplementedWebhookServiceServer
+	webhooks Webhooks
+}
+
+func WebhookService(gRPC *grpc.Server, webhooks Webhooks) {
+	user.RegisterWebhookServiceServer(gRPC, &webhookAPI{webhooks: webhooks})
+}
+
+func (w *webhookAPI) CreateWebhook(
+	ctx context.Context,
+	req *user.CreateWebhookRequest,
+) (*user.WebhookResponse, error) {
+	if req.GetToken() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
+	}
+	if req.GetUrl() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrWebhookURLEmpty)
+	}
+
+	message, hook, err := w.webhooks.CreateWebhook(ctx, req.GetToken(), req.GetUrl(), req.GetEventTypes(), req.GetSecret())
+	if err != nil {
+		return nil, grpcerr.Status(ctx, err)
+	}
+
+	return &user.WebhookResponse{
+		Message: message,
+		Webhook: convert.Webhook(hook, true),
+	}, nil
+}
+
+func (w *webhookAPI) ListWebhooks(
+	ctx context.Context,
+	req *user.ListWebhooksRequest,
+) (*user.ListWebhooksResponse, error) {
+	if req.GetToken() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
+	}
+
+	hooks, err := w.webhooks.ListWebhooks(ctx, req.GetToken())
+	if err != nil {
+		return nil, grpcerr.Status(ctx, err)
+	}
+
+	return &user.ListWebhooksResponse{
+		Webhooks: convert.Webhooks(hooks),
+	}, nil
+}
+
+func (w *webhookAPI) DeleteWebhook(
+	ctx context.Context,
+	req *user.WebhookRequest,
+) (*user.WebhookResponse, error) {
+	if req.GetToken() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
+	}
+	if req.GetId() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrWebhookIDEmpty)
+	}
+
+	message, hook, err := w.webhooks.DeleteWebhook(ctx, req.GetToken(), req.GetId())
+	if err != nil {
+		return nil, grpcerr.Status(ctx, err)
+	}
+
+	return &user.WebhookResponse{
+		Message: message,
+		Webhook: convert.Webhook(hook, false),
+	}, nil
+}
+
+func (w *webhookAPI) ListDeliveries(
+	ctx context.Context,
+	req *user.ListDeliveriesRequest,
+) (*user.ListDeliveriesResponse, error) {
+	if req.GetToken() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
+	}
+
+	deliveries, err := w.webhooks.ListDeliveries(ctx, req.GetToken(), req.GetWebhookId(), req.GetStatus(), int(req.GetLimit()))
+	if err != nil {
+		return nil, grpcerr.Status(ctx, err)
+	}
+
+	return &user.ListDeliveriesResponse{
+		Deliveries: convert.Deliveries(deliveries),
+	}, nil
+}
+
+func (w *webhookAPI) RetryDelivery(
+	ctx context.Context,
+	req *user.RetryDeliveryRequest,
+) (*user.WebhookDelivery, error) {
+	if req.GetToken() == "" {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrTokenEmpty)
+	}
+	if req.GetId() == 0 {
+		return nil, grpcerr.InvalidArgument(ctx, i18n.ErrDeliveryIDEmpty)
+	}
+
+	delivery, err := w.webhooks.RetryDelivery(ctx, req.GetToken(), req.GetId())
+	if err != nil {
+		return nil, grpcerr.Status(ctx, err)
+	}
+	return convert.Delivery(delivery), nil
+}
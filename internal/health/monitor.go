@@ -0,0 +1,192 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Служебные имена для проверок оркестратора через grpc.health.v1.
+// Пустое имя ("") соответствует готовности всего сервера.
+const (
+	Liveness  = "liveness"  // процесс жив; не зависит от внешних систем
+	Readiness = "readiness" // экземпляр может обслуживать запросы
+)
+
+const (
+	defaultInterval = 10 * time.Second
+	defaultTimeout  = 2 * time.Second
+)
+
+// Storage — зависимости, состояние которых проверяет Monitor
+type Storage interface {
+	// Ping проверяет соединение с базой
+	Ping(ctx context.Context) error
+	// RatesUpdatedAt возвращает время последней сохраненной котировки
+	RatesUpdatedAt(ctx context.Context) (time.Time, error)
+}
+
+// Options — параметры проверок
+type Options struct {
+	Interval    time.Duration // Период проверок
+	Timeout     time.Duration // Таймаут одной проверки
+	RatesMaxAge time.Duration // Курсы старше считаются устаревшими, 0 — не проверять
+}
+
+// Services — gRPC-сервисы, статус которых выставляет Monitor
+type Services struct {
+	Storage []string // Работают только при доступной базе
+	Rates   []string // Дополнительно требуют свежих курсов
+}
+
+// Monitor периодически проверяет зависимости и выставляет статусы
+// grpc.health.v1 по сервисам. Живость (Liveness) не зависит от базы:
+// перезапуск не поможет, если база недоступна, поэтому при сбое базы
+// экземпляр только перестает быть готовым (Readiness).
+type Monitor struct {
+	log      *slog.Logger
+	server   *grpchealth.Server
+	storage  Storage
+	opts     Options
+	services Services
+
+	mu       sync.RWMutex
+	ready    bool
+	stopping bool
+}
+
+func NewMonitor(log *slog.Logger, server *grpchealth.Server, storage Storage, opts Options, services Services) *Monitor {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	m := &Monitor{
+		log:      log,
+		server:   server,
+		storage:  storage,
+		opts:     opts,
+		services: services,
+	}
+
+	// До первой проверки экземпляр жив, но не готов
+	server.SetServingStatus(Liveness, healthpb.HealthCheckResponse_SERVING)
+	m.setAll(false, false)
+	return m
+}
+
+// Run проверяет зависимости сразу и затем с периодом Interval до отмены ctx
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		m.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check выполняет одну проверку зависимостей и обновляет статусы
+func (m *Monitor) Check(ctx context.Context) {
+	const op = "health.Monitor.Check"
+	log := m.log.With(slog.String("op", op))
+
+	dbErr := m.checkDB(ctx)
+	if dbErr != nil {
+		log.Warn("storage is unavailable", slog.String("error", dbErr.Error()))
+	}
+
+	ratesOK := dbErr == nil
+	if ratesOK {
+		if err := m.checkRates(ctx); err != nil {
+			log.Warn("rates are stale", slog.String("error", err.Error()))
+			ratesOK = false
+		}
+	}
+
+	m.setAll(dbErr == nil, ratesOK)
+}
+
+func (m *Monitor) checkDB(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, m.opts.Timeout)
+	defer cancel()
+	return m.storage.Ping(ctx)
+}
+
+func (m *Monitor) checkRates(ctx context.Context) error {
+	if m.opts.RatesMaxAge <= 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.opts.Timeout)
+	defer cancel()
+
+	updated, err := m.storage.RatesUpdatedAt(ctx)
+	if err != nil {
+		return err
+	}
+	if updated.IsZero() {
+		return fmt.Errorf("no rates stored")
+	}
+	if age := time.Since(updated); age > m.opts.RatesMaxAge {
+		return fmt.Errorf("last update %s ago", age.Round(time.Second))
+	}
+	return nil
+}
+
+func (m *Monitor) setAll(dbOK, ratesOK bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopping {
+		return
+	}
+	m.ready = dbOK
+
+	m.server.SetServingStatus("", status(dbOK))
+	m.server.SetServingStatus(Readiness, status(dbOK))
+	for _, name := range m.services.Storage {
+		m.server.SetServingStatus(name, status(dbOK))
+	}
+	for _, name := range m.services.Rates {
+		m.server.SetServingStatus(name, status(ratesOK))
+	}
+}
+
+// Live сообщает, жив ли процесс
+func (m *Monitor) Live() bool {
+	return true
+}
+
+// Ready сообщает, готов ли экземпляр принимать запросы
+func (m *Monitor) Ready() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.ready && !m.stopping
+}
+
+// Shutdown переводит все сервисы в NOT_SERVING перед остановкой сервера,
+// чтобы оркестратор перестал направлять запросы на экземпляр.
+func (m *Monitor) Shutdown() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopping = true
+	m.ready = false
+	m.server.Shutdown()
+}
+
+func status(ok bool) healthpb.HealthCheckResponse_ServingStatus {
+	if ok {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
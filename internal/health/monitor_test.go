@@ -0,0 +1,132 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"main/internal/health"
+	"sync"
+	"testing"
+	"time"
+
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// fakeStorage возвращает заданные результаты проверок
+type fakeStorage struct {
+	mu      sync.Mutex
+	pingErr error
+	updated time.Time
+}
+
+func (f *fakeStorage) Ping(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pingErr
+}
+
+func (f *fakeStorage) RatesUpdatedAt(ctx context.Context) (time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.updated, nil
+}
+
+func (f *fakeStorage) set(pingErr error, updated time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pingErr, f.updated = pingErr, updated
+}
+
+const (
+	storageService = "user.Auth"
+	ratesService   = "user.ExchangeService"
+)
+
+// statuses возвращает статусы служебных и прикладных сервисов:
+// "", liveness, readiness, сервис базы, сервис курсов
+func statuses(t *testing.T, server *grpchealth.Server) [5]healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	var got [5]healthpb.HealthCheckResponse_ServingStatus
+	for i, name := range []string{"", health.Liveness, health.Readiness, storageService, ratesService} {
+		resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: name})
+		if err != nil {
+			t.Fatalf("check %q: %v", name, err)
+		}
+		got[i] = resp.GetStatus()
+	}
+	return got
+}
+
+func TestMonitorTransitions(t *testing.T) {
+	const (
+		up   = healthpb.HealthCheckResponse_SERVING
+		down = healthpb.HealthCheckResponse_NOT_SERVING
+	)
+	storage := &fakeStorage{}
+	server := grpchealth.NewServer()
+	m := health.NewMonitor(slog.New(slog.NewTextHandler(io.Discard, nil)), server, storage,
+		health.Options{RatesMaxAge: time.Minute},
+		health.Services{Storage: []string{storageService}, Rates: []string{ratesService}},
+	)
+
+	steps := []struct {
+		name    string
+		pingErr error
+		updated time.Time
+		want    [5]healthpb.HealthCheckResponse_ServingStatus
+		ready   bool
+	}{
+		{"healthy", nil, time.Now(), [5]healthpb.HealthCheckResponse_ServingStatus{up, up, up, up, up}, true},
+		{"stale rates", nil, time.Now().Add(-time.Hour), [5]healthpb.HealthCheckResponse_ServingStatus{up, up, up, up, down}, true},
+		{"no rates", nil, time.Time{}, [5]healthpb.HealthCheckResponse_ServingStatus{up, up, up, up, down}, true},
+		{"storage down", errors.New("connection refused"), time.Now(), [5]healthpb.HealthCheckResponse_ServingStatus{down, up, down, down, down}, false},
+		{"recovered", nil, time.Now(), [5]healthpb.HealthCheckResponse_ServingStatus{up, up, up, up, up}, true},
+	}
+
+	// До первой проверки экземпляр жив, но не готов
+	if got := statuses(t, server); got != [5]healthpb.HealthCheckResponse_ServingStatus{down, up, down, down, down} {
+		t.Fatalf("initial statuses = %v", got)
+	}
+	if m.Ready() {
+		t.Fatal("ready before the first check")
+	}
+
+	for _, step := range steps {
+		storage.set(step.pingErr, step.updated)
+		m.Check(context.Background())
+		if got := statuses(t, server); got != step.want {
+			t.Fatalf("%s: statuses = %v, want %v", step.name, got, step.want)
+		}
+		if m.Ready() != step.ready {
+			t.Fatalf("%s: ready = %v, want %v", step.name, m.Ready(), step.ready)
+		}
+	}
+
+	// После Shutdown проверки больше не возвращают экземпляр в работу
+	m.Shutdown()
+	m.Check(context.Background())
+	if got := statuses(t, server); got != [5]healthpb.HealthCheckResponse_ServingStatus{down, down, down, down, down} {
+		t.Fatalf("statuses after shutdown = %v", got)
+	}
+	if m.Ready() || !m.Live() {
+		t.Fatalf("ready = %v, live = %v after shutdown; want false, true", m.Ready(), m.Live())
+	}
+}
+
+// Без RatesMaxAge свежесть курсов не проверяется
+func TestMonitorRatesCheckDisabled(t *testing.T) {
+	server := grpchealth.NewServer()
+	health.NewMonitor(slog.New(slog.NewTextHandler(io.Discard, nil)), server, &fakeStorage{}, health.Options{},
+		health.Services{Rates: []string{ratesService}},
+	).Check(context.Background())
+
+	resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: ratesService})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("rates status = %v, want SERVING", resp.GetStatus())
+	}
+}
@@ -0,0 +1,198 @@
+package i18n
+
+// Сообщения об успешных операциях
+const (
+	MsgUserRegistered Key = "user.registered"
+	MsgDeposited      Key = "wallet.deposited"
+	MsgWithdrawn      Key = "wallet.withdrawn"
+	MsgRatesFetched   Key = "exchange.rates_fetched"
+	MsgExchanged      Key = "exchange.completed"
+	MsgCurrencySaved  Key = "currency.saved"
+	MsgCurrencyOn     Key = "currency.enabled"
+	MsgCurrencyOff    Key = "currency.disabled"
+	MsgQuoteApproved  Key = "rates.quote_approved"
+	MsgQuoteRejected  Key = "rates.quote_rejected"
+	MsgWebhookCreated Key = "webhook.created"
+	MsgWebhookDeleted Key = "webhook.deleted"
+	MsgWalletCreated  Key = "wallet.created"
+	MsgWalletRenamed  Key = "wallet.renamed"
+	MsgWalletArchived Key = "wallet.archived"
+	MsgTransferred    Key = "wallet.transferred"
+)
+
+// Ошибки валидации запроса
+const (
+	ErrUsernameEmpty     Key = "validation.username_empty"
+	ErrEmailEmpty        Key = "validation.email_empty"
+	ErrPasswordEmpty     Key = "validation.password_empty"
+	ErrTokenEmpty        Key = "validation.token_empty"
+	ErrCurrencyEmpty     Key = "validation.currency_empty"
+	ErrFromCurrencyEmpty Key = "validation.from_currency_empty"
+	ErrToCurrencyEmpty   Key = "validation.to_currency_empty"
+	ErrAmountNotPositive Key = "validation.amount_not_positive"
+	ErrCurrencyCodeEmpty Key = "validation.currency_code_empty"
+	ErrQuoteIDEmpty      Key = "validation.quote_id_empty"
+	ErrWebhookURLEmpty   Key = "validation.webhook_url_empty"
+	ErrWebhookIDEmpty    Key = "validation.webhook_id_empty"
+	ErrDeliveryIDEmpty   Key = "validation.delivery_id_empty"
+	ErrWalletIDEmpty     Key = "validation.wallet_id_empty"
+	ErrWalletNameEmpty   Key = "validation.wallet_name_empty"
+)
+
+// Ошибки бизнес-логики
+const (
+	ErrUserNotFound       Key = "error.user_not_found"
+	ErrUserExists         Key = "error.user_exists"
+	ErrInvalidCredentials Key = "error.invalid_credentials"
+	ErrInvalidToken       Key = "error.invalid_token"
+	ErrInvalidCurrency    Key = "error.invalid_currency"
+	ErrInvalidAmount      Key = "error.invalid_amount"
+	ErrAmountPrecision    Key = "error.amount_precision"
+	ErrAmountBelowMinimum Key = "error.amount_below_minimum"
+	ErrInsufficientFunds  Key = "error.insufficient_funds"
+	ErrCurrencyNotFound   Key = "error.currency_not_found"
+	ErrCurrencyNotAllowed Key = "error.currency_not_allowed"
+	ErrPermissionDenied   Key = "error.permission_denied"
+	ErrRateNotFound       Key = "error.rate_not_found"
+	ErrPairHalted         Key = "error.pair_halted"
+	ErrQuoteNotFound      Key = "error.quote_not_found"
+	ErrInvalidPair        Key = "error.invalid_pair"
+	ErrInvalidWebhook     Key = "error.invalid_webhook"
+	ErrWebhookNotFound    Key = "error.webhook_not_found"
+	ErrDeliveryNotFound   Key = "error.delivery_not_found"
+	ErrWalletNotFound     Key = "error.wallet_not_found"
+	ErrWalletExists       Key = "error.wallet_exists"
+	ErrWalletArchived     Key = "error.wallet_archived"
+	ErrWalletNotEmpty     Key = "error.wallet_not_empty"
+	ErrInvalidWallet      Key = "error.invalid_wallet"
+	ErrDeadlineExceeded   Key = "error.deadline_exceeded"
+	ErrCanceled           Key = "error.canceled"
+	ErrInternal           Key = "error.internal"
+)
+
+var catalog = map[Lang]map[Key]string{
+	RU: {
+		MsgUserRegistered: "Пользователь успешно зарегистрирован",
+		MsgDeposited:      "Счет успешно пополнен",
+		MsgWithdrawn:      "Средства успешно выведены",
+		MsgRatesFetched:   "Курсы валют успешно получены",
+		MsgExchanged:      "Обмен успешно завершен",
+		MsgCurrencySaved:  "Валюта сохранена",
+		MsgCurrencyOn:     "Валюта включена",
+		MsgCurrencyOff:    "Валюта отключена",
+		MsgQuoteApproved:  "Котировка подтверждена и применена",
+		MsgQuoteRejected:  "Котировка отклонена",
+		MsgWebhookCreated: "Webhook создан",
+		MsgWebhookDeleted: "Webhook удален",
+		MsgWalletCreated:  "Кошелек создан",
+		MsgWalletRenamed:  "Кошелек переименован",
+		MsgWalletArchived: "Кошелек перенесен в архив",
+		MsgTransferred:    "Перевод между кошельками выполнен",
+
+		ErrUsernameEmpty:     "Имя пользователя не указано",
+		ErrEmailEmpty:        "Email не указан",
+		ErrPasswordEmpty:     "Пароль не указан",
+		ErrTokenEmpty:        "Токен не указан",
+		ErrCurrencyEmpty:     "Валюта не указана",
+		ErrFromCurrencyEmpty: "Не указана исходная валюта",
+		ErrToCurrencyEmpty:   "Не указана целевая валюта",
+		ErrAmountNotPositive: "Сумма должна быть больше нуля",
+		ErrCurrencyCodeEmpty: "Код валюты не указан",
+		ErrQuoteIDEmpty:      "Идентификатор котировки не указан",
+		ErrWebhookURLEmpty:   "URL webhook не указан",
+		ErrWebhookIDEmpty:    "Идентификатор webhook не указан",
+		ErrDeliveryIDEmpty:   "Идентификатор доставки не указан",
+		ErrWalletIDEmpty:     "Идентификатор кошелька не указан",
+		ErrWalletNameEmpty:   "Название кошелька не указано",
+
+		ErrUserNotFound:       "Пользователь не найден",
+		ErrUserExists:         "Пользователь уже существует",
+		ErrInvalidCredentials: "Неверные учетные данные",
+		ErrInvalidToken:       "Недействительный токен",
+		ErrInvalidCurrency:    "Неверная валюта",
+		ErrInvalidAmount:      "Неверная сумма",
+		ErrAmountPrecision:    "Слишком много знаков после запятой для этой валюты",
+		ErrAmountBelowMinimum: "Сумма меньше минимальной для этой валюты",
+		ErrInsufficientFunds:  "Недостаточно средств на счете",
+		ErrCurrencyNotFound:   "Валюта не найдена",
+		ErrCurrencyNotAllowed: "Операция с этой валютой недоступна",
+		ErrPermissionDenied:   "Недостаточно прав",
+		ErrRateNotFound:       "Курс для пары валют недоступен",
+		ErrPairHalted:         "Обмен по паре временно остановлен до проверки курса",
+		ErrQuoteNotFound:      "Котировка не найдена или уже рассмотрена",
+		ErrInvalidPair:        "Неверная пара валют, ожидается формат BTC/EUR",
+		ErrInvalidWebhook:     "Неверный URL или тип события webhook",
+		ErrWebhookNotFound:    "Webhook не найден",
+		ErrDeliveryNotFound:   "Доставка webhook не найдена",
+		ErrWalletNotFound:     "Кошелек не найден",
+		ErrWalletExists:       "Кошелек с таким названием уже существует",
+		ErrWalletArchived:     "Кошелек в архиве, операции по нему недоступны",
+		ErrWalletNotEmpty:     "В архив можно перенести только пустой кошелек",
+		ErrInvalidWallet:      "Неверный кошелек: проверьте валюту, название и направление перевода",
+		ErrDeadlineExceeded:   "Превышено время ожидания ответа",
+		ErrCanceled:           "Запрос отменен",
+		ErrInternal:           "Внутренняя ошибка сервера",
+	},
+	EN: {
+		MsgUserRegistered: "User registered successfully",
+		MsgDeposited:      "Account topped up successfully",
+		MsgWithdrawn:      "Withdrawal successful",
+		MsgRatesFetched:   "Exchange rates fetched successfully",
+		MsgExchanged:      "Exchange completed successfully",
+		MsgCurrencySaved:  "Currency saved",
+		MsgCurrencyOn:     "Currency enabled",
+		MsgCurrencyOff:    "Currency disabled",
+		MsgQuoteApproved:  "Quote approved and applied",
+		MsgQuoteRejected:  "Quote rejected",
+		MsgWebhookCreated: "Webhook created",
+		MsgWebhookDeleted: "Webhook deleted",
+		MsgWalletCreated:  "Wallet created",
+		MsgWalletRenamed:  "Wallet renamed",
+		MsgWalletArchived: "Wallet archived",
+		MsgTransferred:    "Transfer between wallets completed",
+
+		ErrUsernameEmpty:     "Username is empty",
+		ErrEmailEmpty:        "Email is empty",
+		ErrPasswordEmpty:     "Password is empty",
+		ErrTokenEmpty:        "Token is empty",
+		ErrCurrencyEmpty:     "Currency is empty",
+		ErrFromCurrencyEmpty: "Source currency is empty",
+		ErrToCurrencyEmpty:   "Target currency is empty",
+		ErrAmountNotPositive: "Amount must be greater than zero",
+		ErrCurrencyCodeEmpty: "Currency code is empty",
+		ErrQuoteIDEmpty:      "Quote id is empty",
+		ErrWebhookURLEmpty:   "Webhook URL is empty",
+		ErrWebhookIDEmpty:    "Webhook id is empty",
+		ErrDeliveryIDEmpty:   "Delivery id is empty",
+		ErrWalletIDEmpty:     "Wallet id is empty",
+		ErrWalletNameEmpty:   "Wallet name is empty",
+
+		ErrUserNotFound:       "User not found",
+		ErrUserExists:         "User already exists",
+		ErrInvalidCredentials: "Invalid credentials",
+		ErrInvalidToken:       "Invalid token",
+		ErrInvalidCurrency:    "Invalid currency",
+		ErrInvalidAmount:      "Invalid amount",
+		ErrAmountPrecision:    "Too many decimal places for this currency",
+		ErrAmountBelowMinimum: "Amount is below the minimum for this currency",
+		ErrInsufficientFunds:  "Insufficient funds",
+		ErrCurrencyNotFound:   "Currency not found",
+		ErrCurrencyNotAllowed: "Operation is not available for this currency",
+		ErrPermissionDenied:   "Permission denied",
+		ErrRateNotFound:       "Exchange rate for this pair is unavailable",
+		ErrPairHalted:         "Exchange for this pair is halted pending rate review",
+		ErrQuoteNotFound:      "Quote not found or already resolved",
+		ErrInvalidPair:        "Invalid currency pair, expected format BTC/EUR",
+		ErrInvalidWebhook:     "Invalid webhook URL or event type",
+		ErrWebhookNotFound:    "Webhook not found",
+		ErrDeliveryNotFound:   "Webhook delivery not found",
+		ErrWalletNotFound:     "Wallet not found",
+		ErrWalletExists:       "A wallet with this name already exists",
+		ErrWalletArchived:     "Wallet is archived, operations are not available",
+		ErrWalletNotEmpty:     "Only an empty wallet can be archived",
+		ErrInvalidWallet:      "Invalid wallet: check the currency, name and transfer direction",
+		ErrDeadlineExceeded:   "Request timed out",
+		ErrCanceled:           "Request canceled",
+		ErrInternal:           "Internal server error",
+	},
+}
@@ -0,0 +1,136 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lang — язык, для которого есть каталог сообщений (ru, en)
+type Lang string
+
+const (
+	RU Lang = "ru"
+	EN Lang = "en"
+
+	// Default — язык, если его не указали ни запрос, ни пользователь
+	Default = RU
+)
+
+// Key — ключ сообщения для пользователя в каталоге
+type Key string
+
+type ctxKey struct{}
+
+// Supported сообщает, есть ли каталог для языка
+func Supported(lang Lang) bool {
+	_, ok := catalog[lang]
+	return ok
+}
+
+// Normalize приводит тег ("en-US", "RU") к поддерживаемому языку или возвращает ""
+func Normalize(tag string) Lang {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if Supported(Lang(tag)) {
+		return Lang(tag)
+	}
+	return ""
+}
+
+// Parse выбирает лучший поддерживаемый язык из заголовка Accept-Language,
+// например "en-US,en;q=0.9,ru;q=0.8". Региональный тег сводится к языку, "*" означает
+// язык по умолчанию, элементы с неверным или нулевым q пропускаются. Если ничего
+// не подошло, возвращает "".
+func Parse(header string) Lang {
+	type candidate struct {
+		lang Lang
+		q    float64
+	}
+	var candidates []candidate
+
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang := Normalize(tag)
+		if strings.TrimSpace(tag) == "*" {
+			lang = Default
+		}
+		if lang == "" {
+			continue
+		}
+		q, ok := weight(params)
+		if !ok || q <= 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{lang: lang, q: q})
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].lang
+}
+
+// weight разбирает параметры элемента Accept-Language и возвращает его q (по умолчанию 1).
+// q вне [0, 1] или нечисловое значение — ошибка.
+func weight(params string) (float64, bool) {
+	for _, param := range strings.Split(params, ";") {
+		v, ok := strings.CutPrefix(strings.TrimSpace(param), "q=")
+		if !ok {
+			continue
+		}
+		q, err := strconv.ParseFloat(v, 64)
+		if err != nil || q < 0 || q > 1 {
+			return 0, false
+		}
+		return q, true
+	}
+	return 1, true
+}
+
+// WithLang сохраняет выбранный язык в ctx
+func WithLang(ctx context.Context, lang Lang) context.Context {
+	return context.WithValue(ctx, ctxKey{}, lang)
+}
+
+// Lookup возвращает язык, выбранный для запроса, если он есть
+func Lookup(ctx context.Context) (Lang, bool) {
+	lang, ok := ctx.Value(ctxKey{}).(Lang)
+	return lang, ok && lang != ""
+}
+
+// FromContext возвращает выбранный язык или Default
+func FromContext(ctx context.Context) Lang {
+	if lang, ok := Lookup(ctx); ok {
+		return lang
+	}
+	return Default
+}
+
+// T переводит key на язык lang и подставляет args, если они есть. Без перевода
+// берется текст на языке Default, а без него — сам ключ.
+func T(lang Lang, key Key, args ...any) string {
+	msg, ok := catalog[lang][key]
+	if !ok {
+		msg, ok = catalog[Default][key]
+	}
+	if !ok {
+		msg = string(key)
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// Tc переводит key на язык из ctx
+func Tc(ctx context.Context, key Key, args ...any) string {
+	return T(FromContext(ctx), key, args...)
+}
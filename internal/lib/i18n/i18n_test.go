@@ -0,0 +1,53 @@
+package i18n_test
+
+import (
+	"main/internal/lib/i18n"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   i18n.Lang
+	}{
+		{"empty", "", ""},
+		{"single", "en", i18n.EN},
+		{"region", "en-US", i18n.EN},
+		{"underscore and case", "EN_gb", i18n.EN},
+		{"first of equal weights", "ru, en", i18n.RU},
+		{"higher weight wins", "en;q=0.5, ru;q=0.8", i18n.RU},
+		{"implicit weight is 1", "ru;q=0.9, en", i18n.EN},
+		{"unsupported skipped", "de-DE, fr;q=0.9, en;q=0.1", i18n.EN},
+		{"region falls back to language", "en-AU;q=0.7, de;q=0.9", i18n.EN},
+		{"zero weight excluded", "en;q=0, ru;q=0.1", i18n.RU},
+		{"only zero weight", "en;q=0", ""},
+		{"wildcard", "*", i18n.Default},
+		{"wildcard after preferred", "en;q=0.9, *;q=0.1", i18n.EN},
+		{"wildcard preferred", "de, *;q=0.5, en;q=0.1", i18n.Default},
+		{"extra parameters", "en;level=1;q=0.9, ru;q=0.5", i18n.EN},
+		{"malformed weight", "en;q=abc, ru;q=0.2", i18n.RU},
+		{"weight above one", "en;q=2, ru;q=0.2", i18n.RU},
+		{"garbage", ";;,,q=1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := i18n.Parse(tt.header); got != tt.want {
+				t.Fatalf("Parse(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := i18n.T(i18n.EN, i18n.ErrTokenEmpty); got == string(i18n.ErrTokenEmpty) || got == i18n.T(i18n.RU, i18n.ErrTokenEmpty) {
+		t.Fatalf("T(en) = %q, want English text", got)
+	}
+	// Неизвестный язык берет текст языка по умолчанию, неизвестный ключ — сам ключ
+	if got, want := i18n.T("de", i18n.ErrTokenEmpty), i18n.T(i18n.Default, i18n.ErrTokenEmpty); got != want {
+		t.Fatalf("T(de) = %q, want %q", got, want)
+	}
+	if got := i18n.T(i18n.EN, "no.such.key"); got != "no.such.key" {
+		t.Fatalf("T(unknown key) = %q", got)
+	}
+}
@@ -0,0 +1,24 @@
+// Package identity передает в контексте запроса внутреннего клиента,
+// опознанного по сертификату mTLS.
+package identity
+
+import "context"
+
+// Identity — внутренний клиент и его роль
+type Identity struct {
+	Name string // Идентификатор из сертификата (URI SAN, DNS SAN или CN)
+	Role string
+}
+
+type ctxKey struct{}
+
+// With сохраняет клиента в контексте
+func With(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// From возвращает клиента из контекста
+func From(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(ctxKey{}).(Identity)
+	return id, ok
+}
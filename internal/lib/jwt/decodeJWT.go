@@ -15,6 +15,8 @@ type Claims struct {
 	UserID   uuid.UUID `json:"uid"`
 	Username string    `json:"username"`
 	Email    string    `json:"email"`
+	Language string    `json:"lang,omitempty"`
+	Role     string    `json:"role,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -14,6 +14,10 @@ func NewToken(user models.User, duration time.Duration) (string, error) {
 	claims["uid"] = user.ID.String()
 	claims["username"] = user.Username
 	claims["email"] = user.Email
+	claims["role"] = user.Role
+	if user.Language != "" {
+		claims["lang"] = user.Language
+	}
 	claims["exp"] = time.Now().Add(duration).Unix()
 
 	tokenString, err := token.SignedString([]byte("secret"))
@@ -0,0 +1,70 @@
+// Package ctxlog хранит в контексте атрибуты запроса (request_id, метод,
+// пользователь, адрес клиента) и добавляет их ко всем записям, залогированным
+// с этим контекстом (InfoContext и т.п.).
+package ctxlog
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+type ctxKey struct{}
+
+// fields — атрибуты запроса. Изменяемые, потому что часть из них
+// (пользователь потока) становится известна уже после начала обработки.
+type fields struct {
+	mu    sync.RWMutex
+	attrs []slog.Attr
+}
+
+// New возвращает контекст с атрибутами запроса attrs
+func New(ctx context.Context, attrs ...slog.Attr) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &fields{attrs: attrs})
+}
+
+// Add дополняет атрибуты запроса. Без New в цепочке контекстов ничего не делает.
+func Add(ctx context.Context, attrs ...slog.Attr) {
+	f, ok := ctx.Value(ctxKey{}).(*fields)
+	if !ok {
+		return
+	}
+	f.mu.Lock()
+	f.attrs = append(f.attrs, attrs...)
+	f.mu.Unlock()
+}
+
+// Attrs возвращает атрибуты запроса
+func Attrs(ctx context.Context) []slog.Attr {
+	f, ok := ctx.Value(ctxKey{}).(*fields)
+	if !ok {
+		return nil
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return append([]slog.Attr(nil), f.attrs...)
+}
+
+// Handler добавляет атрибуты запроса из контекста к записи
+type Handler struct {
+	slog.Handler
+}
+
+func NewHandler(h slog.Handler) *Handler {
+	return &Handler{Handler: h}
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs := Attrs(ctx); len(attrs) > 0 {
+		r.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{Handler: h.Handler.WithGroup(name)}
+}
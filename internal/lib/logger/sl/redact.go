@@ -0,0 +1,87 @@
+package sl
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// Ключи, значения которых никогда не пишутся в лог
+var sensitiveKeys = map[string]bool{
+	"token":              true,
+	"access_token":       true,
+	"refresh_token":      true,
+	"authorization":      true,
+	"password":           true,
+	"pass_hash":          true,
+	"passhash":           true,
+	"secret":             true,
+	"dsn":                true,
+	"storage_path":       true,
+	"local_storage_path": true,
+}
+
+var (
+	// JWT: три base64url-части, заголовок начинается с {"
+	jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+	// password=... в DSN вида "host=... password=..."
+	dsnPasswordPattern = regexp.MustCompile(`(?i)(password\s*=\s*)('[^']*'|\S+)`)
+	// user:password@ в URL
+	urlPasswordPattern = regexp.MustCompile(`(://[^:/@\s]+:)[^@\s]+@`)
+)
+
+// Redact скрывает чувствительные данные. Используется как slog.HandlerOptions.ReplaceAttr:
+// значения по ключам из списка заменяются целиком, в остальных строках
+// маскируются JWT и пароли в DSN/URL. Значения произвольного типа (ошибки,
+// fmt.Stringer, структуры) проверяются в текстовом виде и, если в нем нашлось
+// что скрыть, пишутся строкой. LogValuer раскрывается до проверки.
+func Redact(_ []string, a slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+	a.Value = a.Value.Resolve()
+
+	var s string
+	switch a.Value.Kind() {
+	case slog.KindString:
+		s = a.Value.String()
+	case slog.KindAny:
+		s = text(a.Value.Any())
+	default:
+		return a
+	}
+	if s == "" {
+		return a
+	}
+	if masked := RedactString(s); masked != s {
+		return slog.String(a.Key, masked)
+	}
+	return a
+}
+
+// text возвращает значение так, как его напечатал бы обработчик
+func text(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// RedactString маскирует токены и пароли внутри строки
+func RedactString(s string) string {
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = dsnPasswordPattern.ReplaceAllString(s, "${1}"+redacted)
+	s = urlPasswordPattern.ReplaceAllString(s, "${1}"+redacted+"@")
+	return s
+}
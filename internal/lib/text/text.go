@@ -0,0 +1,23 @@
+// Package text — обработка текста перед записью в базу
+package text
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Truncate оставляет не больше n символов строки s. Строка режется по границе
+// символа, а неверные байты UTF-8 заменяются на U+FFFD: PostgreSQL не принимает
+// такой текст, и запись, например ошибки внешнего сервиса, не должна из-за него падать.
+func Truncate(s string, n int) string {
+	s = strings.ToValidUTF8(s, string(utf8.RuneError))
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	i := 0
+	for range n {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return s[:i]
+}
@@ -0,0 +1,32 @@
+package text_test
+
+import (
+	"main/internal/lib/text"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		n    int
+		want string
+	}{
+		{"short", "ошибка", 10, "ошибка"},
+		{"exact", "ошибка", 6, "ошибка"},
+		{"cyrillic", "ошибка доставки", 6, "ошибка"},
+		{"mixed", "HTTP 500: сервер недоступен", 11, "HTTP 500: с"},
+		{"zero", "ошибка", 0, ""},
+		{"invalid bytes", "ош\xd0", 10, "ош�"},
+		{"invalid cut", "ош\xffибка", 3, "ош�"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := text.Truncate(tt.s, tt.n)
+			if got != tt.want || !utf8.ValidString(got) {
+				t.Fatalf("Truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+// Package tlsconfig собирает серверный TLS и TLS внутреннего клиента из файлов
+// сертификатов и перечитывает их при изменении без перезапуска процесса.
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"main/internal/lib/logger/sl"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+const defaultReloadInterval = 30 * time.Second
+
+// Режимы проверки клиентских сертификатов
+const (
+	ClientAuthNone    = "none"    // сертификат клиента не запрашивается
+	ClientAuthRequest = "request" // проверяется, если клиент его предъявил
+	ClientAuthRequire = "require" // обязателен (mTLS)
+)
+
+// Имя сервера, по которому внутренний клиент проверяет сертификат по умолчанию
+const defaultServerName = "localhost"
+
+// Options — параметры серверного TLS
+type Options struct {
+	CertFile       string
+	KeyFile        string
+	ClientCAFile   string        // CA для проверки клиентских сертификатов
+	ClientAuth     string        // none, request, require; при заданном CA по умолчанию require
+	MinVersion     string        // "1.2" или "1.3"
+	CipherSuites   []string      // Имена из tls.CipherSuites(), только для TLS 1.2
+	ReloadInterval time.Duration // Период проверки файлов на изменение
+
+	// Внутренний клиент (REST gateway)
+	CAFile         string // CA сертификата сервера; пусто — ClientCAFile, без него — сам сертификат сервера
+	ServerName     string // Имя в сертификате сервера для подключения через loopback, по умолчанию localhost
+	ClientCertFile string // Собственный сертификат клиента для mTLS; обязателен при ClientAuth require
+	ClientKeyFile  string
+}
+
+type material struct {
+	cert       *tls.Certificate
+	clientCA   *x509.CertPool
+	serverCA   *x509.CertPool   // Корни для проверки сервера внутренним клиентом
+	clientCert *tls.Certificate // nil — внутренний клиент не предъявляет сертификат
+}
+
+// Reloader хранит текущие сертификаты и подменяет их при изменении файлов.
+// Новые соединения получают новый сертификат, установленные не разрываются.
+type Reloader struct {
+	log  *slog.Logger
+	opts Options
+	base *tls.Config
+
+	current atomic.Pointer[material]
+	stamps  map[string]time.Time
+}
+
+// New загружает сертификаты и проверяет параметры
+func New(log *slog.Logger, opts Options) (*Reloader, error) {
+	const op = "tlsconfig.New"
+
+	if opts.CertFile == "" || opts.KeyFile == "" {
+		return nil, fmt.Errorf("%s: cert_file and key_file are required", op)
+	}
+	if (opts.ClientCertFile == "") != (opts.ClientKeyFile == "") {
+		return nil, fmt.Errorf("%s: client_cert_file and client_key_file must be set together", op)
+	}
+	if opts.ReloadInterval <= 0 {
+		opts.ReloadInterval = defaultReloadInterval
+	}
+	if opts.ServerName == "" {
+		opts.ServerName = defaultServerName
+	}
+
+	base := &tls.Config{}
+	var err error
+	if base.MinVersion, err = parseVersion(opts.MinVersion); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if base.CipherSuites, err = parseCipherSuites(opts.CipherSuites); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if base.ClientAuth, err = parseClientAuth(opts.ClientAuth, opts.ClientCAFile != ""); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if base.ClientAuth == tls.RequireAndVerifyClientCert && opts.ClientCertFile == "" {
+		return nil, fmt.Errorf("%s: client_auth %q requires client_cert_file for the gateway", op, ClientAuthRequire)
+	}
+
+	r := &Reloader{log: log, opts: opts, base: base, stamps: make(map[string]time.Time)}
+	m, err := r.load()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	r.current.Store(m)
+	r.stamps = r.modTimes()
+	return r, nil
+}
+
+// ServerConfig возвращает конфигурацию сервера. Сертификат и CA берутся
+// при каждом рукопожатии, поэтому перезагрузка применяется сразу.
+func (r *Reloader) ServerConfig() *tls.Config {
+	cfg := r.base.Clone()
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		m := r.current.Load()
+		c := r.base.Clone()
+		c.Certificates = []tls.Certificate{*m.cert}
+		c.ClientCAs = m.clientCA
+		return c, nil
+	}
+	return cfg
+}
+
+// ClientConfig возвращает конфигурацию для подключения к этому же серверу
+// изнутри процесса (REST gateway). Сертификат сервера проверяется по CA и имени
+// ServerName. Клиент предъявляет собственный сертификат ClientCertFile, а не
+// сертификат сервера: иначе роль из client_roles, выданная серверу, досталась бы
+// всем запросам через gateway. Конфигурация отражает сертификаты на момент вызова,
+// поэтому ее нужно получать заново для каждого соединения.
+func (r *Reloader) ClientConfig() *tls.Config {
+	m := r.current.Load()
+	cfg := &tls.Config{
+		MinVersion: r.base.MinVersion,
+		RootCAs:    m.serverCA,
+		ServerName: r.opts.ServerName,
+	}
+	if m.clientCert != nil {
+		cfg.Certificates = []tls.Certificate{*m.clientCert}
+	}
+	return cfg
+}
+
+// Run проверяет файлы с периодом ReloadInterval до отмены ctx
+func (r *Reloader) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Reload()
+		}
+	}
+}
+
+// Reload перечитывает сертификаты, если файлы изменились.
+// При ошибке продолжают использоваться прежние сертификаты.
+func (r *Reloader) Reload() {
+	const op = "tlsconfig.Reloader.Reload"
+	log := r.log.With(slog.String("op", op))
+
+	stamps := r.modTimes()
+	if sameStamps(stamps, r.stamps) {
+		return
+	}
+
+	m, err := r.load()
+	if err != nil {
+		// Файлы могут быть записаны не полностью, попробуем на следующем шаге
+		log.Error("failed to reload certificates", sl.Err(err))
+		return
+	}
+	r.current.Store(m)
+	r.stamps = stamps
+	log.Info("certificates reloaded", slog.Time("not_after", m.cert.Leaf.NotAfter))
+}
+
+func (r *Reloader) load() (*material, error) {
+	cert, err := loadKeyPair(r.opts.CertFile, r.opts.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &material{cert: cert}
+	if r.opts.ClientCAFile != "" {
+		if m.clientCA, err = loadPool(r.opts.ClientCAFile); err != nil {
+			return nil, fmt.Errorf("client ca: %w", err)
+		}
+	}
+
+	switch {
+	case r.opts.CAFile != "":
+		if m.serverCA, err = loadPool(r.opts.CAFile); err != nil {
+			return nil, fmt.Errorf("server ca: %w", err)
+		}
+	case m.clientCA != nil:
+		m.serverCA = m.clientCA
+	default:
+		// Без CA доверяем ровно текущему сертификату сервера (самоподписанный)
+		m.serverCA = x509.NewCertPool()
+		m.serverCA.AddCert(cert.Leaf)
+	}
+
+	if r.opts.ClientCertFile != "" {
+		if m.clientCert, err = loadKeyPair(r.opts.ClientCertFile, r.opts.ClientKeyFile); err != nil {
+			return nil, fmt.Errorf("client certificate: %w", err)
+		}
+	}
+	return m, nil
+}
+
+func loadKeyPair(certFile, keyFile string) (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load key pair: %w", err)
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return nil, fmt.Errorf("parse certificate: %w", err)
+		}
+	}
+	return &cert, nil
+}
+
+func loadPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s: no certificates found", path)
+	}
+	return pool, nil
+}
+
+// modTimes возвращает время изменения файлов. Для symlink (секреты Kubernetes)
+// os.Stat следует по ссылке, поэтому подмена ссылки тоже замечается.
+func (r *Reloader) modTimes() map[string]time.Time {
+	paths := []string{r.opts.CertFile, r.opts.KeyFile, r.opts.ClientCAFile, r.opts.CAFile, r.opts.ClientCertFile, r.opts.ClientKeyFile}
+	stamps := make(map[string]time.Time, len(paths))
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil {
+			stamps[path] = info.ModTime()
+		}
+	}
+	return stamps
+}
+
+func sameStamps(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, t := range a {
+		if !b[path].Equal(t) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseVersion(v string) (uint16, error) {
+	switch v {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported min_version %q", v)
+	}
+}
+
+func parseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	known := make(map[string]uint16)
+	for _, s := range tls.CipherSuites() {
+		known[s.Name] = s.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func parseClientAuth(mode string, hasCA bool) (tls.ClientAuthType, error) {
+	if mode == "" {
+		if hasCA {
+			mode = ClientAuthRequire
+		} else {
+			mode = ClientAuthNone
+		}
+	}
+	if mode != ClientAuthNone && !hasCA {
+		return 0, fmt.Errorf("client_auth %q requires client_ca_file", mode)
+	}
+	switch mode {
+	case ClientAuthNone:
+		return tls.NoClientCert, nil
+	case ClientAuthRequest:
+		return tls.VerifyClientCertIfGiven, nil
+	case ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return 0, fmt.Errorf("unknown client_auth %q", mode)
+	}
+}
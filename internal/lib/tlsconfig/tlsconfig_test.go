@@ -0,0 +1,290 @@
+package tlsconfig_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"main/internal/lib/tlsconfig"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var discardLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newCA(t *testing.T, name string) authority {
+	t.Helper()
+	key := newKey(t)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return authority{cert: cert, key: key}
+}
+
+// issue выпускает сертификат name с номером serial и пишет его и ключ в dir.
+// Без ca сертификат самоподписанный.
+func issue(t *testing.T, dir string, ca *authority, name string, serial int64, usage x509.ExtKeyUsage) (certFile, keyFile string) {
+	t.Helper()
+	key := newKey(t)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	parent, signer := tmpl, key
+	if ca != nil {
+		parent, signer = ca.cert, ca.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile = filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+func writeCA(t *testing.T, dir string, ca authority) string {
+	t.Helper()
+	path := filepath.Join(dir, ca.cert.Subject.CommonName+".pem")
+	writePEM(t, path, "CERTIFICATE", ca.cert.Raw)
+	return path
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// touch сдвигает время изменения файлов, чтобы Reload заметил запись в ту же секунду
+func touch(t *testing.T, step int, paths ...string) {
+	t.Helper()
+	at := time.Now().Add(time.Duration(step) * time.Minute)
+	for _, path := range paths {
+		if err := os.Chtimes(path, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// handshake соединяет сервер и внутреннего клиента и возвращает номер
+// сертификата сервера и проверенный сертификат клиента (nil — не предъявлен)
+func handshake(t *testing.T, r *tlsconfig.Reloader) (serial int64, client *x509.Certificate, err error) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+
+	accepted := make(chan *tls.Conn, 1)
+	serverErr := make(chan error, 1)
+	go func() {
+		raw, err := lis.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		server := tls.Server(raw, r.ServerConfig())
+		accepted <- server
+		serverErr <- server.Handshake()
+	}()
+
+	raw, err := net.Dial("tcp", lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := tls.Client(raw, r.ClientConfig())
+	defer conn.Close()
+	server := <-accepted
+	defer server.Close()
+	if err := conn.Handshake(); err != nil {
+		return 0, nil, err
+	}
+	if err := <-serverErr; err != nil {
+		return 0, nil, err
+	}
+	if chains := server.ConnectionState().VerifiedChains; len(chains) > 0 {
+		client = chains[0][0]
+	}
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64(), client, nil
+}
+
+func TestClientConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca, other := newCA(t, "ca"), newCA(t, "other")
+	caFile, otherFile := writeCA(t, dir, ca), writeCA(t, dir, other)
+	certFile, keyFile := issue(t, dir, &ca, "localhost", 10, x509.ExtKeyUsageServerAuth)
+	gatewayCert, gatewayKey := issue(t, dir, &ca, "gateway", 20, x509.ExtKeyUsageClientAuth)
+	selfCert, selfKey := issue(t, dir, nil, "self", 30, x509.ExtKeyUsageServerAuth)
+
+	tests := []struct {
+		name       string
+		opts       tlsconfig.Options
+		wantErr    bool
+		wantClient string
+	}{
+		{
+			name: "mTLS with gateway identity",
+			opts: tlsconfig.Options{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile,
+				ClientCertFile: gatewayCert, ClientKeyFile: gatewayKey},
+			wantClient: "gateway",
+		},
+		{
+			name: "server CA without client auth",
+			opts: tlsconfig.Options{CertFile: certFile, KeyFile: keyFile, CAFile: caFile},
+		},
+		{
+			name:    "untrusted server",
+			opts:    tlsconfig.Options{CertFile: certFile, KeyFile: keyFile, CAFile: otherFile},
+			wantErr: true,
+		},
+		{
+			name:    "wrong server name",
+			opts:    tlsconfig.Options{CertFile: certFile, KeyFile: keyFile, CAFile: caFile, ServerName: "wallet.internal"},
+			wantErr: true,
+		},
+		{
+			name: "self-signed server without CA",
+			opts: tlsconfig.Options{CertFile: selfCert, KeyFile: selfKey, ServerName: "self"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := tlsconfig.New(discardLog, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, client, err := handshake(t, r)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("handshake succeeded, want verification error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Клиент предъявляет собственный сертификат, а не сертификат сервера
+			if tt.wantClient == "" && client != nil || tt.wantClient != "" && (client == nil || client.Subject.CommonName != tt.wantClient) {
+				t.Fatalf("client certificate = %v, want %q", client, tt.wantClient)
+			}
+		})
+	}
+}
+
+func TestNewValidation(t *testing.T) {
+	dir := t.TempDir()
+	ca := newCA(t, "ca")
+	caFile := writeCA(t, dir, ca)
+	certFile, keyFile := issue(t, dir, &ca, "localhost", 10, x509.ExtKeyUsageServerAuth)
+
+	tests := []struct {
+		name string
+		opts tlsconfig.Options
+	}{
+		{"no key pair", tlsconfig.Options{CertFile: certFile}},
+		{"require without gateway certificate", tlsconfig.Options{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile}},
+		{"gateway certificate without key", tlsconfig.Options{CertFile: certFile, KeyFile: keyFile, ClientCertFile: certFile}},
+		{"client auth without CA", tlsconfig.Options{CertFile: certFile, KeyFile: keyFile, ClientAuth: tlsconfig.ClientAuthRequest}},
+		{"unknown min version", tlsconfig.Options{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.1"}},
+		{"unknown cipher suite", tlsconfig.Options{CertFile: certFile, KeyFile: keyFile, CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}}},
+		{"missing file", tlsconfig.Options{CertFile: filepath.Join(dir, "missing.crt"), KeyFile: keyFile}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tlsconfig.New(discardLog, tt.opts); err == nil {
+				t.Fatal("New succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newCA(t, "ca")
+	caFile := writeCA(t, dir, ca)
+	certFile, keyFile := issue(t, dir, &ca, "localhost", 1, x509.ExtKeyUsageServerAuth)
+	r, err := tlsconfig.New(discardLog, tlsconfig.Options{CertFile: certFile, KeyFile: keyFile, CAFile: caFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serialNow := func() int64 {
+		t.Helper()
+		serial, _, err := handshake(t, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return serial
+	}
+	if got := serialNow(); got != 1 {
+		t.Fatalf("serial = %d, want 1", got)
+	}
+
+	// Перевыпущенный сертификат применяется к новым соединениям
+	issue(t, dir, &ca, "localhost", 2, x509.ExtKeyUsageServerAuth)
+	touch(t, 1, certFile, keyFile)
+	r.Reload()
+	if got := serialNow(); got != 2 {
+		t.Fatalf("serial after reload = %d, want 2", got)
+	}
+
+	// Битый файл не заменяет рабочий сертификат
+	if err := os.WriteFile(certFile, []byte("partial"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	touch(t, 2, certFile)
+	r.Reload()
+	if got := serialNow(); got != 2 {
+		t.Fatalf("serial after broken reload = %d, want 2", got)
+	}
+}
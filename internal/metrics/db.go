@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+const startKey = "metrics:start"
+
+// InstrumentDB измеряет длительность запросов gorm и публикует статистику пула соединений
+func InstrumentDB(db *gorm.DB, name string) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("metrics: %w", err)
+	}
+	err = Registry.Register(collectors.NewDBStatsCollector(sqlDB, name))
+	if err != nil && !errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+		return fmt.Errorf("metrics: %w", err)
+	}
+
+	cb := db.Callback()
+	errs := []error{
+		cb.Create().Before("gorm:create").Register("metrics:before_create", before),
+		cb.Create().After("gorm:create").Register("metrics:after_create", after("create")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", before),
+		cb.Query().After("gorm:query").Register("metrics:after_query", after("query")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", before),
+		cb.Update().After("gorm:update").Register("metrics:after_update", after("update")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", before),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", after("delete")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", before),
+		cb.Row().After("gorm:row").Register("metrics:after_row", after("row")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", before),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", after("raw")),
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("metrics: %w", err)
+	}
+	return nil
+}
+
+func before(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+func after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+		start, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+
+		status := "ok"
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			status = "error"
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "raw"
+		}
+		dbQueryDuration.WithLabelValues(operation, table, status).Observe(time.Since(start).Seconds())
+	}
+}
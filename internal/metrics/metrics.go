@@ -0,0 +1,141 @@
+// Package metrics собирает метрики Prometheus: RPC, запросы к базе,
+// обновление курсов и операции с кошельками.
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+)
+
+const namespace = "wallet"
+
+// Registry — реестр метрик сервиса, отдается на /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	rpcHandled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_handled_total",
+		Help:      "Завершенные RPC по сервису, методу и коду статуса.",
+	}, []string{"service", "method", "code"})
+
+	rpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_handling_seconds",
+		Help:      "Длительность RPC. Для потоков — время жизни потока.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"service", "method"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_seconds",
+		Help:      "Длительность запросов к базе по типу операции.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table", "status"})
+
+	ratesRefresh = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rates_refresh_total",
+		Help:      "Обновления курсов по результату (success, failure).",
+	}, []string{"result"})
+
+	ratesRefreshDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rates_refresh_seconds",
+		Help:      "Длительность обновления курсов, включая запросы к источникам.",
+		Buckets:   []float64{.1, .25, .5, 1, 2.5, 5, 10, 30},
+	})
+
+	walletOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "operations_total",
+		Help:      "Успешные операции с кошельками по типу и валюте.",
+	}, []string{"operation", "currency"})
+
+	walletVolume = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "volume_total",
+		Help:      "Объем операций в единицах валюты. Для обмена учитываются обе стороны: exchange_out и exchange_in.",
+	}, []string{"operation", "currency"})
+)
+
+// Операции с кошельками
+const (
+	OpDeposit     = "deposit"
+	OpWithdraw    = "withdraw"
+	OpExchangeOut = "exchange_out"
+	OpExchangeIn  = "exchange_in"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		rpcHandled,
+		rpcDuration,
+		dbQueryDuration,
+		ratesRefresh,
+		ratesRefreshDuration,
+		walletOperations,
+		walletVolume,
+	)
+}
+
+// Handler отдает метрики в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveRPC учитывает завершенный RPC. fullMethod — "/package.Service/Method".
+func ObserveRPC(fullMethod string, code codes.Code, d time.Duration) {
+	service, method := splitMethod(fullMethod)
+	rpcHandled.WithLabelValues(service, method, code.String()).Inc()
+	rpcDuration.WithLabelValues(service, method).Observe(d.Seconds())
+}
+
+// ObserveRefresh учитывает обновление курсов
+func ObserveRefresh(err error, d time.Duration) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	ratesRefresh.WithLabelValues(result).Inc()
+	ratesRefreshDuration.Observe(d.Seconds())
+}
+
+// Deposit учитывает пополнение
+func Deposit(currency string, amount decimal.Decimal) {
+	observeOperation(OpDeposit, currency, amount)
+}
+
+// Withdraw учитывает вывод
+func Withdraw(currency string, amount decimal.Decimal) {
+	observeOperation(OpWithdraw, currency, amount)
+}
+
+// Exchange учитывает обмен: sold списано в from, bought зачислено в to
+func Exchange(from string, sold decimal.Decimal, to string, bought decimal.Decimal) {
+	observeOperation(OpExchangeOut, from, sold)
+	observeOperation(OpExchangeIn, to, bought)
+}
+
+func observeOperation(op, currency string, amount decimal.Decimal) {
+	currency = strings.ToUpper(currency)
+	walletOperations.WithLabelValues(op, currency).Inc()
+	walletVolume.WithLabelValues(op, currency).Add(amount.Abs().InexactFloat64())
+}
+
+func splitMethod(fullMethod string) (string, string) {
+	name := strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "unknown", name
+}
@@ -0,0 +1,161 @@
+package metrics_test
+
+import (
+	"errors"
+	"main/internal/domain/models"
+	"main/internal/metrics"
+	"main/internal/rates"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+)
+
+// value возвращает значение серии name с метками labels (0, если серии нет).
+// Для гистограмм возвращается число наблюдений.
+func value(t *testing.T, name string, labels map[string]string) float64 {
+	t.Helper()
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	next:
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if want, ok := labels[l.GetName()]; ok && want != l.GetValue() {
+					continue next
+				}
+			}
+			switch {
+			case m.GetCounter() != nil:
+				return m.GetCounter().GetValue()
+			case m.GetGauge() != nil:
+				return m.GetGauge().GetValue()
+			case m.GetHistogram() != nil:
+				return float64(m.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+	return 0
+}
+
+func TestObserveRPC(t *testing.T) {
+	tests := []struct {
+		fullMethod string
+		code       codes.Code
+		service    string
+		method     string
+	}{
+		{"/user.ExchangeService/ExchangeCurrency", codes.OK, "user.ExchangeService", "ExchangeCurrency"},
+		{"/user.Auth/Login", codes.Unauthenticated, "user.Auth", "Login"},
+		{"Ping", codes.Internal, "unknown", "Ping"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fullMethod, func(t *testing.T) {
+			handled := map[string]string{"service": tt.service, "method": tt.method, "code": tt.code.String()}
+			seconds := map[string]string{"service": tt.service, "method": tt.method}
+			beforeHandled := value(t, "wallet_grpc_handled_total", handled)
+			beforeSeconds := value(t, "wallet_grpc_handling_seconds", seconds)
+
+			metrics.ObserveRPC(tt.fullMethod, tt.code, 30*time.Millisecond)
+
+			if got := value(t, "wallet_grpc_handled_total", handled) - beforeHandled; got != 1 {
+				t.Fatalf("handled delta = %v, want 1", got)
+			}
+			if got := value(t, "wallet_grpc_handling_seconds", seconds) - beforeSeconds; got != 1 {
+				t.Fatalf("duration observations delta = %v, want 1", got)
+			}
+		})
+	}
+}
+
+func TestOperations(t *testing.T) {
+	volume := func(op, currency string) float64 {
+		return value(t, "wallet_volume_total", map[string]string{"operation": op, "currency": currency})
+	}
+	count := func(op, currency string) float64 {
+		return value(t, "wallet_operations_total", map[string]string{"operation": op, "currency": currency})
+	}
+	depositBefore, outBefore, inBefore := volume(metrics.OpDeposit, "EUR"), volume(metrics.OpExchangeOut, "USD"), volume(metrics.OpExchangeIn, "EUR")
+	withdrawBefore := count(metrics.OpWithdraw, "EUR")
+
+	// Код валюты приводится к верхнему регистру, объем учитывается по модулю
+	metrics.Deposit("eur", decimal.RequireFromString("10.5"))
+	metrics.Withdraw("EUR", decimal.RequireFromString("-2"))
+	metrics.Exchange("USD", decimal.NewFromInt(100), "EUR", decimal.NewFromInt(90))
+
+	if got := volume(metrics.OpDeposit, "EUR") - depositBefore; got != 10.5 {
+		t.Fatalf("deposit volume delta = %v, want 10.5", got)
+	}
+	if got := count(metrics.OpWithdraw, "EUR") - withdrawBefore; got != 1 {
+		t.Fatalf("withdraw count delta = %v, want 1", got)
+	}
+	if got := volume(metrics.OpExchangeOut, "USD") - outBefore; got != 100 {
+		t.Fatalf("exchange_out volume delta = %v, want 100", got)
+	}
+	if got := volume(metrics.OpExchangeIn, "EUR") - inBefore; got != 90 {
+		t.Fatalf("exchange_in volume delta = %v, want 90", got)
+	}
+}
+
+func TestObserveRefresh(t *testing.T) {
+	success := value(t, "wallet_rates_refresh_total", map[string]string{"result": "success"})
+	failure := value(t, "wallet_rates_refresh_total", map[string]string{"result": "failure"})
+
+	metrics.ObserveRefresh(nil, time.Second)
+	metrics.ObserveRefresh(errors.New("timeout"), time.Second)
+	metrics.ObserveRefresh(errors.New("timeout"), time.Second)
+
+	if got := value(t, "wallet_rates_refresh_total", map[string]string{"result": "success"}) - success; got != 1 {
+		t.Fatalf("success delta = %v, want 1", got)
+	}
+	if got := value(t, "wallet_rates_refresh_total", map[string]string{"result": "failure"}) - failure; got != 2 {
+		t.Fatalf("failure delta = %v, want 2", got)
+	}
+}
+
+func TestRegisterRates(t *testing.T) {
+	hub := rates.NewHub(rates.NewEngine("USD", 0))
+	rate := decimal.RequireFromString("0.9")
+	hub.Publish(rates.NewBook([]models.RateQuote{
+		{Base: "USD", Quote: "EUR", Bid: rate, Ask: rate, Source: "test", UpdatedAt: time.Now().Add(-time.Minute)},
+	}))
+	feed := hub.Subscribe(nil)
+	defer feed.Close()
+
+	if err := metrics.RegisterRates(hub); err != nil {
+		t.Fatal(err)
+	}
+	if age := value(t, "wallet_rates_quote_age_seconds", map[string]string{"base": "USD", "quote": "EUR", "source": "test"}); age < 60 {
+		t.Fatalf("quote age = %v, want at least 60s", age)
+	}
+	if age := value(t, "wallet_rates_age_seconds", nil); age < 60 {
+		t.Fatalf("rates age = %v, want at least 60s", age)
+	}
+	if got := value(t, "wallet_rates_subscribers", nil); got != 1 {
+		t.Fatalf("subscribers = %v, want 1", got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	metrics.ObserveRPC("/user.Auth/Login", codes.OK, time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	for _, want := range []string{"wallet_grpc_handled_total", "go_goroutines"} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Fatalf("metrics output has no %s", want)
+		}
+	}
+}
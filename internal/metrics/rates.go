@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"main/internal/rates"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	quoteAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "rates", "quote_age_seconds"),
+		"Возраст котировки пары в книге.",
+		[]string{"base", "quote", "source"}, nil,
+	)
+	ratesAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "rates", "age_seconds"),
+		"Возраст самой свежей котировки в книге.",
+		nil, nil,
+	)
+	subscribersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "rates", "subscribers"),
+		"Активные подписки на поток курсов.",
+		nil, nil,
+	)
+)
+
+// RegisterRates публикует возраст курсов из книги hub.
+// Значения считаются при каждом опросе, база не используется.
+func RegisterRates(hub *rates.Hub) error {
+	return Registry.Register(ratesCollector{hub: hub})
+}
+
+type ratesCollector struct {
+	hub *rates.Hub
+}
+
+func (c ratesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- quoteAgeDesc
+	ch <- ratesAgeDesc
+	ch <- subscribersDesc
+}
+
+func (c ratesCollector) Collect(ch chan<- prometheus.Metric) {
+	now := time.Now()
+	var newest time.Time
+	for pair, q := range c.hub.Book() {
+		ch <- prometheus.MustNewConstMetric(quoteAgeDesc, prometheus.GaugeValue,
+			now.Sub(q.UpdatedAt).Seconds(), pair[0], pair[1], q.Source)
+		if q.UpdatedAt.After(newest) {
+			newest = q.UpdatedAt
+		}
+	}
+	if !newest.IsZero() {
+		ch <- prometheus.MustNewConstMetric(ratesAgeDesc, prometheus.GaugeValue, now.Sub(newest).Seconds())
+	}
+	ch <- prometheus.MustNewConstMetric(subscribersDesc, prometheus.GaugeValue, float64(c.hub.Subscribers()))
+}
@@ -0,0 +1,43 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"main/internal/domain/models"
+	"os"
+	"sync"
+)
+
+// FilePublisher дописывает события в файл в формате JSON Lines.
+// Каждая запись сбрасывается на диск до подтверждения публикации.
+type FilePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("Не удалось открыть файл событий: %w", err)
+	}
+	return &FilePublisher{file: file}, nil
+}
+
+func (p *FilePublisher) Publish(ctx context.Context, event models.OutboxEvent) error {
+	data, err := Encode(event)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("Ошибка записи события %d: %w", event.ID, err)
+	}
+	return p.file.Sync()
+}
+
+func (p *FilePublisher) Close() error {
+	return p.file.Close()
+}
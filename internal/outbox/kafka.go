@@ -0,0 +1,61 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"main/internal/domain/models"
+	"strconv"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaWriter — часть *kafka.Writer, которая нужна публикатору.
+// Позволяет подменить брокер в тестах.
+type KafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// KafkaPublisher публикует события в топик. Ключ сообщения — пользователь,
+// поэтому события одного пользователя попадают в одну партицию и читаются по порядку.
+type KafkaPublisher struct {
+	writer KafkaWriter
+}
+
+// NewKafkaWriter создает writer, который ждет подтверждения всех реплик
+func NewKafkaWriter(brokers []string, topic string) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}
+}
+
+func NewKafkaPublisher(writer KafkaWriter) *KafkaPublisher {
+	return &KafkaPublisher{writer: writer}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, event models.OutboxEvent) error {
+	data, err := Encode(event)
+	if err != nil {
+		return err
+	}
+
+	err = p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.AggregateID.String()),
+		Value: data,
+		Headers: []kafka.Header{
+			{Key: "event-id", Value: []byte(strconv.FormatUint(event.ID, 10))},
+			{Key: "event-type", Value: []byte(event.Type)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("Ошибка публикации события %d в Kafka: %w", event.ID, err)
+	}
+	return nil
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,28 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"main/internal/domain/models"
+)
+
+// LogPublisher пишет события в лог. Подходит для локальной разработки.
+type LogPublisher struct {
+	log *slog.Logger
+}
+
+func NewLogPublisher(log *slog.Logger) *LogPublisher {
+	return &LogPublisher{log: log}
+}
+
+func (p *LogPublisher) Publish(ctx context.Context, event models.OutboxEvent) error {
+	p.log.Info("outbox event",
+		slog.Uint64("id", event.ID),
+		slog.String("type", event.Type),
+		slog.String("aggregate_id", event.AggregateID.String()),
+		slog.String("payload", string(event.Payload)),
+	)
+	return nil
+}
+
+func (p *LogPublisher) Close() error { return nil }
@@ -0,0 +1,64 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"main/internal/domain/models"
+	"strconv"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSConn — часть *nats.Conn, которая нужна публикатору.
+// Позволяет подменить соединение в тестах.
+type NATSConn interface {
+	PublishMsg(msg *nats.Msg) error
+	FlushWithContext(ctx context.Context) error
+	Close()
+}
+
+// NATSPublisher публикует события в subject <prefix>.<Type>.
+// Заголовок Nats-Msg-Id позволяет JetStream отбрасывать повторные доставки.
+type NATSPublisher struct {
+	conn   NATSConn
+	prefix string
+}
+
+// DialNATS подключается к серверу NATS
+func DialNATS(url string, prefix string) (*NATSPublisher, error) {
+	conn, err := nats.Connect(url, nats.Name("gw-exchanger outbox"))
+	if err != nil {
+		return nil, fmt.Errorf("Не удалось подключиться к NATS: %w", err)
+	}
+	return NewNATSPublisher(conn, prefix), nil
+}
+
+func NewNATSPublisher(conn NATSConn, prefix string) *NATSPublisher {
+	return &NATSPublisher{conn: conn, prefix: prefix}
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, event models.OutboxEvent) error {
+	data, err := Encode(event)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(p.prefix + "." + event.Type)
+	msg.Data = data
+	msg.Header.Set(nats.MsgIdHdr, strconv.FormatUint(event.ID, 10))
+	msg.Header.Set("Aggregate-Id", event.AggregateID.String())
+
+	if err := p.conn.PublishMsg(msg); err != nil {
+		return fmt.Errorf("Ошибка публикации события %d в NATS: %w", event.ID, err)
+	}
+	// Flush дожидается, пока сервер примет сообщение
+	if err := p.conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("Ошибка подтверждения события %d в NATS: %w", event.ID, err)
+	}
+	return nil
+}
+
+func (p *NATSPublisher) Close() error {
+	p.conn.Close()
+	return nil
+}
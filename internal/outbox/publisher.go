@@ -0,0 +1,53 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"main/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Publisher доставляет события во внешнюю систему.
+// Publish должен вернуть ошибку, если доставка не подтверждена: событие будет отправлено повторно.
+type Publisher interface {
+	Publish(ctx context.Context, event models.OutboxEvent) error
+	Close() error
+}
+
+// Envelope — формат сообщения для внешних систем.
+// Потребители должны быть идемпотентными: при повторной доставке ID совпадает.
+type Envelope struct {
+	ID          uint64          `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID uuid.UUID       `json:"aggregate_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// Encode сериализует событие в Envelope
+func Encode(event models.OutboxEvent) ([]byte, error) {
+	return json.Marshal(Envelope{
+		ID:          event.ID,
+		Type:        event.Type,
+		AggregateID: event.AggregateID,
+		OccurredAt:  event.CreatedAt,
+		Payload:     json.RawMessage(event.Payload),
+	})
+}
+
+// NewEvent сериализует событие пользователя для записи в outbox
+func NewEvent(userID uuid.UUID, eventType string, payload any) (models.OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return models.OutboxEvent{}, fmt.Errorf("Ошибка сериализации события %s: %v", eventType, err)
+	}
+	return models.OutboxEvent{
+		AggregateID: userID,
+		Type:        eventType,
+		Payload:     data,
+		CreatedAt:   time.Now(),
+	}, nil
+}
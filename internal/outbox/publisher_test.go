@@ -0,0 +1,185 @@
+package outbox_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"main/internal/domain/models"
+	"main/internal/outbox"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/segmentio/kafka-go"
+)
+
+func testEvent(id uint64) models.OutboxEvent {
+	return models.OutboxEvent{
+		ID:          id,
+		AggregateID: uuid.New(),
+		Type:        models.EventWalletCredited,
+		Payload:     []byte(`{"amount":"10"}`),
+		CreatedAt:   time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+// decode проверяет, что data — Envelope события
+func decode(t *testing.T, data []byte, event models.OutboxEvent) {
+	t.Helper()
+	var env outbox.Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		t.Fatal(err)
+	}
+	if env.ID != event.ID || env.Type != event.Type || env.AggregateID != event.AggregateID ||
+		!env.OccurredAt.Equal(event.CreatedAt) || string(env.Payload) != string(event.Payload) {
+		t.Fatalf("envelope = %+v, want event %+v", env, event)
+	}
+}
+
+// fakeNATS — соединение NATS в памяти
+type fakeNATS struct {
+	msgs     []*nats.Msg
+	flushErr error
+	closed   bool
+}
+
+func (c *fakeNATS) PublishMsg(msg *nats.Msg) error {
+	c.msgs = append(c.msgs, msg)
+	return nil
+}
+
+func (c *fakeNATS) FlushWithContext(ctx context.Context) error { return c.flushErr }
+
+func (c *fakeNATS) Close() { c.closed = true }
+
+func TestNATSPublisher(t *testing.T) {
+	conn := &fakeNATS{}
+	p := outbox.NewNATSPublisher(conn, "wallet.events")
+	event := testEvent(7)
+
+	if err := p.Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	if len(conn.msgs) != 1 {
+		t.Fatalf("published %d messages, want 1", len(conn.msgs))
+	}
+	msg := conn.msgs[0]
+	if msg.Subject != "wallet.events.WalletCredited" {
+		t.Fatalf("subject = %q", msg.Subject)
+	}
+	if msg.Header.Get(nats.MsgIdHdr) != "7" || msg.Header.Get("Aggregate-Id") != event.AggregateID.String() {
+		t.Fatalf("headers = %v", msg.Header)
+	}
+	decode(t, msg.Data, event)
+
+	// Без подтверждения сервера публикация не считается выполненной
+	conn.flushErr = errors.New("timeout")
+	if err := p.Publish(context.Background(), event); err == nil {
+		t.Fatal("Publish succeeded without flush")
+	}
+
+	if err := p.Close(); err != nil || !conn.closed {
+		t.Fatalf("Close() = %v, closed = %v", err, conn.closed)
+	}
+}
+
+// fakeKafka — writer Kafka в памяти
+type fakeKafka struct {
+	msgs   []kafka.Message
+	err    error
+	closed bool
+}
+
+func (w *fakeKafka) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+func (w *fakeKafka) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestKafkaPublisher(t *testing.T) {
+	writer := &fakeKafka{}
+	p := outbox.NewKafkaPublisher(writer)
+	event := testEvent(8)
+
+	if err := p.Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	if len(writer.msgs) != 1 {
+		t.Fatalf("wrote %d messages, want 1", len(writer.msgs))
+	}
+	msg := writer.msgs[0]
+	// Ключ — пользователь: его события попадают в одну партицию
+	if string(msg.Key) != event.AggregateID.String() {
+		t.Fatalf("key = %q, want %s", msg.Key, event.AggregateID)
+	}
+	headers := make(map[string]string)
+	for _, h := range msg.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	if headers["event-id"] != "8" || headers["event-type"] != models.EventWalletCredited {
+		t.Fatalf("headers = %v", headers)
+	}
+	decode(t, msg.Value, event)
+
+	writer.err = errors.New("not enough replicas")
+	if err := p.Publish(context.Background(), event); err == nil || !errors.Is(err, writer.err) {
+		t.Fatalf("Publish() = %v, want the writer error", err)
+	}
+
+	if err := p.Close(); err != nil || !writer.closed {
+		t.Fatalf("Close() = %v, closed = %v", err, writer.closed)
+	}
+}
+
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	events := []models.OutboxEvent{testEvent(1), testEvent(2), testEvent(3)}
+
+	// Повторное открытие дописывает файл, а не перезаписывает его
+	for _, batch := range [][]models.OutboxEvent{events[:2], events[2:]} {
+		p, err := outbox.NewFilePublisher(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, event := range batch {
+			if err := p.Publish(context.Background(), event); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := p.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	var lines int
+	for ; scanner.Scan(); lines++ {
+		if lines >= len(events) {
+			t.Fatalf("unexpected line %q", scanner.Text())
+		}
+		decode(t, scanner.Bytes(), events[lines])
+	}
+	if lines != len(events) {
+		t.Fatalf("file has %d lines, want %d", lines, len(events))
+	}
+
+	if _, err := outbox.NewFilePublisher(filepath.Join(t.TempDir(), "missing", "outbox.jsonl")); err == nil {
+		t.Fatal("NewFilePublisher succeeded for a missing directory")
+	}
+}
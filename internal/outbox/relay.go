@@ -0,0 +1,168 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"main/internal/domain/models"
+	"main/internal/lib/logger/sl"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Store — хранилище событий outbox
+type Store interface {
+	PendingEvents(ctx context.Context, limit int) ([]models.OutboxEvent, error)
+	MarkPublished(ctx context.Context, ids []uint64) error
+	MarkFailed(ctx context.Context, id uint64, cause error, retryAt time.Time) error
+	MarkDead(ctx context.Context, id uint64, cause error) error
+}
+
+// Options — параметры публикации
+type Options struct {
+	Interval    time.Duration // Период опроса outbox
+	BatchSize   int
+	MaxAttempts int           // После стольких неудач событие уходит в dead letter
+	BackoffBase time.Duration // Задержка перед второй попыткой, далее удваивается
+	BackoffMax  time.Duration // Максимальная задержка между попытками
+}
+
+func (o Options) withDefaults() Options {
+	if o.Interval <= 0 {
+		o.Interval = time.Second
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 10
+	}
+	if o.BackoffBase <= 0 {
+		o.BackoffBase = time.Second
+	}
+	if o.BackoffMax <= 0 {
+		o.BackoffMax = 5 * time.Minute
+	}
+	return o
+}
+
+// Relay публикует события из outbox.
+// Доставка не реже одного раза: событие отмечается опубликованным только после
+// успешного Publish, поэтому после сбоя оно может прийти повторно.
+// События одного пользователя публикуются строго по порядку: если событие не
+// удалось опубликовать, оно и следующие события этого пользователя ждут повторной
+// попытки, а события остальных пользователей публикуются. После MaxAttempts неудач
+// событие уходит в dead letter, и публикуются следующие события пользователя.
+// Порядок ID совпадает с порядком коммита, потому что хранилище сериализует
+// запись событий одного пользователя.
+// Порядок гарантируется при одном запущенном Relay на базу.
+type Relay struct {
+	log       *slog.Logger
+	store     Store
+	publisher Publisher
+	opts      Options
+}
+
+func NewRelay(log *slog.Logger, store Store, publisher Publisher, opts Options) *Relay {
+	return &Relay{
+		log:       log,
+		store:     store,
+		publisher: publisher,
+		opts:      opts.withDefaults(),
+	}
+}
+
+// Run публикует события до отмены ctx, после чего закрывает Publisher
+func (r *Relay) Run(ctx context.Context) {
+	const op = "outbox.Relay.Run"
+	log := r.log.With(slog.String("op", op))
+
+	defer func() {
+		if err := r.publisher.Close(); err != nil {
+			log.Error("failed to close publisher", sl.Err(err))
+		}
+	}()
+
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Пачка опубликована целиком — вероятно, есть еще события: разбираем без ожидания
+		for {
+			n, err := r.Flush(ctx)
+			if err != nil {
+				log.Error("failed to flush outbox", sl.Err(err))
+				break
+			}
+			if n < r.opts.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+	}
+}
+
+// Flush публикует одну пачку событий и возвращает число опубликованных
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	const op = "outbox.Relay.Flush"
+	log := r.log.With(slog.String("op", op))
+
+	events, err := r.store.PendingEvents(ctx, r.opts.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	blocked := make(map[uuid.UUID]bool)
+	published := make([]uint64, 0, len(events))
+	for _, event := range events {
+		if blocked[event.AggregateID] {
+			continue
+		}
+		if err := r.publisher.Publish(ctx, event); err != nil {
+			blocked[event.AggregateID] = true
+			r.fail(ctx, log, event, err)
+			continue
+		}
+		published = append(published, event.ID)
+	}
+
+	if err := r.store.MarkPublished(ctx, published); err != nil {
+		return 0, err
+	}
+	return len(published), nil
+}
+
+// fail откладывает событие до следующей попытки или переносит его в dead letter
+func (r *Relay) fail(ctx context.Context, log *slog.Logger, event models.OutboxEvent, cause error) {
+	log = log.With(
+		slog.Uint64("id", event.ID),
+		slog.String("type", event.Type),
+		slog.Int("attempt", event.Attempts+1),
+	)
+
+	var err error
+	if event.Attempts+1 >= r.opts.MaxAttempts {
+		log.Error("outbox event moved to dead letter", sl.Err(cause))
+		err = r.store.MarkDead(ctx, event.ID, cause)
+	} else {
+		log.Warn("failed to publish event", sl.Err(cause))
+		err = r.store.MarkFailed(ctx, event.ID, cause, time.Now().Add(r.backoff(event.Attempts+1)))
+	}
+	if err != nil {
+		log.Error("failed to mark event", sl.Err(err))
+	}
+}
+
+// backoff — экспоненциальная задержка после attempt неудач, не больше BackoffMax
+func (r *Relay) backoff(attempt int) time.Duration {
+	delay := r.opts.BackoffBase
+	for i := 1; i < attempt && delay < r.opts.BackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, r.opts.BackoffMax)
+}
@@ -0,0 +1,196 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"main/internal/domain/models"
+	"main/internal/outbox"
+	"main/internal/storage/memory"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var discardLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// recorder запоминает опубликованные события; fail решает, какие из них отклонить
+type recorder struct {
+	mu        sync.Mutex
+	published []models.OutboxEvent
+	fail      func(event models.OutboxEvent) bool
+}
+
+func (p *recorder) Publish(ctx context.Context, event models.OutboxEvent) error {
+	if p.fail != nil && p.fail(event) {
+		return errors.New("broker unavailable")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.published = append(p.published, event)
+	return nil
+}
+
+func (p *recorder) Close() error { return nil }
+
+func (p *recorder) ids() []uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ids := make([]uint64, 0, len(p.published))
+	for _, e := range p.published {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+// appendEvents записывает по count событий каждого пользователя по очереди и возвращает их ID
+func appendEvents(t *testing.T, store *memory.Storage, users []uuid.UUID, count int) map[uuid.UUID][]uint64 {
+	t.Helper()
+	ids := make(map[uuid.UUID][]uint64)
+	for range count {
+		for _, user := range users {
+			event, err := outbox.NewEvent(user, models.EventWalletCredited, map[string]string{"user": user.String()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			events := []models.OutboxEvent{event}
+			if err := store.AppendEvents(context.Background(), events); err != nil {
+				t.Fatal(err)
+			}
+			ids[user] = append(ids[user], events[0].ID)
+		}
+	}
+	return ids
+}
+
+func flush(t *testing.T, relay *outbox.Relay) int {
+	t.Helper()
+	n, err := relay.Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestRelayOrder(t *testing.T) {
+	store := memory.New()
+	first, second := uuid.New(), uuid.New()
+	ids := appendEvents(t, store, []uuid.UUID{first, second}, 3)
+	publisher := &recorder{}
+	relay := outbox.NewRelay(discardLog, store, publisher, outbox.Options{BatchSize: 4})
+
+	if n := flush(t, relay); n != 4 {
+		t.Fatalf("first flush published %d, want a full batch of 4", n)
+	}
+	if n := flush(t, relay); n != 2 {
+		t.Fatalf("second flush published %d, want the remaining 2", n)
+	}
+	if n := flush(t, relay); n != 0 {
+		t.Fatalf("published %d events again", n)
+	}
+
+	got := publisher.ids()
+	if !slices.IsSorted(got) || len(got) != 6 {
+		t.Fatalf("published %v, want all 6 events in write order", got)
+	}
+	for user, want := range ids {
+		var own []uint64
+		for _, e := range publisher.published {
+			if e.AggregateID == user {
+				own = append(own, e.ID)
+			}
+		}
+		if !slices.Equal(own, want) {
+			t.Fatalf("events of %s published as %v, want %v", user, own, want)
+		}
+	}
+}
+
+// lostAck теряет первую отметку о публикации, как при сбое после Publish
+type lostAck struct {
+	*memory.Storage
+	lost bool
+}
+
+func (s *lostAck) MarkPublished(ctx context.Context, ids []uint64) error {
+	if !s.lost {
+		s.lost = true
+		return errors.New("connection reset")
+	}
+	return s.Storage.MarkPublished(ctx, ids)
+}
+
+func TestRelayAtLeastOnce(t *testing.T) {
+	store := &lostAck{Storage: memory.New()}
+	user := uuid.New()
+	want := appendEvents(t, store.Storage, []uuid.UUID{user}, 2)[user]
+	publisher := &recorder{}
+	relay := outbox.NewRelay(discardLog, store, publisher, outbox.Options{})
+
+	if _, err := relay.Flush(context.Background()); err == nil {
+		t.Fatal("Flush succeeded, want the MarkPublished error")
+	}
+	// Неотмеченные события публикуются повторно с теми же ID
+	if n := flush(t, relay); n != 2 {
+		t.Fatalf("retry published %d, want 2", n)
+	}
+	if got := publisher.ids(); !slices.Equal(got, append(slices.Clone(want), want...)) {
+		t.Fatalf("published %v, want %v twice", got, want)
+	}
+	if n := flush(t, relay); n != 0 {
+		t.Fatalf("published %d events after acknowledgement", n)
+	}
+}
+
+func TestRelayDelayedUser(t *testing.T) {
+	store := memory.New()
+	blocked, other := uuid.New(), uuid.New()
+	ids := appendEvents(t, store, []uuid.UUID{blocked}, 3)
+	ids[other] = appendEvents(t, store, []uuid.UUID{other}, 1)[other]
+	poison := ids[blocked][0]
+
+	publisher := &recorder{fail: func(e models.OutboxEvent) bool { return e.ID == poison }}
+	relay := outbox.NewRelay(discardLog, store, publisher, outbox.Options{
+		BatchSize:   2,
+		MaxAttempts: 2,
+		BackoffBase: 20 * time.Millisecond,
+	})
+
+	// Первая пачка — два события задержанного пользователя: первое не опубликовано,
+	// второе ждет его, чтобы не нарушить порядок
+	if n := flush(t, relay); n != 0 {
+		t.Fatalf("first flush published %d, want 0", n)
+	}
+	// Задержанный пользователь больше не занимает пачку
+	if n := flush(t, relay); n != 1 || publisher.ids()[0] != ids[other][0] {
+		t.Fatalf("second flush published %v, want the event of the other user", publisher.ids())
+	}
+	pending, err := store.PendingEvents(context.Background(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("pending = %+v, want none before the retry is due", pending)
+	}
+
+	// Вторая неудача исчерпывает попытки: событие уходит в dead letter,
+	// а следующие события пользователя публикуются по порядку
+	time.Sleep(30 * time.Millisecond)
+	if n := flush(t, relay); n != 0 {
+		t.Fatalf("retry published %d, want 0", n)
+	}
+	if n := flush(t, relay); n != 2 {
+		t.Fatalf("flush after dead letter published %d, want 2", n)
+	}
+	want := []uint64{ids[other][0], ids[blocked][1], ids[blocked][2]}
+	if got := publisher.ids(); !slices.Equal(got, want) {
+		t.Fatalf("published %v, want %v", got, want)
+	}
+	if n := flush(t, relay); n != 0 {
+		t.Fatalf("dead event published again: %v", publisher.ids())
+	}
+}
@@ -0,0 +1,109 @@
+package rates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"main/internal/domain/models"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const coinGeckoURL = "https://api.coingecko.com/api/v3/simple/price"
+
+// Идентификаторы CoinGecko для известных активов, если в справочнике не указан provider_id
+var coinGeckoIDs = map[string]string{
+	"BTC":  "bitcoin",
+	"ETH":  "ethereum",
+	"USDT": "tether",
+}
+
+// CoinGecko котирует криптоактивы через CoinGecko. Источник отдает прямые
+// пары криптоактив/фиат (BTC/EUR, BTC/RUB), поэтому для них кросс-курс не нужен.
+type CoinGecko struct {
+	client *http.Client
+	url    string
+}
+
+func NewCoinGecko(client *http.Client) *CoinGecko {
+	return &CoinGecko{client: client, url: coinGeckoURL}
+}
+
+func (s *CoinGecko) Name() string { return "coingecko" }
+
+func (s *CoinGecko) Quotes(ctx context.Context, pivot string, currencies []models.Currency) ([]models.RateQuote, error) {
+	ids := make(map[string]string)
+	var idList []string
+	vs := map[string]bool{strings.ToLower(pivot): true}
+	for _, c := range currencies {
+		if c.Kind == models.KindCrypto {
+			if id := coinGeckoID(c); id != "" {
+				ids[c.Code] = id
+				idList = append(idList, id)
+			}
+			continue
+		}
+		vs[strings.ToLower(c.Code)] = true
+	}
+	if len(idList) == 0 {
+		return nil, nil
+	}
+
+	vsList := make([]string, 0, len(vs))
+	for code := range vs {
+		vsList = append(vsList, code)
+	}
+
+	q := url.Values{}
+	q.Set("ids", strings.Join(idList, ","))
+	q.Set("vs_currencies", strings.Join(vsList, ","))
+	q.Set("precision", "full")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка создания запроса: %v", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка запроса к API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API вернул статус %d", resp.StatusCode)
+	}
+
+	// {"bitcoin":{"usd":67000.12,"eur":61000.5}}
+	var data map[string]map[string]decimal.Decimal
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("Ошибка декодирования ответа: %v", err)
+	}
+
+	now := time.Now()
+	var quotes []models.RateQuote
+	for code, id := range ids {
+		// Нулевые и отрицательные цены передаются дальше: их отклоняет и записывает в лог Guard
+		for vsCode, price := range data[id] {
+			quotes = append(quotes, models.RateQuote{
+				Base:      code,
+				Quote:     strings.ToUpper(vsCode),
+				Bid:       price,
+				Ask:       price,
+				Source:    s.Name(),
+				UpdatedAt: now,
+			})
+		}
+	}
+	return quotes, nil
+}
+
+func coinGeckoID(c models.Currency) string {
+	if c.ProviderID != "" {
+		return c.ProviderID
+	}
+	return coinGeckoIDs[c.Code]
+}
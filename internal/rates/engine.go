@@ -0,0 +1,130 @@
+package rates
+
+import (
+	"errors"
+	"main/internal/domain/models"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Точность промежуточных расчетов курсов
+const precision = 18
+
+var ErrRateNotFound = errors.New("Курс не найден")
+
+var (
+	one = decimal.NewFromInt(1)
+	two = decimal.NewFromInt(2)
+)
+
+// Engine рассчитывает курсы обмена по прямым котировкам пар,
+// а при их отсутствии — кросс-курс через опорную валюту (pivot).
+type Engine struct {
+	pivot  string
+	spread decimal.Decimal
+}
+
+// NewEngine создает движок курсов. spread — доля спреда (0.002 = 0.2%),
+// которая применяется к котировкам без собственных bid/ask.
+func NewEngine(pivot string, spread float64) *Engine {
+	if pivot == "" {
+		pivot = "USD"
+	}
+	return &Engine{
+		pivot:  strings.ToUpper(pivot),
+		spread: decimal.NewFromFloat(spread),
+	}
+}
+
+// Pivot возвращает опорную валюту
+func (e *Engine) Pivot() string {
+	return e.pivot
+}
+
+// Normalize применяет спред к котировке, если источник дал только среднюю цену
+func (e *Engine) Normalize(q models.RateQuote) models.RateQuote {
+	if !q.Bid.Equal(q.Ask) || e.spread.IsZero() {
+		return q
+	}
+	half := e.spread.Div(two)
+	mid := q.Bid
+	q.Bid = mid.Mul(one.Sub(half)).Round(precision)
+	q.Ask = mid.Mul(one.Add(half)).Round(precision)
+	return q
+}
+
+// Book — набор котировок, индексированный по паре
+type Book map[[2]string]models.RateQuote
+
+// NewBook строит книгу котировок
+func NewBook(quotes []models.RateQuote) Book {
+	book := make(Book, len(quotes))
+	for _, q := range quotes {
+		book[[2]string{q.Base, q.Quote}] = q
+	}
+	return book
+}
+
+// leg — котировка в направлении from -> to (прямая или обратная)
+type leg struct {
+	bid, ask  decimal.Decimal
+	source    string
+	updatedAt time.Time
+}
+
+func (b Book) leg(from, to string) (leg, bool) {
+	if q, ok := b[[2]string{from, to}]; ok {
+		return leg{bid: q.Bid, ask: q.Ask, source: q.Source, updatedAt: q.UpdatedAt}, true
+	}
+	// Обратная котировка: продать To за From = купить From за To
+	if q, ok := b[[2]string{to, from}]; ok && q.Bid.IsPositive() && q.Ask.IsPositive() {
+		return leg{
+			bid:       one.DivRound(q.Ask, precision),
+			ask:       one.DivRound(q.Bid, precision),
+			source:    q.Source,
+			updatedAt: q.UpdatedAt,
+		}, true
+	}
+	return leg{}, false
+}
+
+// Convert рассчитывает курс from -> to: сначала по прямой котировке,
+// затем через опорную валюту.
+func (e *Engine) Convert(book Book, from, to string) (models.Conversion, error) {
+	if from == to {
+		return models.Conversion{From: from, To: to, Bid: one, Ask: one, Path: []string{from}, UpdatedAt: time.Now()}, nil
+	}
+
+	if l, ok := book.leg(from, to); ok {
+		return conversion(from, to, []string{from, to}, l), nil
+	}
+
+	if from != e.pivot && to != e.pivot {
+		first, ok1 := book.leg(from, e.pivot)
+		second, ok2 := book.leg(e.pivot, to)
+		if ok1 && ok2 {
+			return conversion(from, to, []string{from, e.pivot, to}, first, second), nil
+		}
+	}
+
+	return models.Conversion{}, ErrRateNotFound
+}
+
+func conversion(from, to string, path []string, legs ...leg) models.Conversion {
+	c := models.Conversion{From: from, To: to, Bid: one, Ask: one, Path: path}
+	seen := make(map[string]bool)
+	for i, l := range legs {
+		c.Bid = c.Bid.Mul(l.bid).Round(precision)
+		c.Ask = c.Ask.Mul(l.ask).Round(precision)
+		if !seen[l.source] {
+			seen[l.source] = true
+			c.Sources = append(c.Sources, l.source)
+		}
+		if i == 0 || l.updatedAt.Before(c.UpdatedAt) {
+			c.UpdatedAt = l.updatedAt
+		}
+	}
+	return c
+}
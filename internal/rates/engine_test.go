@@ -0,0 +1,102 @@
+package rates_test
+
+import (
+	"errors"
+	"main/internal/domain/models"
+	"main/internal/rates"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestConvert(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-time.Hour)
+	engine := rates.NewEngine("USD", 0)
+	book := rates.NewBook([]models.RateQuote{
+		{Base: "USD", Quote: "EUR", Bid: dec("0.9"), Ask: dec("1"), Source: "fiat", UpdatedAt: now},
+		{Base: "USD", Quote: "BTC", Bid: dec("0.00002"), Ask: dec("0.000025"), Source: "crypto", UpdatedAt: old},
+		{Base: "EUR", Quote: "RUB", Bid: dec("100"), Ask: dec("100"), Source: "fiat", UpdatedAt: now},
+	})
+
+	tests := []struct {
+		name      string
+		from, to  string
+		bid, ask  string
+		path      []string
+		sources   []string
+		updatedAt time.Time
+		err       error
+	}{
+		{name: "direct", from: "USD", to: "EUR", bid: "0.9", ask: "1",
+			path: []string{"USD", "EUR"}, sources: []string{"fiat"}, updatedAt: now},
+		// Обратная пара: bid = 1/ask, ask = 1/bid
+		{name: "inverse", from: "EUR", to: "USD", bid: "1", ask: "1.111111111111111111",
+			path: []string{"EUR", "USD"}, sources: []string{"fiat"}, updatedAt: now},
+		// Прямая котировка важнее кросс-курса через опорную валюту
+		{name: "direct between non-pivot", from: "EUR", to: "RUB", bid: "100", ask: "100",
+			path: []string{"EUR", "RUB"}, sources: []string{"fiat"}, updatedAt: now},
+		// Кросс-курс: EUR -> USD (обратная) и USD -> BTC; время — самой старой котировки
+		{name: "cross via pivot", from: "EUR", to: "BTC", bid: "0.00002", ask: "0.000027777777777778",
+			path: []string{"EUR", "USD", "BTC"}, sources: []string{"fiat", "crypto"}, updatedAt: old},
+		{name: "cross inverse legs", from: "BTC", to: "EUR", bid: "36000", ask: "50000",
+			path: []string{"BTC", "USD", "EUR"}, sources: []string{"crypto", "fiat"}, updatedAt: old},
+		{name: "same currency", from: "BTC", to: "BTC", bid: "1", ask: "1", path: []string{"BTC"}},
+		{name: "unknown currency", from: "USD", to: "XXX", err: rates.ErrRateNotFound},
+		// RUB связан только с EUR, а кросс-курс считается лишь через опорную валюту
+		{name: "missing pivot leg", from: "RUB", to: "BTC", err: rates.ErrRateNotFound},
+		{name: "missing leg to pivot", from: "RUB", to: "USD", err: rates.ErrRateNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conv, err := engine.Convert(book, tt.from, tt.to)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if !conv.Bid.Equal(dec(tt.bid)) || !conv.Ask.Equal(dec(tt.ask)) {
+				t.Fatalf("bid/ask = %s/%s, want %s/%s", conv.Bid, conv.Ask, tt.bid, tt.ask)
+			}
+			if !slices.Equal(conv.Path, tt.path) || !slices.Equal(conv.Sources, tt.sources) {
+				t.Fatalf("path %v sources %v, want %v %v", conv.Path, conv.Sources, tt.path, tt.sources)
+			}
+			if !tt.updatedAt.IsZero() && !conv.UpdatedAt.Equal(tt.updatedAt) {
+				t.Fatalf("updated at %s, want %s", conv.UpdatedAt, tt.updatedAt)
+			}
+		})
+	}
+}
+
+func TestConvertSkipsBrokenInverse(t *testing.T) {
+	engine := rates.NewEngine("USD", 0)
+	book := rates.NewBook([]models.RateQuote{{Base: "USD", Quote: "EUR", Bid: dec("0"), Ask: dec("0")}})
+	if _, err := engine.Convert(book, "EUR", "USD"); !errors.Is(err, rates.ErrRateNotFound) {
+		t.Fatalf("got error %v, want %v", err, rates.ErrRateNotFound)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	q := models.RateQuote{Base: "USD", Quote: "EUR", Bid: dec("1"), Ask: dec("1")}
+
+	got := rates.NewEngine("USD", 0.002).Normalize(q)
+	if !got.Bid.Equal(dec("0.999")) || !got.Ask.Equal(dec("1.001")) {
+		t.Fatalf("normalized = %s/%s, want 0.999/1.001", got.Bid, got.Ask)
+	}
+	// Котировка со своим спредом и нулевой спред не меняются
+	q.Ask = dec("1.01")
+	if got := rates.NewEngine("USD", 0.002).Normalize(q); !got.Ask.Equal(q.Ask) {
+		t.Fatalf("own spread changed: %s", got.Ask)
+	}
+	q.Ask = q.Bid
+	if got := rates.NewEngine("USD", 0).Normalize(q); !got.Ask.Equal(q.Bid) {
+		t.Fatalf("zero spread changed: %s", got.Ask)
+	}
+}
@@ -0,0 +1,76 @@
+package rates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"main/internal/domain/models"
+	"net/http"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const exchangeRateAPIURL = "https://api.exchangerate-api.com/v4/latest/"
+
+// ExchangeRateAPI котирует фиатные валюты через exchangerate-api.com.
+// Отдает только среднюю цену: пары pivot/валюта с bid = ask.
+type ExchangeRateAPI struct {
+	client *http.Client
+	url    string
+}
+
+func NewExchangeRateAPI(client *http.Client) *ExchangeRateAPI {
+	return &ExchangeRateAPI{client: client, url: exchangeRateAPIURL}
+}
+
+type exchangeRateAPIResponse struct {
+	Base  string                     `json:"base"`
+	Rates map[string]decimal.Decimal `json:"rates"`
+}
+
+func (s *ExchangeRateAPI) Name() string { return "exchangerate-api" }
+
+func supportsFiat(currency models.Currency) bool {
+	return currency.Kind == "" || currency.Kind == models.KindFiat
+}
+
+func (s *ExchangeRateAPI) Quotes(ctx context.Context, pivot string, currencies []models.Currency) ([]models.RateQuote, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url+pivot, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка создания запроса: %v", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка запроса к API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API вернул статус %d", resp.StatusCode)
+	}
+
+	var data exchangeRateAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("Ошибка декодирования ответа: %v", err)
+	}
+
+	now := time.Now()
+	var quotes []models.RateQuote
+	for _, c := range currencies {
+		if !supportsFiat(c) || c.Code == pivot {
+			continue
+		}
+		if rate, ok := data.Rates[c.Code]; ok {
+			quotes = append(quotes, models.RateQuote{
+				Base:      pivot,
+				Quote:     c.Code,
+				Bid:       rate,
+				Ask:       rate,
+				Source:    s.Name(),
+				UpdatedAt: now,
+			})
+		}
+	}
+	return quotes, nil
+}
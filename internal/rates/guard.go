@@ -0,0 +1,146 @@
+package rates
+
+import (
+	"main/internal/domain/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// Guard проверяет котировки источников перед сохранением.
+// Котировки, не прошедшие проверки, не попадают в книгу котировок:
+// нулевые, отрицательные и более старые, чем уже сохраненная котировка пары,
+// сразу отклоняются, а резкие скачки и расхождения между источниками
+// задерживаются до решения администратора.
+type Guard struct {
+	maxDeviation  decimal.Decimal
+	maxDivergence decimal.Decimal
+}
+
+// NewGuard создает проверку котировок.
+// maxDeviation — допустимое отклонение средней цены от предыдущей (0.1 = 10%),
+// maxDivergence — допустимое расхождение между источниками (0.02 = 2%).
+// Нулевое значение отключает соответствующую проверку.
+func NewGuard(maxDeviation, maxDivergence float64) *Guard {
+	return &Guard{
+		maxDeviation:  decimal.NewFromFloat(maxDeviation),
+		maxDivergence: decimal.NewFromFloat(maxDivergence),
+	}
+}
+
+// Verdict — результат проверки котировок
+type Verdict struct {
+	Accepted    []models.RateQuote        // Котировки, которые можно сохранить
+	Rejected    []models.QuarantinedQuote // Заведомо неверные котировки, отбрасываются
+	Quarantined []models.QuarantinedQuote // Подозрительные котировки, ждут решения администратора
+}
+
+// Check проверяет котировки относительно ранее сохраненной книги previous.
+// Если пару котируют несколько источников, принимается котировка первого,
+// но только когда источники согласны между собой.
+func (g *Guard) Check(previous Book, quotes []models.RateQuote) Verdict {
+	var v Verdict
+
+	var pairs [][2]string
+	byPair := make(map[[2]string][]models.RateQuote)
+	for _, q := range quotes {
+		pair := [2]string{q.Base, q.Quote}
+		if !validQuote(q) {
+			v.Rejected = append(v.Rejected, flag(q, previous, models.ReasonNonPositive, models.QuarantineRejected))
+			continue
+		}
+		if stale(previous, q) {
+			v.Rejected = append(v.Rejected, flag(q, previous, models.ReasonStale, models.QuarantineRejected))
+			continue
+		}
+		if _, ok := byPair[pair]; !ok {
+			pairs = append(pairs, pair)
+		}
+		byPair[pair] = append(byPair[pair], q)
+	}
+
+	for _, pair := range pairs {
+		candidates := byPair[pair]
+		if !g.agree(candidates) {
+			for _, q := range candidates {
+				v.Quarantined = append(v.Quarantined, flag(q, previous, models.ReasonDivergence, models.QuarantinePending))
+			}
+			continue
+		}
+
+		q := candidates[0]
+		if g.deviates(previous, q) {
+			v.Quarantined = append(v.Quarantined, flag(q, previous, models.ReasonDeviation, models.QuarantinePending))
+			continue
+		}
+		v.Accepted = append(v.Accepted, q)
+	}
+	return v
+}
+
+func validQuote(q models.RateQuote) bool {
+	return q.Bid.IsPositive() && q.Ask.IsPositive() && !q.Bid.GreaterThan(q.Ask)
+}
+
+// stale сообщает, что источник отдал котировку старше сохраненной: повтор старого
+// ответа не должен откатывать курс назад
+func stale(previous Book, q models.RateQuote) bool {
+	prev, ok := previous[[2]string{q.Base, q.Quote}]
+	return ok && q.UpdatedAt.Before(prev.UpdatedAt)
+}
+
+// agree проверяет, что средние цены источников отличаются не больше чем на maxDivergence
+func (g *Guard) agree(quotes []models.RateQuote) bool {
+	if len(quotes) < 2 || g.maxDivergence.IsZero() {
+		return true
+	}
+	low, high := quotes[0].Mid(), quotes[0].Mid()
+	for _, q := range quotes[1:] {
+		low = decimal.Min(low, q.Mid())
+		high = decimal.Max(high, q.Mid())
+	}
+	return high.Sub(low).DivRound(low, precision).LessThanOrEqual(g.maxDivergence)
+}
+
+// deviates проверяет отклонение от предыдущей котировки пары
+func (g *Guard) deviates(previous Book, q models.RateQuote) bool {
+	if g.maxDeviation.IsZero() {
+		return false
+	}
+	prev, ok := previous[[2]string{q.Base, q.Quote}]
+	if !ok || !prev.Mid().IsPositive() {
+		return false
+	}
+	change := q.Mid().Sub(prev.Mid()).Abs().DivRound(prev.Mid(), precision)
+	return change.GreaterThan(g.maxDeviation)
+}
+
+func flag(q models.RateQuote, previous Book, reason, status string) models.QuarantinedQuote {
+	var prevMid decimal.Decimal
+	if prev, ok := previous[[2]string{q.Base, q.Quote}]; ok {
+		prevMid = prev.Mid()
+	}
+	return models.QuarantinedQuote{
+		Base:        q.Base,
+		Quote:       q.Quote,
+		Bid:         q.Bid,
+		Ask:         q.Ask,
+		Source:      q.Source,
+		PreviousMid: prevMid,
+		Reason:      reason,
+		Status:      status,
+		CreatedAt:   q.UpdatedAt,
+	}
+}
+
+// Halted возвращает участки пути конвертации, обмен по которым остановлен.
+// Остановленная пара блокирует обмен в обоих направлениях.
+func Halted(path []string, halted map[[2]string]bool) [][2]string {
+	var result [][2]string
+	for i := 0; i+1 < len(path); i++ {
+		a, b := path[i], path[i+1]
+		if halted[[2]string{a, b}] || halted[[2]string{b, a}] {
+			result = append(result, [2]string{a, b})
+		}
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+package rates_test
+
+import (
+	"main/internal/domain/models"
+	"main/internal/rates"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGuardCheck(t *testing.T) {
+	now := time.Now()
+	previous := rates.NewBook([]models.RateQuote{
+		{Base: "USD", Quote: "EUR", Bid: dec("0.9"), Ask: dec("0.9"), Source: "a", UpdatedAt: now.Add(-time.Minute)},
+	})
+	quote := func(bid, ask, source string, at time.Time) models.RateQuote {
+		return models.RateQuote{Base: "USD", Quote: "EUR", Bid: dec(bid), Ask: dec(ask), Source: source, UpdatedAt: at}
+	}
+
+	tests := []struct {
+		name        string
+		quotes      []models.RateQuote
+		accepted    int
+		rejected    []string // причины отклонения
+		quarantined []string // причины задержки
+	}{
+		{name: "within limits", quotes: []models.RateQuote{quote("0.95", "0.96", "a", now)}, accepted: 1},
+		{name: "zero", quotes: []models.RateQuote{quote("0", "0", "a", now)},
+			rejected: []string{models.ReasonNonPositive}},
+		{name: "negative", quotes: []models.RateQuote{quote("-1", "1", "a", now)},
+			rejected: []string{models.ReasonNonPositive}},
+		{name: "bid above ask", quotes: []models.RateQuote{quote("0.91", "0.9", "a", now)},
+			rejected: []string{models.ReasonNonPositive}},
+		{name: "stale", quotes: []models.RateQuote{quote("0.9", "0.9", "a", now.Add(-time.Hour))},
+			rejected: []string{models.ReasonStale}},
+		{name: "spike", quotes: []models.RateQuote{quote("90", "90", "a", now)},
+			quarantined: []string{models.ReasonDeviation}},
+		{name: "drop", quotes: []models.RateQuote{quote("0.5", "0.5", "a", now)},
+			quarantined: []string{models.ReasonDeviation}},
+		{name: "sources agree", quotes: []models.RateQuote{quote("0.9", "0.9", "a", now), quote("0.91", "0.91", "b", now)},
+			accepted: 1},
+		{name: "sources disagree", quotes: []models.RateQuote{quote("0.9", "0.9", "a", now), quote("0.95", "0.95", "b", now)},
+			quarantined: []string{models.ReasonDivergence, models.ReasonDivergence}},
+		// Неверная котировка одного источника не мешает проверке остальных
+		{name: "invalid source ignored", quotes: []models.RateQuote{quote("0", "0", "a", now), quote("0.9", "0.9", "b", now)},
+			accepted: 1, rejected: []string{models.ReasonNonPositive}},
+	}
+	guard := rates.NewGuard(0.1, 0.02)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := guard.Check(previous, tt.quotes)
+			if len(v.Accepted) != tt.accepted {
+				t.Fatalf("accepted = %+v, want %d", v.Accepted, tt.accepted)
+			}
+			if got := reasons(v.Rejected); !slices.Equal(got, tt.rejected) {
+				t.Fatalf("rejected = %v, want %v", got, tt.rejected)
+			}
+			if got := reasons(v.Quarantined); !slices.Equal(got, tt.quarantined) {
+				t.Fatalf("quarantined = %v, want %v", got, tt.quarantined)
+			}
+			for _, q := range v.Quarantined {
+				if q.Status != models.QuarantinePending || !q.PreviousMid.Equal(dec("0.9")) {
+					t.Fatalf("quarantined quote %+v, want pending with previous mid 0.9", q)
+				}
+			}
+		})
+	}
+}
+
+func TestGuardDisabled(t *testing.T) {
+	previous := rates.NewBook([]models.RateQuote{{Base: "USD", Quote: "EUR", Bid: dec("0.9"), Ask: dec("0.9")}})
+	v := rates.NewGuard(0, 0).Check(previous, []models.RateQuote{
+		{Base: "USD", Quote: "EUR", Bid: dec("90"), Ask: dec("90"), Source: "a"},
+		{Base: "USD", Quote: "EUR", Bid: dec("9"), Ask: dec("9"), Source: "b"},
+	})
+	// Без порогов принимается котировка первого источника
+	if len(v.Accepted) != 1 || v.Accepted[0].Source != "a" || len(v.Quarantined) != 0 {
+		t.Fatalf("verdict = %+v", v)
+	}
+}
+
+func TestHalted(t *testing.T) {
+	halted := map[[2]string]bool{{"USD", "BTC"}: true}
+	if got := rates.Halted([]string{"EUR", "USD", "BTC"}, halted); len(got) != 1 || got[0] != [2]string{"USD", "BTC"} {
+		t.Fatalf("halted = %v", got)
+	}
+	// Остановка действует в обе стороны
+	if got := rates.Halted([]string{"BTC", "USD"}, halted); len(got) != 1 {
+		t.Fatalf("reverse halted = %v", got)
+	}
+	if got := rates.Halted([]string{"EUR", "USD"}, halted); len(got) != 0 {
+		t.Fatalf("unrelated path halted: %v", got)
+	}
+}
+
+func reasons(quotes []models.QuarantinedQuote) []string {
+	var result []string
+	for _, q := range quotes {
+		result = append(result, q.Reason)
+	}
+	return result
+}
@@ -0,0 +1,180 @@
+package rates
+
+import (
+	"main/internal/domain/models"
+	"sort"
+	"sync"
+)
+
+// Hub раздает подписчикам книгу котировок после каждого обновления курсов.
+// Публикация никогда не блокируется: подписчик хранит только последнюю
+// книгу, и медленный клиент пропускает промежуточные обновления, а не тормозит остальных.
+type Hub struct {
+	engine *Engine
+
+	mu   sync.RWMutex
+	book Book
+	subs map[*Feed]struct{}
+}
+
+func NewHub(engine *Engine) *Hub {
+	return &Hub{
+		engine: engine,
+		subs:   make(map[*Feed]struct{}),
+	}
+}
+
+// Publish рассылает новую книгу котировок всем подписчикам
+func (h *Hub) Publish(book Book) {
+	h.mu.Lock()
+	h.book = book
+	subs := make([]*Feed, 0, len(h.subs))
+	for f := range h.subs {
+		subs = append(subs, f)
+	}
+	h.mu.Unlock()
+
+	for _, f := range subs {
+		f.push(book)
+	}
+}
+
+// Subscribe создает подписку на курсы пар pairs.
+// Пустой список — курсы всех валют книги к опорной валюте.
+func (h *Hub) Subscribe(pairs [][2]string) *Feed {
+	f := &Feed{
+		hub:    h,
+		engine: h.engine,
+		pairs:  pairs,
+		notify: make(chan struct{}, 1),
+		last:   make(map[[2]string]models.Conversion),
+	}
+
+	h.mu.Lock()
+	h.subs[f] = struct{}{}
+	f.pending = h.book
+	h.mu.Unlock()
+	return f
+}
+
+// Book возвращает последнюю опубликованную книгу котировок
+func (h *Hub) Book() Book {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.book
+}
+
+// Subscribers возвращает число активных подписок
+func (h *Hub) Subscribers() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs)
+}
+
+func (h *Hub) unsubscribe(f *Feed) {
+	h.mu.Lock()
+	delete(h.subs, f)
+	h.mu.Unlock()
+}
+
+// Feed — подписка на курсы. Хранит последнюю полученную книгу и курсы,
+// уже отправленные клиенту, чтобы отдавать только изменения.
+type Feed struct {
+	hub    *Hub
+	engine *Engine
+	pairs  [][2]string
+	notify chan struct{}
+
+	mu      sync.Mutex
+	pending Book
+	last    map[[2]string]models.Conversion
+}
+
+func (f *Feed) push(book Book) {
+	f.mu.Lock()
+	f.pending = book
+	f.mu.Unlock()
+
+	select {
+	case f.notify <- struct{}{}:
+	default: // сигнал уже ждет обработки
+	}
+}
+
+// Updates сигнализирует о новой книге котировок
+func (f *Feed) Updates() <-chan struct{} {
+	return f.notify
+}
+
+// Snapshot возвращает текущие курсы всех пар подписки
+func (f *Feed) Snapshot() []models.Conversion {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.last = make(map[[2]string]models.Conversion)
+	return f.diff(f.pending)
+}
+
+// Changes возвращает курсы, изменившиеся с последней отправки
+func (f *Feed) Changes() []models.Conversion {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.diff(f.pending)
+}
+
+// Close отменяет подписку
+func (f *Feed) Close() {
+	f.hub.unsubscribe(f)
+}
+
+func (f *Feed) diff(book Book) []models.Conversion {
+	var changed []models.Conversion
+	for _, pair := range f.pairsFor(book) {
+		conv, err := f.engine.Convert(book, pair[0], pair[1])
+		if err != nil {
+			continue
+		}
+		if prev, ok := f.last[pair]; ok && prev.Bid.Equal(conv.Bid) && prev.Ask.Equal(conv.Ask) {
+			continue
+		}
+		f.last[pair] = conv
+		changed = append(changed, conv)
+	}
+	return changed
+}
+
+func (f *Feed) pairsFor(book Book) [][2]string {
+	if len(f.pairs) > 0 {
+		return f.pairs
+	}
+
+	pivot := f.engine.Pivot()
+	seen := make(map[string]bool)
+	var pairs [][2]string
+	for _, codes := range sortedPairs(book) {
+		for _, code := range codes {
+			if code == pivot || seen[code] {
+				continue
+			}
+			seen[code] = true
+			pairs = append(pairs, [2]string{pivot, code})
+		}
+	}
+	return pairs
+}
+
+// sortedPairs возвращает пары книги в детерминированном порядке
+func sortedPairs(book Book) [][2]string {
+	pairs := make([][2]string, 0, len(book))
+	for pair := range book {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	return pairs
+}
@@ -0,0 +1,108 @@
+package rates_test
+
+import (
+	"main/internal/domain/models"
+	"main/internal/rates"
+	"testing"
+	"time"
+)
+
+func usdEUR(bid string) rates.Book {
+	return rates.NewBook([]models.RateQuote{
+		{Base: "USD", Quote: "EUR", Bid: dec(bid), Ask: dec(bid), Source: "test", UpdatedAt: time.Now()},
+		{Base: "USD", Quote: "BTC", Bid: dec("0.00002"), Ask: dec("0.00002"), Source: "test", UpdatedAt: time.Now()},
+	})
+}
+
+func TestHubFanOut(t *testing.T) {
+	hub := rates.NewHub(rates.NewEngine("USD", 0))
+	hub.Publish(usdEUR("0.9"))
+
+	all := hub.Subscribe(nil)
+	eur := hub.Subscribe([][2]string{{"EUR", "USD"}})
+	defer all.Close()
+	defer eur.Close()
+
+	// Снимок: все валюты к опорной или только пары подписки
+	if got := all.Snapshot(); len(got) != 2 || got[0].To != "BTC" || got[1].To != "EUR" {
+		t.Fatalf("snapshot = %+v, want USD/BTC and USD/EUR", got)
+	}
+	if got := eur.Snapshot(); len(got) != 1 || got[0].From != "EUR" || got[0].To != "USD" {
+		t.Fatalf("snapshot = %+v, want EUR/USD", got)
+	}
+
+	hub.Publish(usdEUR("0.8"))
+	for _, f := range []*rates.Feed{all, eur} {
+		select {
+		case <-f.Updates():
+		default:
+			t.Fatal("subscriber was not notified")
+		}
+		// Отдаются только изменившиеся курсы
+		if got := f.Changes(); len(got) != 1 || !got[0].Mid().Equal(dec("0.8")) && !got[0].Mid().Equal(dec("1.25")) {
+			t.Fatalf("changes = %+v, want the new EUR rate only", got)
+		}
+		if got := f.Changes(); len(got) != 0 {
+			t.Fatalf("repeated changes = %+v, want none", got)
+		}
+	}
+}
+
+func TestHubSlowSubscriber(t *testing.T) {
+	hub := rates.NewHub(rates.NewEngine("USD", 0))
+	slow := hub.Subscribe([][2]string{{"USD", "EUR"}})
+	defer slow.Close()
+	slow.Snapshot()
+
+	// Публикация не ждет читателя: подписчик, который не читает, получает один
+	// сигнал и только последнюю книгу
+	done := make(chan struct{})
+	go func() {
+		for _, bid := range []string{"0.7", "0.8", "0.9"} {
+			hub.Publish(usdEUR(bid))
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a slow subscriber")
+	}
+
+	<-slow.Updates()
+	if got := slow.Changes(); len(got) != 1 || !got[0].Bid.Equal(dec("0.9")) {
+		t.Fatalf("changes = %+v, want only the latest rate 0.9", got)
+	}
+	select {
+	case <-slow.Updates():
+		t.Fatal("extra notification queued")
+	default:
+	}
+}
+
+func TestHubClose(t *testing.T) {
+	hub := rates.NewHub(rates.NewEngine("USD", 0))
+	a := hub.Subscribe(nil)
+	b := hub.Subscribe(nil)
+	if hub.Subscribers() != 2 {
+		t.Fatalf("subscribers = %d, want 2", hub.Subscribers())
+	}
+
+	a.Close()
+	if hub.Subscribers() != 1 {
+		t.Fatalf("subscribers = %d, want 1", hub.Subscribers())
+	}
+	// Закрытая подписка больше не получает сигналов
+	hub.Publish(usdEUR("0.9"))
+	select {
+	case <-a.Updates():
+		t.Fatal("closed feed was notified")
+	default:
+	}
+	select {
+	case <-b.Updates():
+	default:
+		t.Fatal("open feed was not notified")
+	}
+	b.Close()
+}
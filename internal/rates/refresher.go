@@ -0,0 +1,51 @@
+package rates
+
+import (
+	"context"
+	"log/slog"
+	"main/internal/lib/logger/sl"
+	"time"
+)
+
+// Updater обновляет и сохраняет котировки
+type Updater interface {
+	UpdateExchangeRates(ctx context.Context) error
+}
+
+// Refresher периодически обновляет курсы, чтобы подписчики получали
+// изменения без обращения клиентов к сервису.
+type Refresher struct {
+	log      *slog.Logger
+	updater  Updater
+	interval time.Duration
+}
+
+func NewRefresher(log *slog.Logger, updater Updater, interval time.Duration) *Refresher {
+	return &Refresher{log: log, updater: updater, interval: interval}
+}
+
+// Run обновляет курсы с заданным интервалом до отмены ctx.
+// Нулевой интервал отключает периодическое обновление.
+func (r *Refresher) Run(ctx context.Context) {
+	const op = "rates.Refresher.Run"
+	log := r.log.With(slog.String("op", op))
+
+	if r.interval <= 0 {
+		log.Info("periodic rate refresh disabled")
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.updater.UpdateExchangeRates(ctx); err != nil {
+				log.Error("failed to refresh rates", sl.Err(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package rates
+
+import (
+	"context"
+	"fmt"
+	"main/internal/domain/models"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Source — источник котировок
+type Source interface {
+	// Name возвращает имя источника для логов и диагностики
+	Name() string
+	// Quotes возвращает котировки пар для валют справочника, которые источник поддерживает.
+	// pivot — опорная валюта, относительно которой источник котирует активы.
+	Quotes(ctx context.Context, pivot string, currencies []models.Currency) ([]models.RateQuote, error)
+}
+
+// DefaultSources возвращает источники для фиатных валют и криптоактивов. Их пары
+// не пересекаются, поэтому согласованность источников Guard проверяет, только
+// если добавить еще один источник тех же пар.
+// timeout ограничивает один запрос к источнику, 0 — без ограничения; дедлайн ctx
+// действует независимо от него.
+func DefaultSources(timeout time.Duration) []Source {
+	client := newHTTPClient(timeout)
+	return []Source{
+		NewExchangeRateAPI(client),
+		NewCoinGecko(client),
+	}
+}
+
+// Fetch опрашивает источники и объединяет котировки. Котировки одной пары
+// от разных источников сохраняются все — их согласованность проверяет Guard.
+// Ошибка одного источника не мешает остальным.
+func Fetch(ctx context.Context, sources []Source, pivot string, currencies []models.Currency) ([]models.RateQuote, error) {
+	var result []models.RateQuote
+	var errs []error
+	for _, src := range sources {
+		quotes, err := src.Quotes(ctx, pivot, currencies)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", src.Name(), err))
+			continue
+		}
+		result = append(result, quotes...)
+	}
+
+	if len(result) == 0 && len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return result, nil
+}
+
+// newHTTPClient возвращает клиент со стандартной проверкой сертификатов: подмена
+// ответа источника подменила бы котировки
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: otelhttp.NewTransport(http.DefaultTransport)}
+}
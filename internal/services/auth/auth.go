@@ -6,10 +6,15 @@ import (
 	"fmt"
 	"log/slog"
 	"main/internal/domain/models"
+	"main/internal/lib/i18n"
 	"main/internal/lib/jwt"
+	"main/internal/lib/logger/sl"
+	"main/internal/outbox"
 	"main/internal/storage"
+	"main/internal/tracing"
 	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -20,15 +25,15 @@ type serverAuth struct {
 	userSaver    UserSaver
 	userProvider UserProvider
 	tokenTTL     time.Duration
+	admins       map[string]struct{}
 }
 
+// UserSaver создает пользователя вместе с кошельками и событием регистрации
 type UserSaver interface {
-	SaveUser(
-		ctx context.Context,
-		username string,
-		email string,
-		passHash []byte,
-	) (message string, err error)
+	storage.Transactor
+	CreateUser(ctx context.Context, user models.User) error
+	AddWalletUser(ctx context.Context, idUser uuid.UUID) error
+	AppendEvents(ctx context.Context, events []models.OutboxEvent) error
 }
 
 type UserProvider interface {
@@ -44,12 +49,19 @@ func New(
 	userSaver UserSaver,
 	userProvider UserProvider,
 	tokenTTL time.Duration,
+	adminEmails []string,
 ) *serverAuth {
+	admins := make(map[string]struct{}, len(adminEmails))
+	for _, email := range adminEmails {
+		admins[email] = struct{}{}
+	}
+
 	return &serverAuth{
 		log:          log,
 		userSaver:    userSaver,
 		userProvider: userProvider,
 		tokenTTL:     tokenTTL,
+		admins:       admins,
 	}
 }
 
@@ -60,11 +72,13 @@ func (a *serverAuth) LoginUser(
 	password string,
 ) (string, error) {
 	const op = "auth.LoginUser"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 	log := a.log.With(
 		slog.String("op", op),
 		slog.String("email", email),
 	)
-	log.Info("Login user")
+	log.InfoContext(ctx, "Login user")
 	if a.userProvider == nil {
 		return "", errors.New("userProvider is not initialized")
 	}
@@ -72,21 +86,31 @@ func (a *serverAuth) LoginUser(
 
 	if err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
-			a.log.Warn("User not found", err)
+			a.log.WarnContext(ctx, "User not found", sl.Err(err))
 			return "", fmt.Errorf("%s: %w", op, storage.ErrInvalidCredentials)
 		}
-		a.log.Error("failed to get user", err)
+		a.log.ErrorContext(ctx, "failed to get user", sl.Err(err))
+		tracing.Fail(span, err)
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
 
 	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)); err != nil {
-		a.log.Info("Invalid password", err)
+		a.log.InfoContext(ctx, "Invalid password", sl.Err(err))
 		return "", fmt.Errorf("%s: %w", op, storage.ErrInvalidCredentials)
 	}
 
+	// Адреса из конфигурации получают роль администратора
+	if _, ok := a.admins[user.Email]; ok {
+		user.Role = models.RoleAdmin
+	}
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+
 	token, err := jwt.NewToken(user, a.tokenTTL)
 	if err != nil {
-		a.log.Error("failed to generate token", err)
+		a.log.ErrorContext(ctx, "failed to generate token", sl.Err(err))
+		tracing.Fail(span, err)
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
 	return token, nil
@@ -100,29 +124,69 @@ func (a *serverAuth) RegisterUser(
 	password string,
 ) (string, error) {
 	const op = "auth.RegisterUser"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 	log := a.log.With(
 		slog.String("op", op),
 		slog.String("email", email),
 	)
 
-	log.Info("Registering new user")
+	log.InfoContext(ctx, "Registering new user")
 	passHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		log.Error("failed to hash password", err)
+		log.ErrorContext(ctx, "failed to hash password", sl.Err(err))
+		tracing.Fail(span, err)
 		return "", err
 	}
-	fmt.Print(passHash)
 
-	uid, err := a.userSaver.SaveUser(ctx, username, email, passHash)
+	// Язык, согласованный для запроса регистрации, сохраняется как предпочтение пользователя
+	var language string
+	if lang, ok := i18n.Lookup(ctx); ok {
+		language = string(lang)
+	}
+
+	err = a.saveUser(ctx, models.User{
+		ID:       uuid.New(),
+		Username: username,
+		Email:    email,
+		PassHash: passHash,
+		Language: language,
+		Role:     models.RoleUser,
+	})
 	if err != nil {
 		if errors.Is(err, storage.ErrUserExists) {
-			log.Warn("User already exists", err)
-			return "", fmt.Errorf("%s: %w", op, storage.ErrInvalidCredentials)
+			log.WarnContext(ctx, "User already exists", sl.Err(err))
+			return "", fmt.Errorf("%s: %w", op, storage.ErrUserExists)
 		}
-		log.Error("failed to save user", err)
+		log.ErrorContext(ctx, "failed to save user", sl.Err(err))
+		tracing.Fail(span, err)
 		return "", err
 	}
-	log.Info("User saved")
+	log.InfoContext(ctx, "User saved")
+
+	return i18n.Tc(ctx, i18n.MsgUserRegistered), nil
+}
+
+// saveUser создает пользователя, его кошельки во всех включенных валютах
+// и событие UserRegistered в одной транзакции
+func (a *serverAuth) saveUser(ctx context.Context, user models.User) error {
+	event, err := outbox.NewEvent(user.ID, models.EventUserRegistered, models.UserRegistered{
+		UserID:   user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+		Language: user.Language,
+	})
+	if err != nil {
+		return err
+	}
 
-	return uid, nil
+	return a.userSaver.WithinTx(ctx, func(ctx context.Context) error {
+		if err := a.userSaver.CreateUser(ctx, user); err != nil {
+			return err
+		}
+		if err := a.userSaver.AddWalletUser(ctx, user.ID); err != nil {
+			return fmt.Errorf("Ошибка создания кошелька: %w", err)
+		}
+		return a.userSaver.AppendEvents(ctx, []models.OutboxEvent{event})
+	})
 }
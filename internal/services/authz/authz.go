@@ -0,0 +1,39 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"main/internal/domain/models"
+	"main/internal/lib/identity"
+	"main/internal/lib/jwt"
+	"main/internal/storage"
+)
+
+// Admin проверяет токен и наличие у пользователя роли admin.
+// Внутренний клиент, опознанный по сертификату mTLS с ролью admin, проходит без токена.
+func Admin(ctx context.Context, token string) (*jwt.Claims, error) {
+	if id, ok := identity.From(ctx); ok && token == "" {
+		if id.Role != models.RoleAdmin {
+			return nil, storage.ErrPermissionDenied
+		}
+		return &jwt.Claims{Username: id.Name, Email: id.Name, Role: id.Role}, nil
+	}
+
+	claims, err := User(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Role != models.RoleAdmin {
+		return nil, storage.ErrPermissionDenied
+	}
+	return claims, nil
+}
+
+// User проверяет токен пользователя
+func User(token string) (*jwt.Claims, error) {
+	claims, err := jwt.ValidateToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrInvalidToken, err)
+	}
+	return claims, nil
+}
@@ -0,0 +1,121 @@
+package currency
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"main/internal/domain/models"
+	"main/internal/lib/i18n"
+	"main/internal/lib/logger/sl"
+	"main/internal/services/authz"
+	"main/internal/storage"
+	"main/internal/tracing"
+	"strings"
+)
+
+// ==================CURRENCY====================
+
+func New(
+	log *slog.Logger,
+	provider CurrencyProvider,
+	saver CurrencySaver,
+) *Currency {
+	return &Currency{
+		log:      log,
+		provider: provider,
+		saver:    saver,
+	}
+}
+
+type Currency struct {
+	log      *slog.Logger
+	provider CurrencyProvider
+	saver    CurrencySaver
+}
+
+type CurrencyProvider interface {
+	Currencies(ctx context.Context, onlyEnabled bool) ([]models.Currency, error)
+}
+
+type CurrencySaver interface {
+	SaveCurrency(ctx context.Context, currency models.Currency) (models.Currency, error)
+	SetCurrencyEnabled(ctx context.Context, code string, enabled bool) (models.Currency, error)
+}
+
+// ListCurrencies возвращает включенные валюты справочника
+func (c *Currency) ListCurrencies(ctx context.Context) ([]models.Currency, error) {
+	const op = "currency.ListCurrencies"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	log := c.log.With(slog.String("op", op))
+
+	currencies, err := c.provider.Currencies(ctx, true)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to list currencies", sl.Err(err))
+		tracing.Fail(span, err)
+		return nil, err
+	}
+	return currencies, nil
+}
+
+// AddCurrency добавляет валюту в справочник или обновляет существующую
+func (c *Currency) AddCurrency(ctx context.Context, token string, currency models.Currency) (string, models.Currency, error) {
+	const op = "currency.AddCurrency"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	log := c.log.With(
+		slog.String("op", op),
+		slog.String("code", currency.Code),
+	)
+	log.InfoContext(ctx, "Add currency")
+
+	if _, err := authz.Admin(ctx, token); err != nil {
+		log.WarnContext(ctx, "access denied", sl.Err(err))
+		return "", models.Currency{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	currency.Code = strings.ToUpper(strings.TrimSpace(currency.Code))
+	if len(currency.Code) != 3 || currency.Decimals < 0 {
+		return "", models.Currency{}, fmt.Errorf("%s: %w: %s", op, storage.ErrInvalidCurrency, currency.Code)
+	}
+	currency.Enabled = true
+
+	saved, err := c.saver.SaveCurrency(ctx, currency)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to save currency", sl.Err(err))
+		tracing.Fail(span, err)
+		return "", models.Currency{}, err
+	}
+	log.InfoContext(ctx, "Currency saved")
+	return i18n.Tc(ctx, i18n.MsgCurrencySaved), saved, nil
+}
+
+// SetCurrencyEnabled включает или отключает валюту
+func (c *Currency) SetCurrencyEnabled(ctx context.Context, token string, code string, enabled bool) (string, models.Currency, error) {
+	const op = "currency.SetCurrencyEnabled"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	log := c.log.With(
+		slog.String("op", op),
+		slog.String("code", code),
+		slog.Bool("enabled", enabled),
+	)
+	log.InfoContext(ctx, "Set currency status")
+
+	if _, err := authz.Admin(ctx, token); err != nil {
+		log.WarnContext(ctx, "access denied", sl.Err(err))
+		return "", models.Currency{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	currency, err := c.saver.SetCurrencyEnabled(ctx, strings.ToUpper(code), enabled)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to update currency", sl.Err(err))
+		tracing.Fail(span, err)
+		return "", models.Currency{}, err
+	}
+
+	if enabled {
+		return i18n.Tc(ctx, i18n.MsgCurrencyOn), currency, nil
+	}
+	return i18n.Tc(ctx, i18n.MsgCurrencyOff), currency, nil
+}
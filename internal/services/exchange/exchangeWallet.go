@@ -5,97 +5,381 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"main/internal/domain/models"
+	"main/internal/lib/i18n"
+	"main/internal/lib/logger/sl"
+	"main/internal/metrics"
+	"main/internal/outbox"
+	"main/internal/rates"
+	"main/internal/services/authz"
+	"main/internal/services/ledger"
+	"main/internal/storage"
+	"main/internal/tracing"
+	"strings"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 // ==================WALLET====================
 
 func NewExchange(
 	log *slog.Logger,
-	exchCurrency ExchangeCurrency,
-	exchRate GetExchangeRates,
+	repo Repository,
+	quotes RateProvider,
+	publisher BalancePublisher,
+	subscriber RateSubscriber,
+	pivot string,
+	fee float64,
 	tokenTTL time.Duration,
 ) *Exchange {
 	return &Exchange{
-		log:          log,
-		exchCurrency: exchCurrency,
-		exchRate:     exchRate,
-		tokenTTL:     tokenTTL,
+		log:        log,
+		repo:       repo,
+		quotes:     quotes,
+		publisher:  publisher,
+		subscriber: subscriber,
+		pivot:      pivot,
+		fee:        decimal.NewFromFloat(fee),
+		tokenTTL:   tokenTTL,
 	}
 }
 
 type Exchange struct {
-	log          *slog.Logger
-	exchCurrency ExchangeCurrency
-	exchRate     GetExchangeRates
-	tokenTTL     time.Duration
+	log        *slog.Logger
+	repo       Repository
+	quotes     RateProvider
+	publisher  BalancePublisher
+	subscriber RateSubscriber
+	pivot      string
+	fee        decimal.Decimal // Доля полученной суммы, которая удерживается как комиссия
+	tokenTTL   time.Duration
 }
 
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 )
 
-type ExchangeCurrency interface {
-	ExchangeCurrency(ctx context.Context,
-		token string,
-		from_currency string,
-		to_currency string,
-		amount float32,
-	) (string,
-		float32,
-		map[string]float32,
-		error)
+// Repository — данные, с которыми работает обмен
+type Repository interface {
+	storage.Transactor
+	storage.CurrencyRepository
+	storage.WalletRepository
+	AppendEvents(ctx context.Context, events []models.OutboxEvent) error
+}
+
+// RateProvider считает курсы пар по сохраненным котировкам
+type RateProvider interface {
+	Rates(ctx context.Context, codes []string) (map[string]decimal.Decimal, error)
+	Convert(ctx context.Context, from, to string) (models.Conversion, error)
 }
 
-type GetExchangeRates interface {
-	GetExchangeRates(ctx context.Context,
-		token string,
-	) (string, map[string]float32, error)
+// BalancePublisher сообщает подписчикам об изменении кошельков
+type BalancePublisher interface {
+	Publish(event models.BalanceEvent)
 }
 
+// RateSubscriber раздает обновления курсов подписчикам
+type RateSubscriber interface {
+	Subscribe(pairs [][2]string) *rates.Feed
+}
+
+// Pivot возвращает опорную валюту, относительно которой отдаются курсы
+func (e *Exchange) Pivot() string {
+	return e.pivot
+}
+
+// ExchangeCurrency обменивает amount в валюте from_currency на to_currency. Пустые
+// идентификаторы кошельков — основные кошельки валют.
 func (e *Exchange) ExchangeCurrency(ctx context.Context, token string,
-	from_currency string, to_currency string, amount float32) (string, float32, map[string]float32, error) {
+	from_currency string, to_currency string, amount decimal.Decimal,
+	fromWalletID string, toWalletID string) (string, models.ExchangeResult, error) {
 
 	const op = "exchange.ExchangeCurrency"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 	log := e.log.With(
 		slog.String("op", op),
-		slog.String("token", token),
 		slog.String("from_currency", from_currency),
 		slog.String("to_currency", to_currency),
-		slog.String("amount", fmt.Sprintf("%f", amount)),
+		slog.String("amount", amount.String()),
+		slog.String("from_wallet_id", fromWalletID),
+		slog.String("to_wallet_id", toWalletID),
 	)
-	log.Info("Exchange currency")
-	if e.exchCurrency == nil {
-		return "", 0, nil, errors.New("ExchangeCurrency is not initialized")
+	log.InfoContext(ctx, "Exchange currency")
+
+	claims, err := authz.User(token)
+	if err != nil {
+		log.WarnContext(ctx, "invalid token", sl.Err(err))
+		return "", models.ExchangeResult{}, err
+	}
+
+	result, err := e.exchange(ctx, claims.UserID, from_currency, to_currency, amount, fromWalletID, toWalletID)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to exchange wallet", sl.Err(err))
+		tracing.Fail(span, err)
+		return "", models.ExchangeResult{}, err
+	}
+	log.InfoContext(ctx, "Exchange OK",
+		slog.String("rate", result.Conversion.Bid.String()),
+		slog.String("fee", result.Fee.String()),
+		slog.Any("path", result.Conversion.Path),
+		slog.Any("sources", result.Conversion.Sources),
+	)
+	metrics.Exchange(from_currency, amount, to_currency, result.Amount)
+	return i18n.Tc(ctx, i18n.MsgExchanged), result, nil
+}
+
+// exchange списывает amount в валюте from и зачисляет результат обмена в валюте to.
+// Оба кошелька блокируются до конца транзакции, списание, зачисление и событие outbox
+// фиксируются вместе.
+func (e *Exchange) exchange(ctx context.Context, userID uuid.UUID, from, to string, amount decimal.Decimal, fromWalletID, toWalletID string) (models.ExchangeResult, error) {
+	if from == to {
+		return models.ExchangeResult{}, fmt.Errorf("%w: %s/%s", storage.ErrInvalidPair, from, to)
+	}
+	fromID, err := ledger.WalletID(fromWalletID)
+	if err != nil {
+		return models.ExchangeResult{}, err
+	}
+	toID, err := ledger.WalletID(toWalletID)
+	if err != nil {
+		return models.ExchangeResult{}, err
+	}
+
+	fromAsset, err := ledger.CheckCurrency(ctx, e.repo, from, models.OperationExchange)
+	if err != nil {
+		return models.ExchangeResult{}, err
+	}
+	toAsset, err := ledger.CheckCurrency(ctx, e.repo, to, models.OperationExchange)
+	if err != nil {
+		return models.ExchangeResult{}, err
+	}
+	if err := ledger.CheckAmount(fromAsset, amount); err != nil {
+		return models.ExchangeResult{}, err
 	}
 
-	message, exchAmount, balance, err := e.exchCurrency.ExchangeCurrency(ctx, token, from_currency, to_currency, amount)
+	// Получение курса: прямая котировка или кросс-курс через опорную валюту
+	conv, err := e.quotes.Convert(ctx, from, to)
 	if err != nil {
-		log.Error("failed to exchange wallet", err)
-		return "", 0, nil, err
+		return models.ExchangeResult{}, err
 	}
-	log.Info("Exchange OK")
-	return message, exchAmount, balance, nil
+
+	// Клиент продает исходную валюту по bid; результат округляется вниз до точности целевого актива
+	received, fee := e.charge(toAsset, amount.Mul(conv.Bid))
+	if !received.IsPositive() || received.LessThan(toAsset.MinAmount) {
+		return models.ExchangeResult{}, fmt.Errorf("%w: после обмена получится %s %s, минимум %s", storage.ErrAmountBelowMinimum, received, to, toAsset.MinAmount)
+	}
+
+	var fromWallet, toWallet models.UserWallet
+	var balances map[string]decimal.Decimal
+	err = e.repo.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		fromWallet, toWallet, err = e.lockPair(ctx, userID, from, to, fromID, toID)
+		if err != nil {
+			return err
+		}
+
+		// Проверка достаточности средств для обмена
+		if fromWallet.Balance.LessThan(amount) {
+			return fmt.Errorf("%w %s: текущий баланс %s, запрашиваемая сумма %s", storage.ErrInsufficientFunds, from, fromWallet.Balance, amount)
+		}
+		fromWallet.Balance = fromWallet.Balance.Sub(amount)
+		toWallet.Balance = toWallet.Balance.Add(received)
+
+		event, err := outbox.NewEvent(userID, models.EventCurrencyExchanged, models.CurrencyExchanged{
+			UserID:       userID,
+			FromCurrency: from,
+			ToCurrency:   to,
+			Amount:       amount,
+			Received:     received,
+			Fee:          fee,
+			Rate:         conv.Bid,
+			Path:         conv.Path,
+			Sources:      conv.Sources,
+			FromWalletID: fromWallet.ID,
+			ToWalletID:   toWallet.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if err := e.repo.SaveWallet(ctx, fromWallet); err != nil {
+			return err
+		}
+		if err := e.repo.SaveWallet(ctx, toWallet); err != nil {
+			return err
+		}
+		if err := e.repo.AppendEvents(ctx, []models.OutboxEvent{event}); err != nil {
+			return err
+		}
+		balances, err = ledger.Balances(ctx, e.repo, userID)
+		return err
+	})
+	if err != nil {
+		return models.ExchangeResult{}, err
+	}
+
+	if e.publisher != nil {
+		e.publisher.Publish(models.BalanceEvent{
+			UserID: userID,
+			Kind:   models.BalanceExchange,
+			Changes: []models.WalletChange{
+				{WalletID: fromWallet.ID, Currency: from, Amount: amount.Neg(), Balance: fromWallet.Balance},
+				{WalletID: toWallet.ID, Currency: to, Amount: received, Balance: toWallet.Balance},
+			},
+			Balances: balances,
+			At:       time.Now(),
+		})
+	}
+
+	return models.ExchangeResult{
+		Amount:     received,
+		Fee:        fee,
+		Balance:    balances,
+		Conversion: conv,
+	}, nil
+}
+
+// charge удерживает комиссию из суммы обмена в целевой валюте. Комиссия округляется
+// вверх, а зачисление вниз до точности актива, поэтому их сумма не превышает gross.
+func (e *Exchange) charge(asset models.Currency, gross decimal.Decimal) (received, fee decimal.Decimal) {
+	gross = asset.Truncate(gross)
+	fee = gross.Mul(e.fee).RoundUp(int32(asset.Decimals))
+	return gross.Sub(fee), fee
 }
 
-func (e *Exchange) GetExchangeRates(ctx context.Context, token string) (string, map[string]float32, error) {
+// lockPair блокирует кошельки обмена всегда в порядке кодов валют,
+// чтобы встречные обмены одного пользователя не ждали друг друга по кругу.
+// uuid.Nil вместо идентификатора — основной кошелек валюты.
+func (e *Exchange) lockPair(ctx context.Context, userID uuid.UUID, from, to string, fromID, toID uuid.UUID) (models.UserWallet, models.UserWallet, error) {
+	first, second := from, to
+	firstID, secondID := fromID, toID
+	if second < first {
+		first, second = second, first
+		firstID, secondID = secondID, firstID
+	}
+
+	a, err := ledger.LockWallet(ctx, e.repo, userID, first, firstID)
+	if err != nil {
+		return models.UserWallet{}, models.UserWallet{}, err
+	}
+	b, err := ledger.LockWallet(ctx, e.repo, userID, second, secondID)
+	if err != nil {
+		return models.UserWallet{}, models.UserWallet{}, err
+	}
+	if first != from {
+		a, b = b, a
+	}
+	return a, b, nil
+}
+
+func (e *Exchange) GetExchangeRates(ctx context.Context, token string) (string, map[string]decimal.Decimal, error) {
 
 	const op = "exchange.GetExchangeRates"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	log := e.log.With(slog.String("op", op))
+	log.InfoContext(ctx, "Get exchange rates")
+
+	if _, err := authz.User(token); err != nil {
+		log.WarnContext(ctx, "invalid token", sl.Err(err))
+		return "", nil, err
+	}
+
+	currencies, err := e.repo.Currencies(ctx, true)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to list currencies", sl.Err(err))
+		tracing.Fail(span, err)
+		return "", nil, err
+	}
+	codes := make([]string, 0, len(currencies))
+	for _, c := range currencies {
+		codes = append(codes, c.Code)
+	}
+
+	rates, err := e.quotes.Rates(ctx, codes)
+	if err != nil {
+
+		log.ErrorContext(ctx, "failed to get exchange rate", sl.Err(err))
+		tracing.Fail(span, err)
+		return "", nil, err
+	}
+	log.InfoContext(ctx, "Get Rate OK")
+	return i18n.Tc(ctx, i18n.MsgRatesFetched), rates, nil
+}
+
+// GetQuote возвращает bid/ask пары и путь, по которому посчитан курс
+func (e *Exchange) GetQuote(ctx context.Context, token string, from_currency string, to_currency string) (models.Conversion, error) {
+
+	const op = "exchange.GetQuote"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 	log := e.log.With(
 		slog.String("op", op),
-		slog.String("token", token),
+		slog.String("from_currency", from_currency),
+		slog.String("to_currency", to_currency),
 	)
-	log.Info("Exchange currency")
-	if e.exchRate == nil {
-		return "", nil, errors.New("ExchangeRates is not initialized")
+	log.InfoContext(ctx, "Get quote")
+
+	if _, err := authz.User(token); err != nil {
+		log.WarnContext(ctx, "invalid token", sl.Err(err))
+		return models.Conversion{}, err
+	}
+
+	for _, code := range []string{from_currency, to_currency} {
+		if _, err := ledger.CheckCurrency(ctx, e.repo, code, models.OperationExchange); err != nil {
+			log.WarnContext(ctx, "currency not available", sl.Err(err))
+			return models.Conversion{}, err
+		}
 	}
 
-	message, balance, err := e.exchRate.GetExchangeRates(ctx, token)
+	conv, err := e.quotes.Convert(ctx, from_currency, to_currency)
 	if err != nil {
+		log.ErrorContext(ctx, "failed to get quote", sl.Err(err))
+		tracing.Fail(span, err)
+		return models.Conversion{}, err
+	}
+	log.InfoContext(ctx, "Get quote OK", slog.Any("path", conv.Path))
+	return conv, nil
+}
 
-		log.Error("failed to get exchange rate", err)
-		return "", nil, err
+// SubscribeRates подписывает клиента на курсы пар вида "BTC/EUR".
+// Пустой список — курсы всех валют к опорной.
+func (e *Exchange) SubscribeRates(ctx context.Context, token string, pairs []string) (*rates.Feed, error) {
+
+	const op = "exchange.SubscribeRates"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	log := e.log.With(
+		slog.String("op", op),
+		slog.Any("pairs", pairs),
+	)
+	log.InfoContext(ctx, "Subscribe rates")
+	if e.subscriber == nil {
+		return nil, errors.New("RateSubscriber is not initialized")
+	}
+
+	if _, err := authz.User(token); err != nil {
+		log.WarnContext(ctx, "invalid token", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	parsed := make([][2]string, 0, len(pairs))
+	for _, p := range pairs {
+		pair, err := parsePair(p)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		parsed = append(parsed, pair)
+	}
+
+	return e.subscriber.Subscribe(parsed), nil
+}
+
+func parsePair(s string) ([2]string, error) {
+	from, to, ok := strings.Cut(strings.ToUpper(strings.TrimSpace(s)), "/")
+	if !ok || from == "" || to == "" || from == to {
+		return [2]string{}, fmt.Errorf("%w: %s", storage.ErrInvalidPair, s)
 	}
-	log.Info("Get Rate OK")
-	return message, balance, nil
+	return [2]string{from, to}, nil
 }
@@ -0,0 +1,114 @@
+package exchange_test
+
+import (
+	"context"
+	"main/internal/domain/models"
+	"main/internal/storage"
+	"main/internal/storage/cache"
+	"main/internal/storage/memory"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// roundTrip — задержка запроса к базе, которую добавляет slowStorage
+const roundTrip = 100 * time.Microsecond
+
+// slowStorage — хранилище в памяти, где чтение валют, котировок и остановленных пар
+// стоит одного запроса к базе. События outbox не копятся, чтобы стоимость
+// транзакции хранилища в памяти не росла с числом итераций.
+type slowStorage struct {
+	*memory.Storage
+	reads atomic.Int64
+}
+
+func (s *slowStorage) query() {
+	s.reads.Add(1)
+	time.Sleep(roundTrip)
+}
+
+func (s *slowStorage) Currency(ctx context.Context, code string) (models.Currency, error) {
+	s.query()
+	return s.Storage.Currency(ctx, code)
+}
+
+func (s *slowStorage) Currencies(ctx context.Context, onlyEnabled bool) ([]models.Currency, error) {
+	s.query()
+	return s.Storage.Currencies(ctx, onlyEnabled)
+}
+
+func (s *slowStorage) Quotes(ctx context.Context) ([]models.RateQuote, error) {
+	s.query()
+	return s.Storage.Quotes(ctx)
+}
+
+func (s *slowStorage) HaltedPairs(ctx context.Context) (map[[2]string]bool, error) {
+	s.query()
+	return s.Storage.HaltedPairs(ctx)
+}
+
+func (s *slowStorage) AppendEvents(ctx context.Context, events []models.OutboxEvent) error {
+	return nil
+}
+
+// BenchmarkExchangeCurrency сравнивает обмен с чтением курсов из хранилища и через кеш.
+// reads/op — запросы за валютами и курсами на одну операцию.
+func BenchmarkExchangeCurrency(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		wrap func(storage.Repository) storage.Repository
+	}{
+		{"storage", func(r storage.Repository) storage.Repository { return r }},
+		{"cache", func(r storage.Repository) storage.Repository { return cache.New(r, time.Minute, 0) }},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			slow := &slowStorage{Storage: memory.New()}
+			repo := bc.wrap(slow)
+			e, user, token := newExchange(b, repo, 0)
+			setBalance(b, repo, user, "USD", dec("1000000000"))
+			ctx := context.Background()
+			amount := dec("1")
+
+			slow.reads.Store(0)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, _, err := e.ExchangeCurrency(ctx, token, "USD", "EUR", amount, "", ""); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+			b.ReportMetric(float64(slow.reads.Load())/float64(b.N), "reads/op")
+		})
+	}
+}
+
+// BenchmarkGetQuote — курс пары без записи: проверка валют и расчет кросс-курса
+func BenchmarkGetQuote(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		wrap func(storage.Repository) storage.Repository
+	}{
+		{"storage", func(r storage.Repository) storage.Repository { return r }},
+		{"cache", func(r storage.Repository) storage.Repository { return cache.New(r, time.Minute, 0) }},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			slow := &slowStorage{Storage: memory.New()}
+			e, _, token := newExchange(b, bc.wrap(slow), 0)
+			ctx := context.Background()
+
+			slow.reads.Store(0)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := e.GetQuote(ctx, token, "EUR", "BTC"); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+			b.ReportMetric(float64(slow.reads.Load())/float64(b.N), "reads/op")
+		})
+	}
+}
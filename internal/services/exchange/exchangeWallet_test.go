@@ -0,0 +1,281 @@
+package exchange_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"main/internal/domain/models"
+	"main/internal/lib/jwt"
+	"main/internal/rates"
+	"main/internal/services/exchange"
+	"main/internal/services/quotes"
+	"main/internal/storage"
+	"main/internal/storage/memory"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var discardLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+// setup создает обмен без внешних источников курсов: котировки USD/EUR и USD/BTC
+// сохранены заранее, у пользователя 100 USD
+func setup(t *testing.T) (*exchange.Exchange, *memory.Storage, models.User, string) {
+	t.Helper()
+	repo := memory.New()
+	e, user, token := newExchange(t, repo, 0)
+	return e, repo, user, token
+}
+
+// newExchange заполняет repo валютами, котировками и пользователем со 100 USD
+// и создает обмен с комиссией fee, который работает с repo
+func newExchange(tb testing.TB, repo storage.Repository, fee float64) (*exchange.Exchange, models.User, string) {
+	tb.Helper()
+	ctx := context.Background()
+	for _, c := range []models.Currency{
+		{Code: "USD", Decimals: 2, Enabled: true, ExchangeEnabled: true, MinAmount: dec("0.01")},
+		{Code: "EUR", Decimals: 2, Enabled: true, ExchangeEnabled: true, MinAmount: dec("0.01")},
+		{Code: "BTC", Decimals: 8, Enabled: true, ExchangeEnabled: true, MinAmount: dec("0.0001")},
+		{Code: "RUB", Decimals: 2, Enabled: true, MinAmount: dec("0.01")},
+	} {
+		if _, err := repo.SaveCurrency(ctx, c); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	now := time.Now()
+	err := repo.SaveQuotes(ctx, []models.RateQuote{
+		{Base: "USD", Quote: "EUR", Bid: dec("0.9"), Ask: dec("0.91"), Source: "test", UpdatedAt: now},
+		{Base: "USD", Quote: "BTC", Bid: dec("0.00002"), Ask: dec("0.0000201"), Source: "test", UpdatedAt: now},
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	user := models.User{ID: uuid.New(), Username: "user", Email: "user@example.com", Role: models.RoleUser}
+	if err := repo.CreateUser(ctx, user); err != nil {
+		tb.Fatal(err)
+	}
+	setBalance(tb, repo, user, "USD", dec("100"))
+	token, err := jwt.NewToken(user, time.Hour)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	engine := rates.NewEngine("USD", 0)
+	q := quotes.New(discardLog, repo, engine, rates.NewGuard(0, 0), nil, nil)
+	return exchange.NewExchange(discardLog, repo, q, nil, nil, engine.Pivot(), fee, time.Hour), user, token
+}
+
+func setBalance(tb testing.TB, repo storage.Repository, user models.User, currency string, balance decimal.Decimal) {
+	tb.Helper()
+	ctx := context.Background()
+	err := repo.WithinTx(ctx, func(ctx context.Context) error {
+		wallet, err := repo.LockWallet(ctx, user.ID, currency)
+		if err != nil {
+			return err
+		}
+		wallet.Balance = balance
+		return repo.SaveWallet(ctx, wallet)
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func TestExchangeCurrency(t *testing.T) {
+	e, repo, _, token := setup(t)
+	ctx := context.Background()
+
+	_, result, err := e.ExchangeCurrency(ctx, token, "USD", "EUR", dec("10"), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Amount.Equal(dec("9")) {
+		t.Fatalf("received %s EUR, want 9", result.Amount)
+	}
+	if !result.Balance["USD"].Equal(dec("90")) || !result.Balance["EUR"].Equal(dec("9")) {
+		t.Fatalf("balance = %v, want 90 USD and 9 EUR", result.Balance)
+	}
+
+	// Кросс-курс через опорную валюту; результат округляется вниз до точности BTC
+	_, result, err = e.ExchangeCurrency(ctx, token, "EUR", "BTC", dec("9"), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Conversion.Path) != 3 || !result.Amount.Equal(result.Amount.Truncate(8)) {
+		t.Fatalf("conversion = %+v, amount %s", result.Conversion, result.Amount)
+	}
+
+	pending, err := repo.PendingEvents(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || pending[0].Type != models.EventCurrencyExchanged {
+		t.Fatalf("outbox = %+v, want two exchange events", pending)
+	}
+}
+
+func TestExchangeFee(t *testing.T) {
+	tests := []struct {
+		name     string
+		fee      float64
+		to       string
+		amount   string
+		received string
+		charged  string
+		err      error
+	}{
+		{"no fee", 0, "EUR", "10", "9", "0", nil},
+		{"percent", 0.01, "EUR", "10", "8.91", "0.09", nil},
+		{"rounded up to precision", 0.001, "EUR", "10", "8.99", "0.01", nil},
+		{"crypto precision", 0.005, "BTC", "10", "0.000199", "0.000001", nil},
+		{"fee leaves below minimum", 0.01, "EUR", "0.02", "", "", storage.ErrAmountBelowMinimum},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.New()
+			e, user, token := newExchange(t, repo, tt.fee)
+			ctx := context.Background()
+
+			_, result, err := e.ExchangeCurrency(ctx, token, "USD", tt.to, dec(tt.amount), "", "")
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if !result.Amount.Equal(dec(tt.received)) || !result.Fee.Equal(dec(tt.charged)) {
+				t.Fatalf("received %s, fee %s; want %s, %s", result.Amount, result.Fee, tt.received, tt.charged)
+			}
+			// Списывается вся сумма, зачисляется сумма за вычетом комиссии
+			wantUSD := dec("100").Sub(dec(tt.amount))
+			if !result.Balance["USD"].Equal(wantUSD) || !result.Balance[tt.to].Equal(dec(tt.received)) {
+				t.Fatalf("balance = %v, want %s USD and %s %s", result.Balance, wantUSD, tt.received, tt.to)
+			}
+
+			pending, err := repo.PendingEvents(ctx, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pending) != 1 {
+				t.Fatalf("outbox = %+v, want one exchange event", pending)
+			}
+			var event models.CurrencyExchanged
+			if err := json.Unmarshal(pending[0].Payload, &event); err != nil {
+				t.Fatal(err)
+			}
+			if event.UserID != user.ID || !event.Received.Equal(result.Amount) || !event.Fee.Equal(result.Fee) {
+				t.Fatalf("event = %+v, want received %s and fee %s", event, result.Amount, result.Fee)
+			}
+		})
+	}
+}
+
+func TestExchangeRules(t *testing.T) {
+	e, repo, user, token := setup(t)
+	ctx := context.Background()
+
+	halted := models.QuarantinedQuote{Base: "USD", Quote: "BTC", Bid: dec("1"), Ask: dec("1"),
+		Status: models.QuarantinePending, CreatedAt: time.Now()}
+	if err := repo.QuarantineQuotes(ctx, []models.QuarantinedQuote{halted}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		amount   string
+		want     error
+	}{
+		{"insufficient funds", "USD", "EUR", "100.01", storage.ErrInsufficientFunds},
+		{"same currency", "USD", "USD", "1", storage.ErrInvalidPair},
+		{"exchange disabled", "USD", "RUB", "1", storage.ErrCurrencyNotAllowed},
+		{"unknown currency", "USD", "XXX", "1", storage.ErrInvalidCurrency},
+		{"precision", "USD", "EUR", "0.001", storage.ErrAmountPrecision},
+		{"result below minimum", "USD", "EUR", "0.01", storage.ErrAmountBelowMinimum},
+		{"halted pair", "EUR", "BTC", "1", storage.ErrPairHalted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := e.ExchangeCurrency(ctx, token, tt.from, tt.to, dec(tt.amount), "", "")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	wallets, err := repo.Wallets(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range wallets {
+		if w.Currency == "USD" && !w.Balance.Equal(dec("100")) || w.Currency != "USD" && !w.Balance.IsZero() {
+			t.Fatalf("rejected exchanges changed wallet %+v", w)
+		}
+	}
+}
+
+func TestExchangeSubWallet(t *testing.T) {
+	e, repo, user, token := setup(t)
+	ctx := context.Background()
+
+	travel, err := repo.CreateWallet(ctx, models.UserWallet{UserID: user.ID, Currency: "EUR", Name: "Travel"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, result, err := e.ExchangeCurrency(ctx, token, "USD", "EUR", dec("10"), "", travel.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Balance["EUR"].Equal(dec("9")) {
+		t.Fatalf("balance = %v, want 9 EUR", result.Balance)
+	}
+	wallets, err := repo.Wallets(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range wallets {
+		if w.ID == travel.ID && !w.Balance.Equal(dec("9")) || w.Currency == "EUR" && w.Main() && !w.Balance.IsZero() {
+			t.Fatalf("exchange credited the wrong wallet: %+v", wallets)
+		}
+	}
+
+	// Кошелек другой валюты не подходит
+	_, _, err = e.ExchangeCurrency(ctx, token, "USD", "BTC", dec("10"), "", travel.ID.String())
+	if !errors.Is(err, storage.ErrInvalidWallet) {
+		t.Fatalf("got error %v, want %v", err, storage.ErrInvalidWallet)
+	}
+}
+
+func TestGetQuote(t *testing.T) {
+	e, _, _, token := setup(t)
+	ctx := context.Background()
+
+	conv, err := e.GetQuote(ctx, token, "EUR", "USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conv.From != "EUR" || conv.To != "USD" || !conv.Bid.IsPositive() {
+		t.Fatalf("conversion = %+v", conv)
+	}
+
+	_, rates, err := e.GetExchangeRates(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rates["EUR"]; !ok || len(rates) != 3 {
+		t.Fatalf("rates = %v, want USD, EUR and BTC", rates)
+	}
+
+	if _, err := e.GetQuote(ctx, "bad", "EUR", "USD"); !errors.Is(err, storage.ErrInvalidToken) {
+		t.Fatalf("got error %v, want %v", err, storage.ErrInvalidToken)
+	}
+}
@@ -0,0 +1,107 @@
+// Package ledger — общие правила операций с кошельками: проверки валюты и суммы
+// и чтение баланса. Используется сервисами кошелька и обмена.
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"main/internal/domain/models"
+	"main/internal/storage"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type CurrencyProvider interface {
+	Currency(ctx context.Context, code string) (models.Currency, error)
+}
+
+type WalletProvider interface {
+	Wallets(ctx context.Context, userID uuid.UUID) ([]models.UserWallet, error)
+}
+
+type WalletLocker interface {
+	LockWallet(ctx context.Context, userID uuid.UUID, currency string) (models.UserWallet, error)
+	LockWalletByID(ctx context.Context, userID uuid.UUID, id uuid.UUID) (models.UserWallet, error)
+}
+
+// CheckCurrency проверяет, что валюта есть в справочнике и операция для нее разрешена
+func CheckCurrency(ctx context.Context, currencies CurrencyProvider, code string, operation string) (models.Currency, error) {
+	currency, err := currencies.Currency(ctx, code)
+	if err != nil {
+		if errors.Is(err, storage.ErrCurrencyNotFound) {
+			return models.Currency{}, fmt.Errorf("%w: %s", storage.ErrInvalidCurrency, code)
+		}
+		return models.Currency{}, err
+	}
+	if !currency.Allows(operation) {
+		return models.Currency{}, fmt.Errorf("%w: %s (%s)", storage.ErrCurrencyNotAllowed, code, operation)
+	}
+	return currency, nil
+}
+
+// CheckAmount проверяет сумму операции с учетом точности и минимальной суммы актива
+func CheckAmount(currency models.Currency, amount decimal.Decimal) error {
+	if !amount.IsPositive() {
+		return fmt.Errorf("%w: запрашиваемая сумма %s", storage.ErrInvalidAmount, amount)
+	}
+	if !currency.Truncate(amount).Equal(amount) {
+		return fmt.Errorf("%w: %s допускает не более %d знаков после запятой", storage.ErrAmountPrecision, currency.Code, currency.Decimals)
+	}
+	if amount.LessThan(currency.MinAmount) {
+		return fmt.Errorf("%w: минимальная сумма %s %s", storage.ErrAmountBelowMinimum, currency.MinAmount, currency.Code)
+	}
+	return nil
+}
+
+// Balances возвращает баланс пользователя по валютам: сумму всех его кошельков в валюте
+func Balances(ctx context.Context, wallets WalletProvider, userID uuid.UUID) (map[string]decimal.Decimal, error) {
+	list, err := wallets.Wallets(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка получения баланса: %w", err)
+	}
+	return Totals(list), nil
+}
+
+// Totals суммирует балансы кошельков по валютам
+func Totals(wallets []models.UserWallet) map[string]decimal.Decimal {
+	totals := make(map[string]decimal.Decimal, len(wallets))
+	for _, wallet := range wallets {
+		totals[wallet.Currency] = totals[wallet.Currency].Add(wallet.Balance)
+	}
+	return totals
+}
+
+// WalletID разбирает идентификатор кошелька из запроса. Пустая строка — основной
+// кошелек валюты (uuid.Nil).
+func WalletID(id string) (uuid.UUID, error) {
+	if id == "" {
+		return uuid.Nil, nil
+	}
+	walletID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %s", storage.ErrWalletNotFound, id)
+	}
+	return walletID, nil
+}
+
+// LockWallet блокирует кошелек операции в валюте currency: основной или, если указан
+// walletID, выбранный пользователем. Выбранный кошелек должен быть в той же валюте
+// и не в архиве.
+func LockWallet(ctx context.Context, wallets WalletLocker, userID uuid.UUID, currency string, walletID uuid.UUID) (models.UserWallet, error) {
+	if walletID == uuid.Nil {
+		return wallets.LockWallet(ctx, userID, currency)
+	}
+	wallet, err := wallets.LockWalletByID(ctx, userID, walletID)
+	if err != nil {
+		return models.UserWallet{}, err
+	}
+	if wallet.Currency != currency {
+		return models.UserWallet{}, fmt.Errorf("%w: кошелек %s в валюте %s, а не %s", storage.ErrInvalidWallet, walletID, wallet.Currency, currency)
+	}
+	if wallet.Archived {
+		return models.UserWallet{}, fmt.Errorf("%w: %s", storage.ErrWalletArchived, walletID)
+	}
+	return wallet, nil
+}
@@ -0,0 +1,163 @@
+// Package portfolio — оценка кошельков пользователя в отчетной валюте по текущим
+// или историческим курсам.
+package portfolio
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"main/internal/domain/models"
+	"main/internal/lib/logger/sl"
+	"main/internal/services/authz"
+	"main/internal/services/ledger"
+	"main/internal/storage"
+	"main/internal/tracing"
+	"slices"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ==================PORTFOLIO====================
+
+func New(
+	log *slog.Logger,
+	repo Repository,
+	rates RateProvider,
+	staleAfter time.Duration,
+) *Portfolio {
+	return &Portfolio{
+		log:        log,
+		repo:       repo,
+		rates:      rates,
+		staleAfter: staleAfter,
+	}
+}
+
+// Portfolio оценивает кошельки пользователя. Курс старше staleAfter на момент
+// оценки отмечается как устаревший; ноль отключает проверку.
+type Portfolio struct {
+	log        *slog.Logger
+	repo       Repository
+	rates      RateProvider
+	staleAfter time.Duration
+}
+
+// Repository — данные, по которым строится оценка
+type Repository interface {
+	ledger.CurrencyProvider
+	ledger.WalletProvider
+}
+
+// RateProvider считает курсы валют по котировкам на заданный момент
+type RateProvider interface {
+	ConvertAll(ctx context.Context, codes []string, to string, at time.Time) (map[string]models.Conversion, error)
+}
+
+// Точность доли валюты в портфеле, знаков после запятой
+const sharePrecision = 2
+
+var hundred = decimal.NewFromInt(100)
+
+// GetPortfolio оценивает все кошельки пользователя в валюте currency по средним
+// курсам на момент at. Нулевой или будущий at — текущие курсы. Валюта без курса
+// попадает в ответ со стоимостью 0 и признаком устаревшего курса, но не входит в итог.
+func (p *Portfolio) GetPortfolio(ctx context.Context, token string, currency string, at time.Time) (models.Portfolio, error) {
+
+	const op = "portfolio.GetPortfolio"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	log := p.log.With(
+		slog.String("op", op),
+		slog.String("currency", currency),
+	)
+	log.InfoContext(ctx, "Get portfolio")
+
+	claims, err := authz.User(token)
+	if err != nil {
+		log.WarnContext(ctx, "invalid token", sl.Err(err))
+		return models.Portfolio{}, err
+	}
+
+	reporting, err := p.repo.Currency(ctx, currency)
+	if err != nil {
+		if errors.Is(err, storage.ErrCurrencyNotFound) {
+			err = fmt.Errorf("%w: %s", storage.ErrInvalidCurrency, currency)
+		}
+		log.WarnContext(ctx, "invalid reporting currency", sl.Err(err))
+		return models.Portfolio{}, err
+	}
+
+	now := time.Now()
+	if at.After(now) {
+		at = time.Time{}
+	}
+
+	wallets, err := p.repo.Wallets(ctx, claims.UserID)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get wallets", sl.Err(err))
+		tracing.Fail(span, err)
+		return models.Portfolio{}, fmt.Errorf("Ошибка получения баланса: %w", err)
+	}
+	balances := ledger.Totals(wallets)
+	codes := make([]string, 0, len(balances))
+	for code, balance := range balances {
+		if !balance.IsZero() {
+			codes = append(codes, code)
+		}
+	}
+
+	conversions, err := p.rates.ConvertAll(ctx, codes, reporting.Code, at)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get rates", sl.Err(err))
+		tracing.Fail(span, err)
+		return models.Portfolio{}, err
+	}
+
+	result := models.Portfolio{Currency: reporting.Code, At: at}
+	if at.IsZero() {
+		result.At = now
+	}
+	for _, code := range codes {
+		holding := models.Holding{Currency: code, Balance: balances[code]}
+		conv, ok := conversions[code]
+		switch {
+		case !ok:
+			holding.Stale = true
+		case code == reporting.Code:
+			// Курс валюты к самой себе не зависит от котировок
+			holding.Rate = decimal.NewFromInt(1)
+			holding.Value = holding.Balance.Round(int32(reporting.Decimals))
+			holding.RateUpdatedAt = result.At
+		default:
+			holding.Rate = conv.Mid()
+			holding.Value = holding.Balance.Mul(holding.Rate).Round(int32(reporting.Decimals))
+			holding.RateUpdatedAt = conv.UpdatedAt
+			holding.Stale = p.stale(conv.UpdatedAt, result.At)
+		}
+		result.Total = result.Total.Add(holding.Value)
+		result.Holdings = append(result.Holdings, holding)
+	}
+
+	for i := range result.Holdings {
+		if result.Total.IsPositive() {
+			result.Holdings[i].Share = result.Holdings[i].Value.Mul(hundred).DivRound(result.Total, sharePrecision)
+		}
+	}
+	slices.SortFunc(result.Holdings, func(a, b models.Holding) int {
+		if c := b.Value.Cmp(a.Value); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Currency, b.Currency)
+	})
+
+	log.InfoContext(ctx, "Get portfolio OK", slog.String("total", result.Total.String()))
+	return result, nil
+}
+
+// stale сообщает, что котировка, полученная в updatedAt, устарела к моменту at
+func (p *Portfolio) stale(updatedAt, at time.Time) bool {
+	return p.staleAfter > 0 && at.Sub(updatedAt) > p.staleAfter
+}
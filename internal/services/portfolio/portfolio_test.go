@@ -0,0 +1,164 @@
+package portfolio_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"main/internal/domain/models"
+	"main/internal/lib/jwt"
+	"main/internal/rates"
+	"main/internal/services/portfolio"
+	"main/internal/services/quotes"
+	"main/internal/storage"
+	"main/internal/storage/memory"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var discardLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+// setup создает оценку портфеля на хранилище в памяти. У пользователя 100 USD,
+// 90 EUR в двух кошельках, 0.001 BTC и 500 RUB, для которых курса нет. Курс EUR
+// сменился час назад, котировка BTC получена час назад.
+func setup(t *testing.T) (*portfolio.Portfolio, string, time.Time) {
+	t.Helper()
+	ctx := context.Background()
+	repo := memory.New()
+	for _, c := range []models.Currency{
+		{Code: "USD", Decimals: 2, Enabled: true},
+		{Code: "EUR", Decimals: 2, Enabled: true},
+		{Code: "BTC", Decimals: 8, Enabled: true},
+		{Code: "RUB", Decimals: 2, Enabled: true},
+	} {
+		if _, err := repo.SaveCurrency(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now().Truncate(time.Second)
+	for _, batch := range [][]models.RateQuote{
+		{{Base: "USD", Quote: "EUR", Bid: dec("0.8"), Ask: dec("0.8"), Source: "test", UpdatedAt: now.Add(-2 * time.Hour)}},
+		{{Base: "USD", Quote: "BTC", Bid: dec("0.00002"), Ask: dec("0.00002"), Source: "test", UpdatedAt: now.Add(-time.Hour)}},
+		{{Base: "USD", Quote: "EUR", Bid: dec("0.9"), Ask: dec("0.9"), Source: "test", UpdatedAt: now}},
+	} {
+		if err := repo.SaveQuotes(ctx, batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	user := models.User{ID: uuid.New(), Username: "user", Email: "user@example.com", Role: models.RoleUser}
+	if err := repo.CreateUser(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	travel, err := repo.CreateWallet(ctx, models.UserWallet{UserID: user.ID, Currency: "EUR", Name: "Travel"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.WithinTx(ctx, func(ctx context.Context) error {
+		for currency, balance := range map[string]string{"USD": "100", "EUR": "45", "BTC": "0.001", "RUB": "500"} {
+			wallet, err := repo.LockWallet(ctx, user.ID, currency)
+			if err != nil {
+				return err
+			}
+			wallet.Balance = dec(balance)
+			if err := repo.SaveWallet(ctx, wallet); err != nil {
+				return err
+			}
+		}
+		travel.Balance = dec("45")
+		return repo.SaveWallet(ctx, travel)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.NewToken(user, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := quotes.New(discardLog, repo, rates.NewEngine("USD", 0), rates.NewGuard(0, 0), nil, nil)
+	return portfolio.New(discardLog, repo, q, 10*time.Minute), token, now
+}
+
+type holding struct {
+	currency, value, share string
+	stale                  bool
+}
+
+func check(t *testing.T, got models.Portfolio, total string, want []holding) {
+	t.Helper()
+	if !got.Total.Equal(dec(total)) {
+		t.Fatalf("total = %s, want %s", got.Total, total)
+	}
+	if len(got.Holdings) != len(want) {
+		t.Fatalf("holdings = %+v, want %d", got.Holdings, len(want))
+	}
+	for i, w := range want {
+		h := got.Holdings[i]
+		if h.Currency != w.currency || !h.Value.Equal(dec(w.value)) || !h.Share.Equal(dec(w.share)) || h.Stale != w.stale {
+			t.Fatalf("holding %d = %+v, want %+v", i, h, w)
+		}
+	}
+}
+
+func TestGetPortfolio(t *testing.T) {
+	p, token, _ := setup(t)
+	ctx := context.Background()
+
+	got, err := p.GetPortfolio(ctx, token, "USD", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Равные стоимости упорядочены по коду; BTC по котировке часовой давности,
+	// RUB без курса не входит в итог
+	check(t, got, "250", []holding{
+		{"EUR", "100", "40", false},
+		{"USD", "100", "40", false},
+		{"BTC", "50", "20", true},
+		{"RUB", "0", "0", true},
+	})
+	if got.Currency != "USD" || !got.Holdings[0].Balance.Equal(dec("90")) {
+		t.Fatalf("portfolio = %+v", got)
+	}
+}
+
+func TestGetPortfolioAt(t *testing.T) {
+	p, token, now := setup(t)
+	ctx := context.Background()
+
+	// Полтора часа назад действовал прежний курс EUR, а котировки BTC еще не было
+	at := now.Add(-90 * time.Minute)
+	got, err := p.GetPortfolio(ctx, token, "USD", at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(t, got, "212.5", []holding{
+		{"EUR", "112.5", "52.94", true},
+		{"USD", "100", "47.06", false},
+		{"BTC", "0", "0", true},
+		{"RUB", "0", "0", true},
+	})
+	if !got.At.Equal(at) {
+		t.Fatalf("at = %s, want %s", got.At, at)
+	}
+}
+
+func TestGetPortfolioErrors(t *testing.T) {
+	p, token, _ := setup(t)
+	ctx := context.Background()
+
+	if _, err := p.GetPortfolio(ctx, token, "XXX", time.Time{}); !errors.Is(err, storage.ErrInvalidCurrency) {
+		t.Fatalf("got error %v, want %v", err, storage.ErrInvalidCurrency)
+	}
+	if _, err := p.GetPortfolio(ctx, "bad", "USD", time.Time{}); !errors.Is(err, storage.ErrInvalidToken) {
+		t.Fatalf("got error %v, want %v", err, storage.ErrInvalidToken)
+	}
+}
@@ -0,0 +1,109 @@
+package quarantine
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"main/internal/domain/models"
+	"main/internal/lib/i18n"
+	"main/internal/lib/logger/sl"
+	"main/internal/services/authz"
+	"main/internal/tracing"
+)
+
+// ==================QUARANTINE====================
+
+func New(
+	log *slog.Logger,
+	provider QuoteProvider,
+	resolver QuoteResolver,
+	publisher RatePublisher,
+) *Quarantine {
+	return &Quarantine{
+		log:       log,
+		provider:  provider,
+		resolver:  resolver,
+		publisher: publisher,
+	}
+}
+
+type Quarantine struct {
+	log       *slog.Logger
+	provider  QuoteProvider
+	resolver  QuoteResolver
+	publisher RatePublisher
+}
+
+type QuoteProvider interface {
+	QuarantinedQuotes(ctx context.Context, onlyPending bool) ([]models.QuarantinedQuote, error)
+}
+
+type QuoteResolver interface {
+	ResolveQuarantine(ctx context.Context, id uint64, approve bool, resolvedBy string) (models.QuarantinedQuote, error)
+}
+
+// RatePublisher рассылает подписчикам актуальные курсы
+type RatePublisher interface {
+	Publish(ctx context.Context) error
+}
+
+// ListQuarantinedQuotes возвращает котировки, задержанные проверками курсов
+func (q *Quarantine) ListQuarantinedQuotes(ctx context.Context, token string, all bool) ([]models.QuarantinedQuote, error) {
+	const op = "quarantine.ListQuarantinedQuotes"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	log := q.log.With(slog.String("op", op))
+
+	if _, err := authz.Admin(ctx, token); err != nil {
+		log.WarnContext(ctx, "access denied", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	quotes, err := q.provider.QuarantinedQuotes(ctx, !all)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to list quarantined quotes", sl.Err(err))
+		tracing.Fail(span, err)
+		return nil, err
+	}
+	return quotes, nil
+}
+
+// ResolveQuarantinedQuote подтверждает или отклоняет задержанную котировку.
+// После решения обмен по паре возобновляется, если других задержанных котировок по ней нет.
+func (q *Quarantine) ResolveQuarantinedQuote(ctx context.Context, token string, id uint64, approve bool) (string, models.QuarantinedQuote, error) {
+	const op = "quarantine.ResolveQuarantinedQuote"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	log := q.log.With(
+		slog.String("op", op),
+		slog.Uint64("id", id),
+		slog.Bool("approve", approve),
+	)
+	log.InfoContext(ctx, "Resolve quarantined quote")
+
+	claims, err := authz.Admin(ctx, token)
+	if err != nil {
+		log.WarnContext(ctx, "access denied", sl.Err(err))
+		return "", models.QuarantinedQuote{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	quote, err := q.resolver.ResolveQuarantine(ctx, id, approve, claims.Email)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to resolve quarantined quote", sl.Err(err))
+		tracing.Fail(span, err)
+		return "", models.QuarantinedQuote{}, err
+	}
+	log.InfoContext(ctx, "Quarantined quote resolved",
+		slog.String("pair", quote.Base+"/"+quote.Quote),
+		slog.String("status", quote.Status),
+	)
+
+	if approve {
+		// Решение уже сохранено: ошибка рассылки не отменяет его
+		if err := q.publisher.Publish(ctx); err != nil {
+			log.ErrorContext(ctx, "failed to publish rates", sl.Err(err))
+		}
+		return i18n.Tc(ctx, i18n.MsgQuoteApproved), quote, nil
+	}
+	return i18n.Tc(ctx, i18n.MsgQuoteRejected), quote, nil
+}
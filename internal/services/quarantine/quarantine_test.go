@@ -0,0 +1,178 @@
+package quarantine_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"main/internal/domain/models"
+	"main/internal/lib/jwt"
+	"main/internal/rates"
+	"main/internal/services/quarantine"
+	"main/internal/services/quotes"
+	"main/internal/storage"
+	"main/internal/storage/memory"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var discardLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// source отдает курс USD/EUR, заданный тестом
+type source struct {
+	rate string
+}
+
+func (s *source) Name() string { return "test" }
+
+func (s *source) Quotes(ctx context.Context, pivot string, currencies []models.Currency) ([]models.RateQuote, error) {
+	rate := decimal.RequireFromString(s.rate)
+	return []models.RateQuote{{Base: "USD", Quote: "EUR", Bid: rate, Ask: rate, Source: s.Name(), UpdatedAt: time.Now()}}, nil
+}
+
+type env struct {
+	repo   *memory.Storage
+	src    *source
+	quotes *quotes.Quotes
+	admin  *quarantine.Quarantine
+	token  string
+}
+
+// setup сохраняет курс USD/EUR 0.9 и создает сервисы с порогом отклонения 10%
+func setup(t *testing.T) *env {
+	t.Helper()
+	ctx := context.Background()
+	repo := memory.New()
+	for _, code := range []string{"USD", "EUR"} {
+		if _, err := repo.SaveCurrency(ctx, models.Currency{Code: code, Decimals: 2, Enabled: true, ExchangeEnabled: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src := &source{rate: "0.9"}
+	q := quotes.New(discardLog, repo, rates.NewEngine("USD", 0), rates.NewGuard(0.1, 0), nil, []rates.Source{src})
+	e := &env{repo: repo, src: src, quotes: q, admin: quarantine.New(discardLog, repo, repo, q)}
+	e.update(t, "0.9")
+
+	token, err := jwt.NewToken(models.User{ID: uuid.New(), Email: "admin@example.com", Role: models.RoleAdmin}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.token = token
+	return e
+}
+
+func (e *env) update(t *testing.T, rate string) {
+	t.Helper()
+	e.src.rate = rate
+	if err := e.quotes.UpdateExchangeRates(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// rate возвращает средний курс USD/EUR или ошибку, если обмен по паре остановлен
+func (e *env) rate(t *testing.T) (decimal.Decimal, error) {
+	t.Helper()
+	conv, err := e.quotes.Convert(context.Background(), "USD", "EUR")
+	return conv.Mid(), err
+}
+
+func (e *env) pending(t *testing.T) []models.QuarantinedQuote {
+	t.Helper()
+	pending, err := e.admin.ListQuarantinedQuotes(context.Background(), e.token, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pending
+}
+
+func TestBreakerOpens(t *testing.T) {
+	e := setup(t)
+
+	e.update(t, "9")
+	if _, err := e.rate(t); !errors.Is(err, storage.ErrPairHalted) {
+		t.Fatalf("got error %v, want %v", err, storage.ErrPairHalted)
+	}
+	pending := e.pending(t)
+	if len(pending) != 1 || pending[0].Reason != models.ReasonDeviation {
+		t.Fatalf("pending = %+v, want one deviation", pending)
+	}
+
+	// Пока пара остановлена, новые котировки не применяются и не копятся в карантине
+	e.update(t, "0.91")
+	if got := e.pending(t); len(got) != 1 {
+		t.Fatalf("pending = %+v, want the first quote only", got)
+	}
+	quotes, err := e.repo.Quotes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 1 || !quotes[0].Mid().Equal(decimal.RequireFromString("0.9")) {
+		t.Fatalf("quotes = %+v, want the last accepted 0.9", quotes)
+	}
+}
+
+func TestBreakerReject(t *testing.T) {
+	e := setup(t)
+	ctx := context.Background()
+
+	e.update(t, "9")
+	id := e.pending(t)[0].ID
+	if _, _, err := e.admin.ResolveQuarantinedQuote(ctx, e.token, id, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// После отклонения обмен идет по последнему принятому курсу, а следующая
+	// котировка снова проходит проверку относительно него
+	rate, err := e.rate(t)
+	if err != nil || !rate.Equal(decimal.RequireFromString("0.9")) {
+		t.Fatalf("rate = %s, %v; want 0.9", rate, err)
+	}
+	e.update(t, "9")
+	if _, err := e.rate(t); !errors.Is(err, storage.ErrPairHalted) {
+		t.Fatalf("repeated spike: got error %v, want %v", err, storage.ErrPairHalted)
+	}
+}
+
+func TestBreakerApprove(t *testing.T) {
+	e := setup(t)
+	ctx := context.Background()
+
+	e.update(t, "9")
+	id := e.pending(t)[0].ID
+	_, quote, err := e.admin.ResolveQuarantinedQuote(ctx, e.token, id, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.Status != models.QuarantineApproved || quote.ResolvedBy != "admin@example.com" {
+		t.Fatalf("resolved quote = %+v", quote)
+	}
+
+	// Подтвержденный курс становится базой для следующей проверки
+	rate, err := e.rate(t)
+	if err != nil || !rate.Equal(decimal.RequireFromString("9")) {
+		t.Fatalf("rate = %s, %v; want 9", rate, err)
+	}
+	e.update(t, "9.1")
+	if rate, err := e.rate(t); err != nil || !rate.Equal(decimal.RequireFromString("9.1")) {
+		t.Fatalf("rate = %s, %v; want 9.1", rate, err)
+	}
+
+	// Решение по уже закрытой котировке не принимается
+	if _, _, err := e.admin.ResolveQuarantinedQuote(ctx, e.token, id, false); !errors.Is(err, storage.ErrQuoteNotFound) {
+		t.Fatalf("got error %v, want %v", err, storage.ErrQuoteNotFound)
+	}
+}
+
+func TestQuarantineRequiresAdmin(t *testing.T) {
+	e := setup(t)
+	token, err := jwt.NewToken(models.User{ID: uuid.New(), Email: "user@example.com", Role: models.RoleUser}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.admin.ListQuarantinedQuotes(context.Background(), token, true); !errors.Is(err, storage.ErrPermissionDenied) {
+		t.Fatalf("got error %v, want %v", err, storage.ErrPermissionDenied)
+	}
+}
@@ -0,0 +1,237 @@
+package quotes
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"main/internal/domain/models"
+	"main/internal/metrics"
+	"main/internal/rates"
+	"main/internal/storage"
+	"main/internal/tracing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ==================QUOTES====================
+
+func New(
+	log *slog.Logger,
+	repo Repository,
+	engine *rates.Engine,
+	guard *rates.Guard,
+	publisher BookPublisher,
+	sources []rates.Source,
+) *Quotes {
+	return &Quotes{
+		log:       log,
+		repo:      repo,
+		engine:    engine,
+		guard:     guard,
+		publisher: publisher,
+		sources:   sources,
+	}
+}
+
+// Quotes загружает котировки из источников, проверяет их и считает курсы пар
+type Quotes struct {
+	log       *slog.Logger
+	repo      Repository
+	engine    *rates.Engine
+	guard     *rates.Guard
+	publisher BookPublisher
+	sources   []rates.Source
+}
+
+type Repository interface {
+	storage.Transactor
+	Currencies(ctx context.Context, onlyEnabled bool) ([]models.Currency, error)
+	storage.RateRepository
+	HaltedPairs(ctx context.Context) (map[[2]string]bool, error)
+	QuarantineQuotes(ctx context.Context, quotes []models.QuarantinedQuote) error
+}
+
+// BookPublisher рассылает книгу котировок подписчикам
+type BookPublisher interface {
+	Publish(book rates.Book)
+}
+
+// UpdateExchangeRates загружает котировки из источников относительно опорной валюты
+// и сохраняет прямые пары, которые отдают источники. Котировки проходят проверки Guard:
+// подозрительные попадают в карантин и останавливают обмен по паре, пока
+// администратор не примет решение. Новые котировки остановленных пар не применяются.
+func (q *Quotes) UpdateExchangeRates(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "quotes.UpdateExchangeRates")
+	defer span.End()
+
+	start := time.Now()
+	err := q.updateExchangeRates(ctx)
+	metrics.ObserveRefresh(err, time.Since(start))
+	if err != nil {
+		tracing.Fail(span, err)
+	}
+	return err
+}
+
+func (q *Quotes) updateExchangeRates(ctx context.Context) error {
+	// Список валют берется из справочника
+	currencies, err := q.repo.Currencies(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	fetched, err := rates.Fetch(ctx, q.sources, q.engine.Pivot(), currencies)
+	if err != nil {
+		return fmt.Errorf("Ошибка получения курсов: %w", err)
+	}
+
+	previous, err := q.book(ctx)
+	if err != nil {
+		return err
+	}
+	halted, err := q.repo.HaltedPairs(ctx)
+	if err != nil {
+		return err
+	}
+
+	normalized := make([]models.RateQuote, 0, len(fetched))
+	for _, quote := range fetched {
+		if halted[[2]string{quote.Base, quote.Quote}] {
+			continue
+		}
+		normalized = append(normalized, q.engine.Normalize(quote))
+	}
+	verdict := q.guard.Check(previous, normalized)
+
+	for _, r := range verdict.Rejected {
+		q.log.WarnContext(ctx, "quote rejected",
+			slog.String("pair", r.Base+"/"+r.Quote),
+			slog.String("source", r.Source),
+			slog.String("reason", r.Reason),
+			slog.String("bid", r.Bid.String()),
+			slog.String("ask", r.Ask.String()),
+		)
+	}
+	for _, r := range verdict.Quarantined {
+		q.log.WarnContext(ctx, "quote quarantined",
+			slog.String("pair", r.Base+"/"+r.Quote),
+			slog.String("source", r.Source),
+			slog.String("reason", r.Reason),
+			slog.String("mid", r.RateQuote().Mid().String()),
+			slog.String("previous_mid", r.PreviousMid.String()),
+		)
+	}
+
+	err = q.repo.WithinTx(ctx, func(ctx context.Context) error {
+		if err := q.repo.SaveQuotes(ctx, verdict.Accepted); err != nil {
+			return err
+		}
+		return q.repo.QuarantineQuotes(ctx, verdict.Quarantined)
+	})
+	if err != nil {
+		return err
+	}
+	q.log.DebugContext(ctx, "exchange rates saved",
+		slog.Int("accepted", len(verdict.Accepted)),
+		slog.Int("rejected", len(verdict.Rejected)),
+		slog.Int("quarantined", len(verdict.Quarantined)),
+	)
+
+	return q.Publish(ctx)
+}
+
+// Publish рассылает подписчикам актуальную книгу котировок
+func (q *Quotes) Publish(ctx context.Context) error {
+	if q.publisher == nil {
+		return nil
+	}
+	book, err := q.book(ctx)
+	if err != nil {
+		return err
+	}
+	q.publisher.Publish(book)
+	return nil
+}
+
+// Rates возвращает средний курс валют codes к опорной: единиц валюты за 1 единицу
+// опорной валюты. Валюты без курса пропускаются.
+func (q *Quotes) Rates(ctx context.Context, codes []string) (map[string]decimal.Decimal, error) {
+	book, err := q.book(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]decimal.Decimal, len(codes))
+	for _, code := range codes {
+		conv, err := q.engine.Convert(book, q.engine.Pivot(), code)
+		if err != nil {
+			continue
+		}
+		result[code] = conv.Mid()
+	}
+	return result, nil
+}
+
+// Convert рассчитывает курс from -> to: по прямой котировке или кросс-курсом
+// через опорную валюту. Обмен по пути, где есть остановленная пара, запрещен.
+func (q *Quotes) Convert(ctx context.Context, from, to string) (models.Conversion, error) {
+	book, err := q.book(ctx)
+	if err != nil {
+		return models.Conversion{}, err
+	}
+	conv, err := q.engine.Convert(book, from, to)
+	if err != nil {
+		return models.Conversion{}, fmt.Errorf("%w: %s/%s", storage.ErrRateNotFound, from, to)
+	}
+
+	halted, err := q.repo.HaltedPairs(ctx)
+	if err != nil {
+		return models.Conversion{}, err
+	}
+	if pairs := rates.Halted(conv.Path, halted); len(pairs) > 0 {
+		return models.Conversion{}, fmt.Errorf("%w: %s/%s", storage.ErrPairHalted, pairs[0][0], pairs[0][1])
+	}
+	return conv, nil
+}
+
+// ConvertAll рассчитывает курсы валют codes к to по котировкам на момент at; нулевой
+// at — текущие котировки. Остановленные пары не учитываются: курс нужен для оценки,
+// а не для обмена. Валюты без курса пропускаются.
+func (q *Quotes) ConvertAll(ctx context.Context, codes []string, to string, at time.Time) (map[string]models.Conversion, error) {
+	book, err := q.bookAt(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]models.Conversion, len(codes))
+	for _, code := range codes {
+		conv, err := q.engine.Convert(book, code, to)
+		if err != nil {
+			continue
+		}
+		result[code] = conv
+	}
+	return result, nil
+}
+
+// bookAt загружает котировки, действовавшие на момент at, или текущие при нулевом at
+func (q *Quotes) bookAt(ctx context.Context, at time.Time) (rates.Book, error) {
+	if at.IsZero() {
+		return q.book(ctx)
+	}
+	quotes, err := q.repo.QuotesAt(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+	return rates.NewBook(quotes), nil
+}
+
+// book загружает все сохраненные котировки
+func (q *Quotes) book(ctx context.Context) (rates.Book, error) {
+	quotes, err := q.repo.Quotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rates.NewBook(quotes), nil
+}
@@ -5,33 +5,45 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"main/internal/domain/models"
+	"main/internal/events"
+	"main/internal/lib/i18n"
+	"main/internal/lib/logger/sl"
+	"main/internal/metrics"
+	"main/internal/outbox"
+	"main/internal/services/authz"
+	"main/internal/services/ledger"
 	"main/internal/storage"
+	"main/internal/tracing"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 // ==================WALLET====================
 
 func NewWallet(
 	log *slog.Logger,
-	getBalanc GetBalance,
-	deposit Deposit,
-	withdraw Withdraw,
+	repo Repository,
+	watcher BalanceWatcher,
+	publisher BalancePublisher,
 	tokenTTL time.Duration,
 ) *Wallet {
 	return &Wallet{
 		log:       log,
-		getBalanc: getBalanc,
-		deposit:   deposit,
-		withdraw:  withdraw,
+		repo:      repo,
+		watcher:   watcher,
+		publisher: publisher,
 		tokenTTL:  tokenTTL,
 	}
 }
 
 type Wallet struct {
 	log       *slog.Logger
-	getBalanc GetBalance
-	deposit   Deposit
-	withdraw  Withdraw
+	repo      Repository
+	watcher   BalanceWatcher
+	publisher BalancePublisher
 	tokenTTL  time.Duration
 }
 
@@ -39,100 +51,226 @@ var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 )
 
-type GetBalance interface {
-	GetBalance(
-		ctx context.Context,
-		token string,
-	) (map[string]float32, error)
+// Repository — данные, с которыми работают операции кошелька
+type Repository interface {
+	storage.Transactor
+	ledger.CurrencyProvider
+	storage.WalletRepository
+	AppendEvents(ctx context.Context, events []models.OutboxEvent) error
 }
 
-type Deposit interface {
-	Deposit(
-		ctx context.Context,
-		token string,
-		amount float32,
-		currency string,
-	) (string, map[string]float32, error)
+// BalanceWatcher выдает подписку на изменения кошельков пользователя
+type BalanceWatcher interface {
+	Subscribe(userID uuid.UUID) *events.Subscription
 }
 
-type Withdraw interface {
-	Withdraw(
-		ctx context.Context,
-		token string,
-		amount float32,
-		currency string,
-	) (string, map[string]float32, error)
+// BalancePublisher сообщает подписчикам об изменении кошельков
+type BalancePublisher interface {
+	Publish(event models.BalanceEvent)
 }
 
-func (w *Wallet) GetBalance(ctx context.Context, token string) (map[string]float32, error) {
+// GetBalance возвращает баланс пользователя по валютам и список его кошельков, включая архивные
+func (w *Wallet) GetBalance(ctx context.Context, token string) (map[string]decimal.Decimal, []models.UserWallet, error) {
 
 	const op = "walletUser.GetBalance"
-	log := w.log.With(
-		slog.String("op", op),
-		slog.String("token", token),
-	)
-	log.Info("Get balance")
-	if w.getBalanc == nil {
-		return nil, errors.New("GetBalance is not initialized")
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	log := w.log.With(slog.String("op", op))
+	log.InfoContext(ctx, "Get balance")
+
+	claims, err := authz.User(token)
+	if err != nil {
+		log.WarnContext(ctx, "invalid token", sl.Err(err))
+		return nil, nil, err
 	}
 
-	balance, err := w.getBalanc.GetBalance(ctx, token)
+	wallets, err := w.repo.Wallets(ctx, claims.UserID)
 	if err != nil {
-		if errors.Is(err, storage.ErrUserExists) {
-			log.Warn("User already exists", err)
-			return nil, fmt.Errorf("%w", ErrInvalidCredentials)
-		}
-		log.Error("failed to save user", err)
-		return nil, err
+		log.ErrorContext(ctx, "failed to get balance", sl.Err(err))
+		tracing.Fail(span, err)
+		return nil, nil, fmt.Errorf("Ошибка получения баланса: %w", err)
 	}
-	log.Info("Кошелек найден")
-	return balance, nil
+	log.InfoContext(ctx, "Кошелек найден")
+	return ledger.Totals(wallets), wallets, nil
 }
 
-func (w *Wallet) Deposit(ctx context.Context, token string, amount float32, currency string) (string, map[string]float32, error) {
+// Deposit пополняет кошелек пользователя. Пустой walletID — основной кошелек валюты.
+func (w *Wallet) Deposit(ctx context.Context, token string, amount decimal.Decimal, currency string, walletID string) (string, map[string]decimal.Decimal, error) {
 
 	const op = "walletUser.Deposit"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 	log := w.log.With(
 		slog.String("op", op),
-		slog.String("token", token),
-		slog.String("amount", fmt.Sprint(amount)),
+		slog.String("amount", amount.String()),
 		slog.String("currency", currency),
+		slog.String("wallet_id", walletID),
 	)
-	log.Info("Deposit")
-	if w.deposit == nil {
-		return "", nil, errors.New("Deposit is not initialized")
+	log.InfoContext(ctx, "Deposit")
+
+	claims, err := authz.User(token)
+	if err != nil {
+		log.WarnContext(ctx, "invalid token", sl.Err(err))
+		return "", nil, err
 	}
 
-	message, balance, err := w.deposit.Deposit(ctx, token, amount, currency)
+	balance, err := w.change(ctx, claims.UserID, models.OperationDeposit, currency, walletID, amount)
 	if err != nil {
 
-		log.Error("failed to deposit wallet", err)
+		log.ErrorContext(ctx, "failed to deposit wallet", sl.Err(err))
+		tracing.Fail(span, err)
 		return "", nil, err
 	}
-	log.Info("Deposit OK")
-	return message, balance, nil
+	log.InfoContext(ctx, "Deposit OK")
+	metrics.Deposit(currency, amount)
+	return i18n.Tc(ctx, i18n.MsgDeposited), balance, nil
 }
 
-func (w *Wallet) Withdraw(ctx context.Context, token string, amount float32, currency string) (string, map[string]float32, error) {
+// Withdraw списывает средства с кошелька пользователя. Пустой walletID — основной кошелек валюты.
+func (w *Wallet) Withdraw(ctx context.Context, token string, amount decimal.Decimal, currency string, walletID string) (string, map[string]decimal.Decimal, error) {
 
 	const op = "walletUser.Withdraw"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 	log := w.log.With(
 		slog.String("op", op),
-		slog.String("token", token),
-		slog.String("amount", fmt.Sprint(amount)),
+		slog.String("amount", amount.String()),
 		slog.String("currency", currency),
+		slog.String("wallet_id", walletID),
 	)
-	log.Info("Withdraw")
-	if w.withdraw == nil {
-		return "", nil, errors.New("Withdraw is not initialized")
+	log.InfoContext(ctx, "Withdraw")
+
+	claims, err := authz.User(token)
+	if err != nil {
+		log.WarnContext(ctx, "invalid token", sl.Err(err))
+		return "", nil, err
 	}
 
-	message, balance, err := w.withdraw.Withdraw(ctx, token, amount, currency)
+	balance, err := w.change(ctx, claims.UserID, models.OperationWithdraw, currency, walletID, amount)
 	if err != nil {
 
-		log.Error("failed to deposit wallet", err)
+		log.ErrorContext(ctx, "failed to withdraw wallet", sl.Err(err))
+		tracing.Fail(span, err)
 		return "", nil, err
 	}
-	log.Info("Deposit OK")
-	return message, balance, nil
+	log.InfoContext(ctx, "Withdraw OK")
+	metrics.Withdraw(currency, amount)
+	return i18n.Tc(ctx, i18n.MsgWithdrawn), balance, nil
+}
+
+// change пополняет кошелек или списывает с него amount. Новый баланс и событие
+// outbox фиксируются в одной транзакции; кошелек заблокирован до ее завершения,
+// поэтому параллельные операции не теряют изменения.
+func (w *Wallet) change(ctx context.Context, userID uuid.UUID, operation string, currency string, walletID string, amount decimal.Decimal) (map[string]decimal.Decimal, error) {
+	id, err := ledger.WalletID(walletID)
+	if err != nil {
+		return nil, err
+	}
+	asset, err := ledger.CheckCurrency(ctx, w.repo, currency, operation)
+	if err != nil {
+		return nil, err
+	}
+	if err := ledger.CheckAmount(asset, amount); err != nil {
+		return nil, err
+	}
+
+	var wallet models.UserWallet
+	var balances map[string]decimal.Decimal
+	err = w.repo.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		wallet, err = ledger.LockWallet(ctx, w.repo, userID, currency, id)
+		if err != nil {
+			return err
+		}
+
+		var event models.OutboxEvent
+		if operation == models.OperationWithdraw {
+			if wallet.Balance.LessThan(amount) {
+				return fmt.Errorf("%w: текущий баланс %s %s, запрашиваемая сумма %s %s", storage.ErrInsufficientFunds, wallet.Balance, currency, amount, currency)
+			}
+			wallet.Balance = wallet.Balance.Sub(amount)
+			event, err = outbox.NewEvent(userID, models.EventWalletDebited, models.WalletDebited{
+				UserID:   userID,
+				WalletID: wallet.ID,
+				Currency: currency,
+				Amount:   amount,
+				Balance:  wallet.Balance,
+			})
+		} else {
+			wallet.Balance = wallet.Balance.Add(amount)
+			event, err = outbox.NewEvent(userID, models.EventWalletCredited, models.WalletCredited{
+				UserID:   userID,
+				WalletID: wallet.ID,
+				Currency: currency,
+				Amount:   amount,
+				Balance:  wallet.Balance,
+			})
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := w.repo.SaveWallet(ctx, wallet); err != nil {
+			return err
+		}
+		if err := w.repo.AppendEvents(ctx, []models.OutboxEvent{event}); err != nil {
+			return err
+		}
+		balances, err = ledger.Balances(ctx, w.repo, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	kind, delta := models.BalanceDeposit, amount
+	if operation == models.OperationWithdraw {
+		kind, delta = models.BalanceWithdraw, amount.Neg()
+	}
+	w.publish(userID, kind, balances, models.WalletChange{WalletID: wallet.ID, Currency: currency, Amount: delta, Balance: wallet.Balance})
+	return balances, nil
+}
+
+// publish сообщает подписчикам об изменении кошельков. Вызывается только после фиксации транзакции.
+func (w *Wallet) publish(userID uuid.UUID, kind string, balances map[string]decimal.Decimal, changes ...models.WalletChange) {
+	if w.publisher == nil {
+		return
+	}
+	w.publisher.Publish(models.BalanceEvent{
+		UserID:   userID,
+		Kind:     kind,
+		Changes:  changes,
+		Balances: balances,
+		At:       time.Now(),
+	})
+}
+
+// WatchBalance подписывает пользователя на изменения его кошельков и возвращает
+// текущий баланс. Подписка создается до чтения баланса, чтобы не пропустить операции между ними.
+func (w *Wallet) WatchBalance(ctx context.Context, token string) (*events.Subscription, map[string]decimal.Decimal, error) {
+
+	const op = "walletUser.WatchBalance"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	log := w.log.With(slog.String("op", op))
+	log.InfoContext(ctx, "Watch balance")
+	if w.watcher == nil {
+		return nil, nil, errors.New("BalanceWatcher is not initialized")
+	}
+
+	claims, err := authz.User(token)
+	if err != nil {
+		log.WarnContext(ctx, "invalid token", sl.Err(err))
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sub := w.watcher.Subscribe(claims.UserID)
+	balance, err := ledger.Balances(ctx, w.repo, claims.UserID)
+	if err != nil {
+		sub.Close()
+		log.ErrorContext(ctx, "failed to get balance", sl.Err(err))
+		tracing.Fail(span, err)
+		return nil, nil, err
+	}
+	return sub, balance, nil
 }
@@ -0,0 +1,262 @@
+package walletuser_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"main/internal/domain/models"
+	"main/internal/events"
+	"main/internal/lib/jwt"
+	walletuser "main/internal/services/walletUser"
+	"main/internal/storage"
+	"main/internal/storage/memory"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var discardLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// setup создает сервис на хранилище в памяти с валютами USD и BTC
+// и пользователем с пустыми кошельками
+func setup(t *testing.T) (*walletuser.Wallet, *memory.Storage, *events.Bus, models.User, string) {
+	t.Helper()
+	ctx := context.Background()
+	repo := memory.New()
+	for _, c := range []models.Currency{
+		{Code: "USD", Decimals: 2, Enabled: true, DepositEnabled: true, WithdrawEnabled: true, ExchangeEnabled: true, MinAmount: decimal.RequireFromString("0.01")},
+		{Code: "BTC", Decimals: 8, Enabled: true, DepositEnabled: true, ExchangeEnabled: true, MinAmount: decimal.RequireFromString("0.0001")},
+	} {
+		if _, err := repo.SaveCurrency(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	user := models.User{ID: uuid.New(), Username: "user", Email: "user@example.com", Role: models.RoleUser}
+	if err := repo.CreateUser(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AddWalletUser(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.NewToken(user, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bus := events.NewBus(0)
+	return walletuser.NewWallet(discardLog, repo, bus, bus, time.Hour), repo, bus, user, token
+}
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestDepositWithdraw(t *testing.T) {
+	w, repo, bus, user, token := setup(t)
+	ctx := context.Background()
+	sub := bus.Subscribe(user.ID)
+	defer sub.Close()
+
+	_, balance, err := w.Deposit(ctx, token, dec("100"), "USD", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !balance["USD"].Equal(dec("100")) {
+		t.Fatalf("balance after deposit = %v", balance)
+	}
+
+	_, balance, err = w.Withdraw(ctx, token, dec("30.5"), "USD", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !balance["USD"].Equal(dec("69.5")) || !balance["BTC"].IsZero() {
+		t.Fatalf("balance after withdraw = %v", balance)
+	}
+
+	// Операция публикуется после фиксации и попадает в outbox
+	for _, want := range []string{models.BalanceDeposit, models.BalanceWithdraw} {
+		select {
+		case event := <-sub.Events():
+			if event.Kind != want {
+				t.Fatalf("event kind = %q, want %q", event.Kind, want)
+			}
+		default:
+			t.Fatalf("no %s balance event", want)
+		}
+	}
+	pending, err := repo.PendingEvents(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || pending[0].Type != models.EventWalletCredited || pending[1].Type != models.EventWalletDebited {
+		t.Fatalf("outbox = %+v, want credited and debited events", pending)
+	}
+}
+
+func TestWalletRules(t *testing.T) {
+	w, repo, bus, user, token := setup(t)
+	ctx := context.Background()
+	if _, _, err := w.Deposit(ctx, token, dec("10"), "USD", ""); err != nil {
+		t.Fatal(err)
+	}
+	sub := bus.Subscribe(user.ID)
+	defer sub.Close()
+
+	tests := []struct {
+		name     string
+		withdraw bool
+		amount   string
+		currency string
+		want     error
+	}{
+		{"insufficient funds", true, "10.01", "USD", storage.ErrInsufficientFunds},
+		{"unknown currency", false, "1", "XXX", storage.ErrInvalidCurrency},
+		{"operation disabled", true, "0.001", "BTC", storage.ErrCurrencyNotAllowed},
+		{"zero amount", false, "0", "USD", storage.ErrInvalidAmount},
+		{"negative amount", true, "-1", "USD", storage.ErrInvalidAmount},
+		{"precision", false, "1.001", "USD", storage.ErrAmountPrecision},
+		{"below minimum", false, "0.00001", "BTC", storage.ErrAmountBelowMinimum},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := w.Deposit
+			if tt.withdraw {
+				op = w.Withdraw
+			}
+			if _, _, err := op(ctx, token, dec(tt.amount), tt.currency, ""); !errors.Is(err, tt.want) {
+				t.Fatalf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// Отклоненные операции не меняют баланс и не оставляют событий
+	balance, _, err := w.GetBalance(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !balance["USD"].Equal(dec("10")) {
+		t.Fatalf("balance = %v, want 10 USD", balance)
+	}
+	pending, err := repo.PendingEvents(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 {
+		t.Fatalf("got %d outbox events, want only the deposit", len(pending))
+	}
+	select {
+	case event := <-sub.Events():
+		t.Fatalf("unexpected balance event %+v", event)
+	default:
+	}
+}
+
+func TestInvalidToken(t *testing.T) {
+	w, _, _, _, _ := setup(t)
+	ctx := context.Background()
+
+	if _, _, err := w.GetBalance(ctx, "bad"); !errors.Is(err, storage.ErrInvalidToken) {
+		t.Fatalf("GetBalance: got error %v, want %v", err, storage.ErrInvalidToken)
+	}
+	if _, _, err := w.Deposit(ctx, "bad", dec("1"), "USD", ""); !errors.Is(err, storage.ErrInvalidToken) {
+		t.Fatalf("Deposit: got error %v, want %v", err, storage.ErrInvalidToken)
+	}
+}
+
+func TestSubWallets(t *testing.T) {
+	w, repo, bus, user, token := setup(t)
+	ctx := context.Background()
+
+	_, savings, err := w.CreateWallet(ctx, token, "USD", "  Savings ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if savings.Name != "Savings" || savings.Currency != "USD" {
+		t.Fatalf("created wallet %+v", savings)
+	}
+	if _, _, err := w.CreateWallet(ctx, token, "USD", "savings"); !errors.Is(err, storage.ErrWalletExists) {
+		t.Fatalf("duplicate name: got error %v, want %v", err, storage.ErrWalletExists)
+	}
+	id := savings.ID.String()
+
+	// Пополнение и перевод с дополнительного кошелька; суммарный баланс валюты не меняется
+	if _, _, err := w.Deposit(ctx, token, dec("50"), "USD", id); err != nil {
+		t.Fatal(err)
+	}
+	sub := bus.Subscribe(user.ID)
+	defer sub.Close()
+	_, result, err := w.Transfer(ctx, token, "USD", id, "", dec("20"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.From.Balance.Equal(dec("30")) || !result.To.Balance.Equal(dec("20")) || !result.To.Main() {
+		t.Fatalf("transfer result = %+v", result)
+	}
+	select {
+	case event := <-sub.Events():
+		if event.Kind != models.BalanceTransfer || len(event.Changes) != 2 || !event.Balances["USD"].Equal(dec("50")) {
+			t.Fatalf("transfer event = %+v", event)
+		}
+	default:
+		t.Fatal("no transfer balance event")
+	}
+
+	balance, wallets, err := w.GetBalance(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !balance["USD"].Equal(dec("50")) || len(wallets) != 3 {
+		t.Fatalf("balance = %v, wallets = %+v", balance, wallets)
+	}
+
+	tests := []struct {
+		name string
+		op   func() error
+		want error
+	}{
+		{"archive non-empty", func() error { _, _, err := w.ArchiveWallet(ctx, token, id); return err }, storage.ErrWalletNotEmpty},
+		{"archive main wallet", func() error { _, _, err := w.ArchiveWallet(ctx, token, result.To.ID.String()); return err }, storage.ErrInvalidWallet},
+		{"transfer to itself", func() error { _, _, err := w.Transfer(ctx, token, "USD", id, id, dec("1")); return err }, storage.ErrInvalidWallet},
+		{"currency mismatch", func() error { _, _, err := w.Deposit(ctx, token, dec("1"), "BTC", id); return err }, storage.ErrInvalidWallet},
+		{"unknown wallet", func() error { _, _, err := w.Withdraw(ctx, token, dec("1"), "USD", uuid.NewString()); return err }, storage.ErrWalletNotFound},
+		{"malformed wallet id", func() error { _, _, err := w.Deposit(ctx, token, dec("1"), "USD", "savings"); return err }, storage.ErrWalletNotFound},
+		{"empty name", func() error { _, _, err := w.RenameWallet(ctx, token, id, " "); return err }, storage.ErrInvalidWallet},
+		{"insufficient funds", func() error { _, _, err := w.Transfer(ctx, token, "USD", id, "", dec("30.01")); return err }, storage.ErrInsufficientFunds},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op(); !errors.Is(err, tt.want) {
+				t.Fatalf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, _, err := w.Transfer(ctx, token, "USD", id, "", dec("30")); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := w.RenameWallet(ctx, token, id, "Old savings"); err != nil {
+		t.Fatal(err)
+	}
+	_, archived, err := w.ArchiveWallet(ctx, token, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !archived.Archived || archived.Name != "Old savings" {
+		t.Fatalf("archived wallet = %+v", archived)
+	}
+	if _, _, err := w.Deposit(ctx, token, dec("1"), "USD", id); !errors.Is(err, storage.ErrWalletArchived) {
+		t.Fatalf("deposit to archived wallet: got error %v, want %v", err, storage.ErrWalletArchived)
+	}
+
+	pending, err := repo.PendingEvents(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 3 || pending[1].Type != models.EventWalletTransferred {
+		t.Fatalf("outbox = %+v, want a deposit and two transfers", pending)
+	}
+}
@@ -0,0 +1,292 @@
+package walletuser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"main/internal/domain/models"
+	"main/internal/lib/i18n"
+	"main/internal/lib/logger/sl"
+	"main/internal/outbox"
+	"main/internal/services/authz"
+	"main/internal/services/ledger"
+	"main/internal/storage"
+	"main/internal/tracing"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// maxWalletName — наибольшая длина названия кошелька в символах
+const maxWalletName = 64
+
+// CreateWallet создает пользователю дополнительный кошелек в валюте с названием name
+func (w *Wallet) CreateWallet(ctx context.Context, token string, currency string, name string) (string, models.UserWallet, error) {
+
+	const op = "walletUser.CreateWallet"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	log := w.log.With(
+		slog.String("op", op),
+		slog.String("currency", currency),
+	)
+	log.InfoContext(ctx, "Create wallet")
+
+	claims, err := authz.User(token)
+	if err != nil {
+		log.WarnContext(ctx, "invalid token", sl.Err(err))
+		return "", models.UserWallet{}, err
+	}
+
+	name, err = walletName(name)
+	if err != nil {
+		return "", models.UserWallet{}, err
+	}
+	if _, err := ledger.CheckCurrency(ctx, w.repo, currency, models.OperationTransfer); err != nil {
+		log.WarnContext(ctx, "currency not available", sl.Err(err))
+		return "", models.UserWallet{}, err
+	}
+
+	wallet, err := w.repo.CreateWallet(ctx, models.UserWallet{UserID: claims.UserID, Currency: currency, Name: name})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to create wallet", sl.Err(err))
+		tracing.Fail(span, err)
+		return "", models.UserWallet{}, err
+	}
+	log.InfoContext(ctx, "Wallet created", slog.String("wallet_id", wallet.ID.String()))
+	return i18n.Tc(ctx, i18n.MsgWalletCreated), wallet, nil
+}
+
+// RenameWallet меняет название дополнительного кошелька
+func (w *Wallet) RenameWallet(ctx context.Context, token string, walletID string, name string) (string, models.UserWallet, error) {
+
+	const op = "walletUser.RenameWallet"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	log := w.log.With(
+		slog.String("op", op),
+		slog.String("wallet_id", walletID),
+	)
+	log.InfoContext(ctx, "Rename wallet")
+
+	name, err := walletName(name)
+	if err != nil {
+		return "", models.UserWallet{}, err
+	}
+	wallet, err := w.update(ctx, token, walletID, func(wallet *models.UserWallet) error {
+		if wallet.Archived {
+			return fmt.Errorf("%w: %s", storage.ErrWalletArchived, wallet.ID)
+		}
+		wallet.Name = name
+		return nil
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to rename wallet", sl.Err(err))
+		tracing.Fail(span, err)
+		return "", models.UserWallet{}, err
+	}
+	log.InfoContext(ctx, "Wallet renamed")
+	return i18n.Tc(ctx, i18n.MsgWalletRenamed), wallet, nil
+}
+
+// ArchiveWallet переносит пустой дополнительный кошелек в архив. Операции по архивному
+// кошельку запрещены, а его название можно занять новым кошельком.
+func (w *Wallet) ArchiveWallet(ctx context.Context, token string, walletID string) (string, models.UserWallet, error) {
+
+	const op = "walletUser.ArchiveWallet"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	log := w.log.With(
+		slog.String("op", op),
+		slog.String("wallet_id", walletID),
+	)
+	log.InfoContext(ctx, "Archive wallet")
+
+	wallet, err := w.update(ctx, token, walletID, func(wallet *models.UserWallet) error {
+		if !wallet.Balance.IsZero() {
+			return fmt.Errorf("%w: баланс %s %s", storage.ErrWalletNotEmpty, wallet.Balance, wallet.Currency)
+		}
+		wallet.Archived = true
+		return nil
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to archive wallet", sl.Err(err))
+		tracing.Fail(span, err)
+		return "", models.UserWallet{}, err
+	}
+	log.InfoContext(ctx, "Wallet archived")
+	return i18n.Tc(ctx, i18n.MsgWalletArchived), wallet, nil
+}
+
+// update блокирует дополнительный кошелек пользователя, применяет к нему fn и сохраняет.
+// Основной кошелек валюты переименовать или архивировать нельзя.
+func (w *Wallet) update(ctx context.Context, token string, walletID string, fn func(wallet *models.UserWallet) error) (models.UserWallet, error) {
+	claims, err := authz.User(token)
+	if err != nil {
+		return models.UserWallet{}, err
+	}
+	id, err := ledger.WalletID(walletID)
+	if err != nil {
+		return models.UserWallet{}, err
+	}
+	if id == uuid.Nil {
+		return models.UserWallet{}, fmt.Errorf("%w: не указан кошелек", storage.ErrInvalidWallet)
+	}
+
+	var wallet models.UserWallet
+	err = w.repo.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		wallet, err = w.repo.LockWalletByID(ctx, claims.UserID, id)
+		if err != nil {
+			return err
+		}
+		if wallet.Main() {
+			return fmt.Errorf("%w: основной кошелек %s нельзя изменить", storage.ErrInvalidWallet, wallet.Currency)
+		}
+		if err := fn(&wallet); err != nil {
+			return err
+		}
+		return w.repo.SaveWallet(ctx, wallet)
+	})
+	return wallet, err
+}
+
+// Transfer переводит amount между двумя кошельками пользователя в валюте currency.
+// Пустой идентификатор кошелька — основной кошелек валюты.
+func (w *Wallet) Transfer(ctx context.Context, token string, currency string, fromWalletID string, toWalletID string, amount decimal.Decimal) (string, models.TransferResult, error) {
+
+	const op = "walletUser.Transfer"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	log := w.log.With(
+		slog.String("op", op),
+		slog.String("currency", currency),
+		slog.String("from_wallet_id", fromWalletID),
+		slog.String("to_wallet_id", toWalletID),
+		slog.String("amount", amount.String()),
+	)
+	log.InfoContext(ctx, "Transfer")
+
+	claims, err := authz.User(token)
+	if err != nil {
+		log.WarnContext(ctx, "invalid token", sl.Err(err))
+		return "", models.TransferResult{}, err
+	}
+
+	result, balances, err := w.transfer(ctx, claims.UserID, currency, fromWalletID, toWalletID, amount)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to transfer", sl.Err(err))
+		tracing.Fail(span, err)
+		return "", models.TransferResult{}, err
+	}
+	log.InfoContext(ctx, "Transfer OK")
+	w.publish(claims.UserID, models.BalanceTransfer, balances,
+		models.WalletChange{WalletID: result.From.ID, Currency: currency, Amount: amount.Neg(), Balance: result.From.Balance},
+		models.WalletChange{WalletID: result.To.ID, Currency: currency, Amount: amount, Balance: result.To.Balance},
+	)
+	return i18n.Tc(ctx, i18n.MsgTransferred), result, nil
+}
+
+// transfer списывает amount с одного кошелька и зачисляет на другой в одной транзакции
+// вместе с событием outbox
+func (w *Wallet) transfer(ctx context.Context, userID uuid.UUID, currency string, fromWalletID, toWalletID string, amount decimal.Decimal) (models.TransferResult, map[string]decimal.Decimal, error) {
+	fromID, err := ledger.WalletID(fromWalletID)
+	if err != nil {
+		return models.TransferResult{}, nil, err
+	}
+	toID, err := ledger.WalletID(toWalletID)
+	if err != nil {
+		return models.TransferResult{}, nil, err
+	}
+	if fromID == toID {
+		return models.TransferResult{}, nil, fmt.Errorf("%w: перевод на тот же кошелек", storage.ErrInvalidWallet)
+	}
+	asset, err := ledger.CheckCurrency(ctx, w.repo, currency, models.OperationTransfer)
+	if err != nil {
+		return models.TransferResult{}, nil, err
+	}
+	if err := ledger.CheckAmount(asset, amount); err != nil {
+		return models.TransferResult{}, nil, err
+	}
+
+	var result models.TransferResult
+	var balances map[string]decimal.Decimal
+	err = w.repo.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		result.From, result.To, err = w.lockPair(ctx, userID, currency, fromID, toID)
+		if err != nil {
+			return err
+		}
+		// Основной кошелек, переданный явным идентификатором, совпадает с пустым
+		if result.From.ID == result.To.ID {
+			return fmt.Errorf("%w: перевод на тот же кошелек", storage.ErrInvalidWallet)
+		}
+		if result.From.Balance.LessThan(amount) {
+			return fmt.Errorf("%w: текущий баланс %s %s, запрашиваемая сумма %s %s", storage.ErrInsufficientFunds, result.From.Balance, currency, amount, currency)
+		}
+		result.From.Balance = result.From.Balance.Sub(amount)
+		result.To.Balance = result.To.Balance.Add(amount)
+
+		event, err := outbox.NewEvent(userID, models.EventWalletTransferred, models.WalletTransferred{
+			UserID:       userID,
+			Currency:     currency,
+			Amount:       amount,
+			FromWalletID: result.From.ID,
+			ToWalletID:   result.To.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if err := w.repo.SaveWallet(ctx, result.From); err != nil {
+			return err
+		}
+		if err := w.repo.SaveWallet(ctx, result.To); err != nil {
+			return err
+		}
+		if err := w.repo.AppendEvents(ctx, []models.OutboxEvent{event}); err != nil {
+			return err
+		}
+		balances, err = ledger.Balances(ctx, w.repo, userID)
+		return err
+	})
+	if err != nil {
+		return models.TransferResult{}, nil, err
+	}
+	return result, balances, nil
+}
+
+// lockPair блокирует кошельки перевода в порядке идентификаторов (основной кошелек,
+// uuid.Nil, первым), чтобы встречные переводы не ждали друг друга по кругу. Если
+// порядок все же нарушится, база прервет взаимоблокировку и транзакция повторится.
+func (w *Wallet) lockPair(ctx context.Context, userID uuid.UUID, currency string, fromID, toID uuid.UUID) (models.UserWallet, models.UserWallet, error) {
+	first, second := fromID, toID
+	swapped := bytes.Compare(second[:], first[:]) < 0
+	if swapped {
+		first, second = second, first
+	}
+
+	a, err := ledger.LockWallet(ctx, w.repo, userID, currency, first)
+	if err != nil {
+		return models.UserWallet{}, models.UserWallet{}, err
+	}
+	b, err := ledger.LockWallet(ctx, w.repo, userID, currency, second)
+	if err != nil {
+		return models.UserWallet{}, models.UserWallet{}, err
+	}
+	if swapped {
+		a, b = b, a
+	}
+	return a, b, nil
+}
+
+// walletName проверяет название кошелька и убирает пробелы по краям
+func walletName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxWalletName {
+		return "", fmt.Errorf("%w: название должно содержать от 1 до %d символов", storage.ErrInvalidWallet, maxWalletName)
+	}
+	return name, nil
+}
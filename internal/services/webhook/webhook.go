@@ -0,0 +1,245 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"main/internal/domain/models"
+	"main/internal/lib/i18n"
+	"main/internal/lib/logger/sl"
+	"main/internal/services/authz"
+	"main/internal/storage"
+	"main/internal/tracing"
+	"main/internal/webhooks"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ==================WEBHOOK====================
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+func New(
+	log *slog.Logger,
+	hooks WebhookStore,
+	deliveries DeliveryStore,
+) *Webhooks {
+	return &Webhooks{
+		log:        log,
+		hooks:      hooks,
+		deliveries: deliveries,
+	}
+}
+
+type Webhooks struct {
+	log        *slog.Logger
+	hooks      WebhookStore
+	deliveries DeliveryStore
+}
+
+type WebhookStore interface {
+	SaveWebhook(ctx context.Context, hook models.Webhook) (models.Webhook, error)
+	Webhooks(ctx context.Context, userID uuid.UUID) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, userID uuid.UUID, id uuid.UUID) (models.Webhook, error)
+}
+
+type DeliveryStore interface {
+	WebhookDeliveries(ctx context.Context, userID uuid.UUID, webhookID uuid.UUID, status string, limit int) ([]models.WebhookDelivery, error)
+	RetryDelivery(ctx context.Context, userID uuid.UUID, id uint64) (models.WebhookDelivery, error)
+}
+
+// CreateWebhook подписывает URL на события счета владельца токена.
+// Если ключ подписи не передан, он генерируется и возвращается один раз.
+func (w *Webhooks) CreateWebhook(ctx context.Context, token string, rawURL string, eventTypes []string, secret string) (string, models.Webhook, error) {
+	const op = "webhook.CreateWebhook"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	log := w.log.With(slog.String("op", op))
+	log.InfoContext(ctx, "Create webhook")
+
+	claims, err := authz.User(token)
+	if err != nil {
+		log.WarnContext(ctx, "invalid token", sl.Err(err))
+		return "", models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := validateURL(ctx, rawURL); err != nil {
+		log.WarnContext(ctx, "invalid webhook url", sl.Err(err))
+		return "", models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+	for _, t := range eventTypes {
+		if !slices.Contains(models.WebhookEventTypes, t) {
+			return "", models.Webhook{}, fmt.Errorf("%s: %w: тип события %s", op, storage.ErrInvalidWebhook, t)
+		}
+	}
+	if secret == "" {
+		if secret, err = newSecret(); err != nil {
+			return "", models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	hook, err := w.hooks.SaveWebhook(ctx, models.Webhook{
+		ID:         uuid.New(),
+		UserID:     claims.UserID,
+		URL:        rawURL,
+		EventTypes: strings.Join(eventTypes, ","),
+		Secret:     secret,
+		Active:     true,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to save webhook", sl.Err(err))
+		tracing.Fail(span, err)
+		return "", models.Webhook{}, err
+	}
+	log.InfoContext(ctx, "Webhook created", slog.String("id", hook.ID.String()))
+	return i18n.Tc(ctx, i18n.MsgWebhookCreated), hook, nil
+}
+
+// ListWebhooks возвращает подписки пользователя
+func (w *Webhooks) ListWebhooks(ctx context.Context, token string) ([]models.Webhook, error) {
+	const op = "webhook.ListWebhooks"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	log := w.log.With(slog.String("op", op))
+
+	claims, err := authz.User(token)
+	if err != nil {
+		log.WarnContext(ctx, "invalid token", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	hooks, err := w.hooks.Webhooks(ctx, claims.UserID)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to list webhooks", sl.Err(err))
+		tracing.Fail(span, err)
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// DeleteWebhook удаляет подписку пользователя
+func (w *Webhooks) DeleteWebhook(ctx context.Context, token string, id string) (string, models.Webhook, error) {
+	const op = "webhook.DeleteWebhook"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	log := w.log.With(
+		slog.String("op", op),
+		slog.String("id", id),
+	)
+	log.InfoContext(ctx, "Delete webhook")
+
+	claims, err := authz.User(token)
+	if err != nil {
+		log.WarnContext(ctx, "invalid token", sl.Err(err))
+		return "", models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+	hookID, err := uuid.Parse(id)
+	if err != nil {
+		return "", models.Webhook{}, fmt.Errorf("%s: %w: %s", op, storage.ErrWebhookNotFound, id)
+	}
+
+	hook, err := w.hooks.DeleteWebhook(ctx, claims.UserID, hookID)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to delete webhook", sl.Err(err))
+		tracing.Fail(span, err)
+		return "", models.Webhook{}, err
+	}
+	return i18n.Tc(ctx, i18n.MsgWebhookDeleted), hook, nil
+}
+
+// ListDeliveries возвращает журнал доставок пользователя
+func (w *Webhooks) ListDeliveries(ctx context.Context, token string, webhookID string, status string, limit int) ([]models.WebhookDelivery, error) {
+	const op = "webhook.ListDeliveries"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	log := w.log.With(slog.String("op", op))
+
+	claims, err := authz.User(token)
+	if err != nil {
+		log.WarnContext(ctx, "invalid token", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	hookID := uuid.Nil
+	if webhookID != "" {
+		if hookID, err = uuid.Parse(webhookID); err != nil {
+			return nil, fmt.Errorf("%s: %w: %s", op, storage.ErrWebhookNotFound, webhookID)
+		}
+	}
+	if limit <= 0 {
+		limit = defaultDeliveriesLimit
+	}
+	limit = min(limit, maxDeliveriesLimit)
+
+	deliveries, err := w.deliveries.WebhookDeliveries(ctx, claims.UserID, hookID, status, limit)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to list deliveries", sl.Err(err))
+		tracing.Fail(span, err)
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// RetryDelivery ставит доставку в очередь повторно
+func (w *Webhooks) RetryDelivery(ctx context.Context, token string, id uint64) (models.WebhookDelivery, error) {
+	const op = "webhook.RetryDelivery"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	log := w.log.With(
+		slog.String("op", op),
+		slog.Uint64("id", id),
+	)
+	log.InfoContext(ctx, "Retry delivery")
+
+	claims, err := authz.User(token)
+	if err != nil {
+		log.WarnContext(ctx, "invalid token", sl.Err(err))
+		return models.WebhookDelivery{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	delivery, err := w.deliveries.RetryDelivery(ctx, claims.UserID, id)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to retry delivery", sl.Err(err))
+		tracing.Fail(span, err)
+		return models.WebhookDelivery{}, err
+	}
+	return delivery, nil
+}
+
+// validateURL принимает только http(s) URL, все адреса хоста которого публичные.
+// При доставке адрес проверяется повторно, так как запись DNS может измениться.
+func validateURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("%w: %s", storage.ErrInvalidWebhook, rawURL)
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", storage.ErrInvalidWebhook, u.Hostname(), err)
+	}
+	for _, addr := range addrs {
+		if !webhooks.PublicAddr(addr) {
+			return fmt.Errorf("%w: %s: %v", storage.ErrInvalidWebhook, u.Hostname(), webhooks.ErrForbiddenAddress)
+		}
+	}
+	return nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать ключ подписи: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,73 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"main/internal/domain/models"
+	"main/internal/lib/jwt"
+	"main/internal/services/webhook"
+	"main/internal/storage"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeHooks сохраняет подписки; остальные методы не используются
+type fakeHooks struct {
+	webhook.WebhookStore
+	saved []models.Webhook
+}
+
+func (f *fakeHooks) SaveWebhook(ctx context.Context, hook models.Webhook) (models.Webhook, error) {
+	f.saved = append(f.saved, hook)
+	return hook, nil
+}
+
+func TestCreateWebhook(t *testing.T) {
+	userID := uuid.New()
+	token, err := jwt.NewToken(models.User{ID: userID, Role: models.RoleUser}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		url   string
+		want  error
+	}{
+		{"public address", token, "https://93.184.216.34/hook", nil},
+		{"invalid token", "bad", "https://93.184.216.34/hook", storage.ErrInvalidToken},
+		{"scheme", token, "ftp://93.184.216.34/hook", storage.ErrInvalidWebhook},
+		{"no host", token, "https:///hook", storage.ErrInvalidWebhook},
+		{"loopback", token, "http://127.0.0.1:8080/hook", storage.ErrInvalidWebhook},
+		{"loopback name", token, "http://localhost/hook", storage.ErrInvalidWebhook},
+		{"loopback v6", token, "http://[::1]/hook", storage.ErrInvalidWebhook},
+		{"private", token, "http://10.0.0.5/hook", storage.ErrInvalidWebhook},
+		{"link-local metadata", token, "http://169.254.169.254/latest", storage.ErrInvalidWebhook},
+		{"unspecified", token, "http://0.0.0.0/hook", storage.ErrInvalidWebhook},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := &fakeHooks{}
+			w := webhook.New(slog.New(slog.NewTextHandler(io.Discard, nil)), hooks, nil)
+
+			_, hook, err := w.CreateWebhook(context.Background(), tt.token, tt.url, []string{models.EventWalletCredited}, "")
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) || len(hooks.saved) != 0 {
+					t.Fatalf("err = %v, saved = %d; want %v and nothing saved", err, len(hooks.saved), tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hook.UserID != userID || hook.Secret == "" {
+				t.Fatalf("hook = %+v, want owner %s and generated secret", hook, userID)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Package cache — кеш справочника валют, котировок и остановленных пар поверх
+// storage.Repository. Эти данные читаются при каждой операции кошелька и обмена,
+// а меняются редко: при обновлении курсов и действиях администратора.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"main/internal/domain/models"
+	"main/internal/storage"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Storage отдает валюты, котировки и остановленные пары из памяти не дольше ttl.
+// Запись через Storage сбрасывает кеш сразу, а внутри транзакции — после ее
+// завершения, чтобы параллельные чтения не закешировали незафиксированное
+// состояние. Записи других экземпляров сервиса становятся видны по истечении ttl.
+// Чтение внутри транзакции идет мимо кеша. Остальные методы передаются хранилищу.
+type Storage struct {
+	storage.Repository
+	currencies *value[[]models.Currency]
+	quotes     *value[[]models.RateQuote]
+	halted     *value[map[[2]string]bool]
+}
+
+var _ storage.Repository = (*Storage)(nil)
+
+// DefaultLoadTimeout — предел загрузки значения, если loadTimeout не задан
+const DefaultLoadTimeout = 5 * time.Second
+
+// New оборачивает repo кешем. Нулевой ttl отключает хранение, но одновременные
+// чтения по-прежнему объединяются в один запрос. Загрузка из repo длится не дольше
+// loadTimeout, при нуле — DefaultLoadTimeout.
+func New(repo storage.Repository, ttl, loadTimeout time.Duration) *Storage {
+	if loadTimeout <= 0 {
+		loadTimeout = DefaultLoadTimeout
+	}
+	return &Storage{
+		Repository: repo,
+		currencies: newValue(ttl, loadTimeout, func(ctx context.Context) ([]models.Currency, error) {
+			return repo.Currencies(ctx, false)
+		}),
+		quotes: newValue(ttl, loadTimeout, repo.Quotes),
+		halted: newValue(ttl, loadTimeout, repo.HaltedPairs),
+	}
+}
+
+type invalidator interface {
+	Invalidate()
+}
+
+type txKey struct{ c *Storage }
+
+// txState — кеши, которые нужно сбросить после завершения транзакции
+type txState struct {
+	dirty []invalidator
+}
+
+func (c *Storage) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := c.tx(ctx); ok {
+		return c.Repository.WithinTx(ctx, fn)
+	}
+
+	tx := &txState{}
+	err := c.Repository.WithinTx(ctx, func(ctx context.Context) error {
+		return fn(context.WithValue(ctx, txKey{c}, tx))
+	})
+	// После отката сбрасывать нечего, но и лишний сброс безопасен
+	for _, v := range tx.dirty {
+		v.Invalidate()
+	}
+	return err
+}
+
+func (c *Storage) tx(ctx context.Context) (*txState, bool) {
+	tx, ok := ctx.Value(txKey{c}).(*txState)
+	return tx, ok
+}
+
+// changed сбрасывает кеши values сейчас или, внутри транзакции, после нее
+func (c *Storage) changed(ctx context.Context, values ...invalidator) {
+	if tx, ok := c.tx(ctx); ok {
+		tx.dirty = append(tx.dirty, values...)
+		return
+	}
+	for _, v := range values {
+		v.Invalidate()
+	}
+}
+
+func (c *Storage) Currencies(ctx context.Context, onlyEnabled bool) ([]models.Currency, error) {
+	if _, ok := c.tx(ctx); ok {
+		return c.Repository.Currencies(ctx, onlyEnabled)
+	}
+	all, err := c.currencies.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]models.Currency, 0, len(all))
+	for _, currency := range all {
+		if !onlyEnabled || currency.Enabled {
+			result = append(result, currency)
+		}
+	}
+	return result, nil
+}
+
+func (c *Storage) Currency(ctx context.Context, code string) (models.Currency, error) {
+	if _, ok := c.tx(ctx); ok {
+		return c.Repository.Currency(ctx, code)
+	}
+	all, err := c.currencies.Get(ctx)
+	if err != nil {
+		return models.Currency{}, err
+	}
+	for _, currency := range all {
+		if currency.Code == code {
+			return currency, nil
+		}
+	}
+	return models.Currency{}, fmt.Errorf("%w: %s", storage.ErrCurrencyNotFound, code)
+}
+
+func (c *Storage) SaveCurrency(ctx context.Context, currency models.Currency) (models.Currency, error) {
+	saved, err := c.Repository.SaveCurrency(ctx, currency)
+	c.changed(ctx, c.currencies)
+	return saved, err
+}
+
+func (c *Storage) SetCurrencyEnabled(ctx context.Context, code string, enabled bool) (models.Currency, error) {
+	currency, err := c.Repository.SetCurrencyEnabled(ctx, code, enabled)
+	c.changed(ctx, c.currencies)
+	return currency, err
+}
+
+func (c *Storage) Quotes(ctx context.Context) ([]models.RateQuote, error) {
+	if _, ok := c.tx(ctx); ok {
+		return c.Repository.Quotes(ctx)
+	}
+	quotes, err := c.quotes.Get(ctx)
+	return slices.Clone(quotes), err
+}
+
+func (c *Storage) SaveQuotes(ctx context.Context, quotes []models.RateQuote) error {
+	err := c.Repository.SaveQuotes(ctx, quotes)
+	c.changed(ctx, c.quotes)
+	return err
+}
+
+func (c *Storage) HaltedPairs(ctx context.Context) (map[[2]string]bool, error) {
+	if _, ok := c.tx(ctx); ok {
+		return c.Repository.HaltedPairs(ctx)
+	}
+	halted, err := c.halted.Get(ctx)
+	return maps.Clone(halted), err
+}
+
+func (c *Storage) QuarantineQuotes(ctx context.Context, quotes []models.QuarantinedQuote) error {
+	err := c.Repository.QuarantineQuotes(ctx, quotes)
+	c.changed(ctx, c.halted)
+	return err
+}
+
+func (c *Storage) ResolveQuarantine(ctx context.Context, id uint64, approve bool, resolvedBy string) (models.QuarantinedQuote, error) {
+	quote, err := c.Repository.ResolveQuarantine(ctx, id, approve, resolvedBy)
+	c.changed(ctx, c.quotes, c.halted)
+	return quote, err
+}
@@ -0,0 +1,157 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"main/internal/domain/models"
+	"main/internal/storage"
+	"main/internal/storage/cache"
+	"main/internal/storage/memory"
+	"main/internal/storage/storagetest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(*testing.T) storage.Repository {
+		return cache.New(memory.New(), time.Minute, 0)
+	})
+}
+
+// counting считает чтения котировок и может задерживать их до отмены ctx
+type counting struct {
+	*memory.Storage
+	reads atomic.Int32
+	delay time.Duration
+}
+
+func (c *counting) Quotes(ctx context.Context) ([]models.RateQuote, error) {
+	c.reads.Add(1)
+	select {
+	case <-time.After(c.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return c.Storage.Quotes(ctx)
+}
+
+func quote(bid string) models.RateQuote {
+	return models.RateQuote{Base: "USD", Quote: "EUR", Bid: decimal.RequireFromString(bid),
+		Ask: decimal.RequireFromString(bid), Source: "test", UpdatedAt: time.Now()}
+}
+
+func TestSingleflight(t *testing.T) {
+	repo := &counting{Storage: memory.New(), delay: 20 * time.Millisecond}
+	c := cache.New(repo, time.Minute, 0)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Quotes(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := repo.reads.Load(); n != 1 {
+		t.Fatalf("got %d reads for concurrent misses, want 1", n)
+	}
+}
+
+func TestInvalidation(t *testing.T) {
+	repo := &counting{Storage: memory.New()}
+	c := cache.New(repo, time.Minute, 0)
+	ctx := context.Background()
+
+	if err := c.SaveQuotes(ctx, []models.RateQuote{quote("0.9")}); err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if _, err := c.Quotes(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := repo.reads.Load(); n != 1 {
+		t.Fatalf("got %d reads, want 1", n)
+	}
+
+	// Внутри транзакции кеш сбрасывается только после фиксации
+	err := c.WithinTx(ctx, func(ctx context.Context) error {
+		if err := c.SaveQuotes(ctx, []models.RateQuote{quote("0.8")}); err != nil {
+			return err
+		}
+		quotes, err := c.Quotes(ctx)
+		if err != nil {
+			return err
+		}
+		if !quotes[0].Bid.Equal(decimal.RequireFromString("0.8")) {
+			t.Errorf("transaction sees bid %s, want its own write", quotes[0].Bid)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	quotes, err := c.Quotes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !quotes[0].Bid.Equal(decimal.RequireFromString("0.8")) {
+		t.Fatalf("bid after commit = %s, want 0.8", quotes[0].Bid)
+	}
+
+	// Запись в обход кеша видна после истечения ttl
+	short := cache.New(repo, 10*time.Millisecond, 0)
+	if _, err := short.Quotes(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SaveQuotes(ctx, []models.RateQuote{quote("0.7")}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if quotes, err = short.Quotes(ctx); err != nil || !quotes[0].Bid.Equal(decimal.RequireFromString("0.7")) {
+		t.Fatalf("quotes after ttl = %v, %v; want bid 0.7", quotes, err)
+	}
+}
+
+func TestCanceledWaiter(t *testing.T) {
+	repo := &counting{Storage: memory.New(), delay: 50 * time.Millisecond}
+	c := cache.New(repo, time.Minute, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := c.Quotes(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	// Загрузка продолжилась и ее результат достался следующему вызову
+	if _, err := c.Quotes(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := repo.reads.Load(); n != 1 {
+		t.Fatalf("got %d reads, want 1", n)
+	}
+}
+
+func TestLoadTimeout(t *testing.T) {
+	repo := &counting{Storage: memory.New(), delay: time.Hour}
+	c := cache.New(repo, time.Minute, 10*time.Millisecond)
+
+	// Зависшая загрузка прерывается, хотя вызывающий ждет без дедлайна
+	if _, err := c.Quotes(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	// Неудачная загрузка не сохраняется: следующий вызов загружает заново
+	repo.delay = 0
+	if _, err := c.Quotes(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := repo.reads.Load(); n != 2 {
+		t.Fatalf("got %d reads, want 2", n)
+	}
+}
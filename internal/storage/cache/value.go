@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// value хранит результат load не дольше ttl. Одновременные промахи объединяются
+// в одну загрузку. Загрузка, начатая до Invalidate, свой результат не сохраняет.
+type value[T any] struct {
+	ttl     time.Duration
+	timeout time.Duration // Предел одной загрузки
+	load    func(ctx context.Context) (T, error)
+	group   singleflight.Group
+
+	mu      sync.Mutex
+	data    T
+	expires time.Time
+	version uint64 // Растет при каждой инвалидации
+}
+
+func newValue[T any](ttl, timeout time.Duration, load func(ctx context.Context) (T, error)) *value[T] {
+	return &value[T]{ttl: ttl, timeout: timeout, load: load}
+}
+
+// Get возвращает сохраненное значение или загружает его. Загрузка не прерывается
+// отменой ctx одного из ожидающих: ее результат нужен остальным. Вместо этого
+// ее ограничивает собственный таймаут, чтобы зависший запрос не держал всех ожидающих.
+func (v *value[T]) Get(ctx context.Context) (T, error) {
+	v.mu.Lock()
+	if time.Now().Before(v.expires) {
+		data := v.data
+		v.mu.Unlock()
+		return data, nil
+	}
+	version := v.version
+	v.mu.Unlock()
+
+	ch := v.group.DoChan(strconv.FormatUint(version, 10), func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), v.timeout)
+		defer cancel()
+		data, err := v.load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		v.mu.Lock()
+		if v.version == version {
+			v.data = data
+			v.expires = time.Now().Add(v.ttl)
+		}
+		v.mu.Unlock()
+		return data, nil
+	})
+
+	var zero T
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		return res.Val.(T), nil
+	}
+}
+
+// Invalidate сбрасывает значение: следующий Get загрузит его заново
+func (v *value[T]) Invalidate() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.version++
+	v.expires = time.Time{}
+	var zero T
+	v.data = zero
+}
@@ -0,0 +1,286 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"main/internal/domain/models"
+	"main/internal/lib/text"
+	"main/internal/outbox"
+	"main/internal/storage"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Длина сохраняемого текста ошибки публикации, как в postgresql.Storage
+const maxOutboxError = 1000
+
+func pending(e models.OutboxEvent) bool {
+	return e.PublishedAt == nil && e.DeadAt == nil
+}
+
+func (s *Storage) AppendEvents(ctx context.Context, events []models.OutboxEvent) error {
+	return s.write(ctx, func(st *state) error {
+		for i := range events {
+			st.lastEvent++
+			events[i].ID = st.lastEvent
+			if events[i].CreatedAt.IsZero() {
+				events[i].CreatedAt = time.Now()
+			}
+			event := events[i]
+			event.Payload = bytes.Clone(event.Payload)
+			st.events[event.ID] = event
+
+			if err := st.enqueueWebhooks(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// enqueueWebhooks ставит событие в очередь доставки активным подпискам пользователя
+func (st *state) enqueueWebhooks(event models.OutboxEvent) error {
+	hooks := make([]models.Webhook, 0)
+	for _, h := range st.webhooks {
+		if h.UserID == event.AggregateID && h.Active && h.Accepts(event.Type) {
+			hooks = append(hooks, h)
+		}
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	payload, err := outbox.Encode(event)
+	if err != nil {
+		return fmt.Errorf("Ошибка сериализации события %d: %v", event.ID, err)
+	}
+	for _, h := range hooks {
+		st.lastDelivery++
+		st.deliveries[st.lastDelivery] = models.WebhookDelivery{
+			ID:            st.lastDelivery,
+			WebhookID:     h.ID,
+			UserID:        event.AggregateID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       payload,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: event.CreatedAt,
+			CreatedAt:     event.CreatedAt,
+		}
+	}
+	return nil
+}
+
+func (s *Storage) PendingEvents(ctx context.Context, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := s.read(ctx, func(st *state) error {
+		for _, e := range st.events {
+			if pending(e) {
+				events = append(events, e)
+			}
+		}
+		return nil
+	})
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+
+	// Как в postgresql: с первого события, ждущего повторной попытки, пользователь
+	// задержан, и его события в выборку не попадают
+	now := time.Now()
+	delayed := make(map[uuid.UUID]bool)
+	ready := events[:0]
+	for _, e := range events {
+		if e.NextAttemptAt != nil && e.NextAttemptAt.After(now) {
+			delayed[e.AggregateID] = true
+		}
+		if !delayed[e.AggregateID] {
+			ready = append(ready, e)
+		}
+	}
+	return truncate(ready, limit), err
+}
+
+func (s *Storage) MarkPublished(ctx context.Context, ids []uint64) error {
+	return s.write(ctx, func(st *state) error {
+		now := time.Now()
+		for _, id := range ids {
+			if e, ok := st.events[id]; ok {
+				e.PublishedAt = &now
+				st.events[id] = e
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Storage) MarkFailed(ctx context.Context, id uint64, cause error, retryAt time.Time) error {
+	return s.markFailed(ctx, id, cause, func(e *models.OutboxEvent) { e.NextAttemptAt = &retryAt })
+}
+
+func (s *Storage) MarkDead(ctx context.Context, id uint64, cause error) error {
+	now := time.Now()
+	return s.markFailed(ctx, id, cause, func(e *models.OutboxEvent) { e.DeadAt = &now })
+}
+
+func (s *Storage) markFailed(ctx context.Context, id uint64, cause error, mark func(e *models.OutboxEvent)) error {
+	msg := text.Truncate(cause.Error(), maxOutboxError)
+	return s.write(ctx, func(st *state) error {
+		if e, ok := st.events[id]; ok {
+			e.Attempts++
+			e.LastError = msg
+			mark(&e)
+			st.events[id] = e
+		}
+		return nil
+	})
+}
+
+func (s *Storage) SaveWebhook(ctx context.Context, hook models.Webhook) (models.Webhook, error) {
+	err := s.write(ctx, func(st *state) error {
+		if _, ok := st.webhooks[hook.ID]; ok {
+			return fmt.Errorf("Ошибка создания webhook: %s уже существует", hook.ID)
+		}
+		if hook.CreatedAt.IsZero() {
+			hook.CreatedAt = time.Now()
+		}
+		st.webhooks[hook.ID] = hook
+		return nil
+	})
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	return hook, nil
+}
+
+func (s *Storage) Webhooks(ctx context.Context, userID uuid.UUID) ([]models.Webhook, error) {
+	var hooks []models.Webhook
+	err := s.read(ctx, func(st *state) error {
+		for _, h := range st.webhooks {
+			if h.UserID == userID {
+				hooks = append(hooks, h)
+			}
+		}
+		return nil
+	})
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].CreatedAt.Before(hooks[j].CreatedAt) })
+	return hooks, err
+}
+
+func (s *Storage) DeleteWebhook(ctx context.Context, userID uuid.UUID, id uuid.UUID) (models.Webhook, error) {
+	var hook models.Webhook
+	err := s.write(ctx, func(st *state) error {
+		h, ok := st.webhooks[id]
+		if !ok || h.UserID != userID {
+			return fmt.Errorf("%w: %s", storage.ErrWebhookNotFound, id)
+		}
+		for dID, d := range st.deliveries {
+			if d.WebhookID == id && d.Status == models.DeliveryPending {
+				d.Status = models.DeliveryDead
+				d.LastError = "webhook удален"
+				st.deliveries[dID] = d
+			}
+		}
+		delete(st.webhooks, id)
+		hook = h
+		return nil
+	})
+	return hook, err
+}
+
+func (s *Storage) WebhookDeliveries(ctx context.Context, userID uuid.UUID, webhookID uuid.UUID, status string, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := s.read(ctx, func(st *state) error {
+		for _, d := range st.deliveries {
+			if d.UserID != userID ||
+				(webhookID != uuid.Nil && d.WebhookID != webhookID) ||
+				(status != "" && d.Status != status) {
+				continue
+			}
+			deliveries = append(deliveries, d)
+		}
+		return nil
+	})
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID > deliveries[j].ID })
+	return truncate(deliveries, limit), err
+}
+
+func (s *Storage) RetryDelivery(ctx context.Context, userID uuid.UUID, id uint64) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := s.write(ctx, func(st *state) error {
+		d, ok := st.deliveries[id]
+		if !ok || d.UserID != userID {
+			return fmt.Errorf("%w: %d", storage.ErrDeliveryNotFound, id)
+		}
+		if _, ok := st.webhooks[d.WebhookID]; !ok {
+			return fmt.Errorf("%w: %s", storage.ErrWebhookNotFound, d.WebhookID)
+		}
+		d.Status = models.DeliveryPending
+		d.Attempts = 0
+		d.NextAttemptAt = time.Now()
+		st.deliveries[id] = d
+		delivery = d
+		return nil
+	})
+	return delivery, err
+}
+
+func (s *Storage) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookJob, error) {
+	var jobs []models.WebhookJob
+	err := s.write(ctx, func(st *state) error {
+		now := time.Now()
+		var ready []models.WebhookDelivery
+		for _, d := range st.deliveries {
+			if d.Status == models.DeliveryPending && !d.NextAttemptAt.After(now) {
+				ready = append(ready, d)
+			}
+		}
+		sort.Slice(ready, func(i, j int) bool { return ready[i].ID < ready[j].ID })
+
+		for _, d := range truncate(ready, limit) {
+			d.NextAttemptAt = now.Add(lease)
+			hook, ok := st.webhooks[d.WebhookID]
+			if !ok {
+				// Подписку удалили после постановки в очередь
+				d.Status = models.DeliveryDead
+				d.LastError = "webhook удален"
+				st.deliveries[d.ID] = d
+				continue
+			}
+			st.deliveries[d.ID] = d
+			jobs = append(jobs, models.WebhookJob{Delivery: d, URL: hook.URL, Secret: hook.Secret})
+		}
+		return nil
+	})
+	return jobs, err
+}
+
+func (s *Storage) SaveAttempt(ctx context.Context, delivery models.WebhookDelivery, attempt models.WebhookAttempt) error {
+	return s.write(ctx, func(st *state) error {
+		if d, ok := st.deliveries[delivery.ID]; ok {
+			d.Status = delivery.Status
+			d.Attempts = delivery.Attempts
+			d.NextAttemptAt = delivery.NextAttemptAt
+			d.LastStatusCode = delivery.LastStatusCode
+			d.LastError = delivery.LastError
+			d.DeliveredAt = delivery.DeliveredAt
+			st.deliveries[d.ID] = d
+		}
+		st.lastAttempt++
+		attempt.ID = st.lastAttempt
+		if attempt.CreatedAt.IsZero() {
+			attempt.CreatedAt = time.Now()
+		}
+		st.attempts = append(st.attempts, attempt)
+		return nil
+	})
+}
+
+// truncate ограничивает выборку как LIMIT; отрицательный limit не ограничивает
+func truncate[T any](items []T, limit int) []T {
+	if limit >= 0 && len(items) > limit {
+		return items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,122 @@
+// Package memory — хранилище в памяти для тестов сервисов и локального запуска без базы.
+// Поведение совпадает с postgresql.Storage и проверяется общим набором тестов storagetest.
+package memory
+
+import (
+	"context"
+	"main/internal/domain/models"
+	"main/internal/storage"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var _ storage.Repository = (*Storage)(nil)
+
+// Storage хранит данные в памяти. Транзакции сериализуются: WithinTx работает
+// с копией данных под блокировкой и подменяет ею состояние только при успехе,
+// поэтому изменения откатываются целиком, а читатели не видят незафиксированных данных.
+type Storage struct {
+	mu    sync.RWMutex
+	state *state
+}
+
+type state struct {
+	users      map[uuid.UUID]models.User
+	wallets    map[uuid.UUID]models.UserWallet
+	currencies map[string]models.Currency
+	quotes     map[[2]string]models.RateQuote
+	history    []models.RateQuote // История котировок, как rate_history
+	quarantine map[uint64]models.QuarantinedQuote
+	events     map[uint64]models.OutboxEvent
+	webhooks   map[uuid.UUID]models.Webhook
+	deliveries map[uint64]models.WebhookDelivery
+	attempts   []models.WebhookAttempt
+
+	// Последние выданные идентификаторы, как у bigserial
+	lastQuarantine, lastEvent, lastDelivery, lastAttempt uint64
+}
+
+func New() *Storage {
+	return &Storage{state: &state{
+		users:      make(map[uuid.UUID]models.User),
+		wallets:    make(map[uuid.UUID]models.UserWallet),
+		currencies: make(map[string]models.Currency),
+		quotes:     make(map[[2]string]models.RateQuote),
+		quarantine: make(map[uint64]models.QuarantinedQuote),
+		events:     make(map[uint64]models.OutboxEvent),
+		webhooks:   make(map[uuid.UUID]models.Webhook),
+		deliveries: make(map[uint64]models.WebhookDelivery),
+	}}
+}
+
+func (st *state) clone() *state {
+	c := *st
+	c.users = maps.Clone(st.users)
+	c.wallets = maps.Clone(st.wallets)
+	c.currencies = maps.Clone(st.currencies)
+	c.quotes = maps.Clone(st.quotes)
+	c.history = slices.Clone(st.history)
+	c.quarantine = maps.Clone(st.quarantine)
+	c.events = maps.Clone(st.events)
+	c.webhooks = maps.Clone(st.webhooks)
+	c.deliveries = maps.Clone(st.deliveries)
+	c.attempts = slices.Clone(st.attempts)
+	return &c
+}
+
+type txKey struct{ s *Storage }
+
+// WithinTx выполняет fn в транзакции. Вложенный вызов работает как savepoint:
+// его изменения попадают во внешнюю транзакцию, только если fn вернула nil.
+// Внутри fn методы хранилища нужно вызывать с полученным ctx: вызов с другим
+// контекстом будет ждать завершения транзакции.
+func (s *Storage) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if outer, ok := s.tx(ctx); ok {
+		st := outer.clone()
+		if err := fn(context.WithValue(ctx, txKey{s}, st)); err != nil {
+			return err
+		}
+		*outer = *st
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.state.clone()
+	if err := fn(context.WithValue(ctx, txKey{s}, st)); err != nil {
+		return err
+	}
+	// Как и в базе, отмененный запрос не фиксирует изменения
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.state = st
+	return nil
+}
+
+func (s *Storage) tx(ctx context.Context) (*state, bool) {
+	st, ok := ctx.Value(txKey{s}).(*state)
+	return st, ok
+}
+
+// read выполняет fn над данными транзакции из ctx или над зафиксированными данными
+func (s *Storage) read(ctx context.Context, fn func(st *state) error) error {
+	if st, ok := s.tx(ctx); ok {
+		return fn(st)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fn(s.state)
+}
+
+// write выполняет fn атомарно: вне транзакции изменения применяются, только если fn не вернула ошибку
+func (s *Storage) write(ctx context.Context, fn func(st *state) error) error {
+	return s.WithinTx(ctx, func(ctx context.Context) error {
+		st, _ := s.tx(ctx)
+		return fn(st)
+	})
+}
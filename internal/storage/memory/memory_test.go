@@ -0,0 +1,14 @@
+package memory_test
+
+import (
+	"main/internal/storage"
+	"main/internal/storage/memory"
+	"main/internal/storage/storagetest"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(*testing.T) storage.Repository {
+		return memory.New()
+	})
+}
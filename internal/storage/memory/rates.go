@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"main/internal/domain/models"
+	"main/internal/storage"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+)
+
+func (s *Storage) Quotes(ctx context.Context) ([]models.RateQuote, error) {
+	var quotes []models.RateQuote
+	err := s.read(ctx, func(st *state) error {
+		for _, q := range st.quotes {
+			quotes = append(quotes, q)
+		}
+		return nil
+	})
+	sort.Slice(quotes, func(i, j int) bool {
+		if quotes[i].Base != quotes[j].Base {
+			return quotes[i].Base < quotes[j].Base
+		}
+		return quotes[i].Quote < quotes[j].Quote
+	})
+	return quotes, err
+}
+
+func (s *Storage) SaveQuotes(ctx context.Context, quotes []models.RateQuote) error {
+	return s.write(ctx, func(st *state) error {
+		for _, q := range quotes {
+			if err := st.saveQuote(q); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// saveQuote повторяет ограничения таблицы rate_quotes и добавляет котировку в историю
+func (st *state) saveQuote(q models.RateQuote) error {
+	if q.Base == q.Quote || !q.Bid.IsPositive() || q.Ask.LessThan(q.Bid) {
+		return fmt.Errorf("%w: %s/%s bid %s ask %s", storage.ErrInvalidQuote, q.Base, q.Quote, q.Bid, q.Ask)
+	}
+	st.quotes[[2]string{q.Base, q.Quote}] = q
+	for _, h := range st.history {
+		if h.Base == q.Base && h.Quote == q.Quote && h.UpdatedAt.Equal(q.UpdatedAt) {
+			return nil
+		}
+	}
+	st.history = append(st.history, q)
+	return nil
+}
+
+func (s *Storage) QuotesAt(ctx context.Context, at time.Time) ([]models.RateQuote, error) {
+	latest := make(map[[2]string]models.RateQuote)
+	err := s.read(ctx, func(st *state) error {
+		for _, q := range st.history {
+			pair := [2]string{q.Base, q.Quote}
+			if prev, ok := latest[pair]; !q.UpdatedAt.After(at) && (!ok || q.UpdatedAt.After(prev.UpdatedAt)) {
+				latest[pair] = q
+			}
+		}
+		return nil
+	})
+	quotes := slices.Collect(maps.Values(latest))
+	sort.Slice(quotes, func(i, j int) bool {
+		if quotes[i].Base != quotes[j].Base {
+			return quotes[i].Base < quotes[j].Base
+		}
+		return quotes[i].Quote < quotes[j].Quote
+	})
+	return quotes, err
+}
+
+func (s *Storage) RatesUpdatedAt(ctx context.Context) (time.Time, error) {
+	var updated time.Time
+	err := s.read(ctx, func(st *state) error {
+		for _, q := range st.quotes {
+			if q.UpdatedAt.After(updated) {
+				updated = q.UpdatedAt
+			}
+		}
+		return nil
+	})
+	return updated, err
+}
+
+func (s *Storage) QuarantineQuotes(ctx context.Context, quotes []models.QuarantinedQuote) error {
+	return s.write(ctx, func(st *state) error {
+		for i := range quotes {
+			st.lastQuarantine++
+			quotes[i].ID = st.lastQuarantine
+			if quotes[i].Status == "" {
+				quotes[i].Status = models.QuarantinePending
+			}
+			if quotes[i].CreatedAt.IsZero() {
+				quotes[i].CreatedAt = time.Now()
+			}
+			st.quarantine[quotes[i].ID] = quotes[i]
+		}
+		return nil
+	})
+}
+
+func (s *Storage) HaltedPairs(ctx context.Context) (map[[2]string]bool, error) {
+	halted := make(map[[2]string]bool)
+	err := s.read(ctx, func(st *state) error {
+		for _, q := range st.quarantine {
+			if q.Status == models.QuarantinePending {
+				halted[[2]string{q.Base, q.Quote}] = true
+			}
+		}
+		return nil
+	})
+	return halted, err
+}
+
+func (s *Storage) QuarantinedQuotes(ctx context.Context, onlyPending bool) ([]models.QuarantinedQuote, error) {
+	var quotes []models.QuarantinedQuote
+	err := s.read(ctx, func(st *state) error {
+		for _, q := range st.quarantine {
+			if !onlyPending || q.Status == models.QuarantinePending {
+				quotes = append(quotes, q)
+			}
+		}
+		return nil
+	})
+	sort.Slice(quotes, func(i, j int) bool {
+		if !quotes[i].CreatedAt.Equal(quotes[j].CreatedAt) {
+			return quotes[i].CreatedAt.After(quotes[j].CreatedAt)
+		}
+		return quotes[i].ID > quotes[j].ID
+	})
+	return quotes, err
+}
+
+func (s *Storage) ResolveQuarantine(ctx context.Context, id uint64, approve bool, resolvedBy string) (models.QuarantinedQuote, error) {
+	var quote models.QuarantinedQuote
+	err := s.write(ctx, func(st *state) error {
+		q, ok := st.quarantine[id]
+		if !ok || q.Status != models.QuarantinePending {
+			return fmt.Errorf("%w: %d", storage.ErrQuoteNotFound, id)
+		}
+
+		now := time.Now()
+		q.ResolvedAt = &now
+		q.ResolvedBy = resolvedBy
+		q.Status = models.QuarantineRejected
+
+		if approve {
+			q.Status = models.QuarantineApproved
+			if err := st.saveQuote(q.RateQuote()); err != nil {
+				return err
+			}
+			for otherID, other := range st.quarantine {
+				if otherID != id && other.Base == q.Base && other.Quote == q.Quote && other.Status == models.QuarantinePending {
+					other.Status = models.QuarantineRejected
+					other.ResolvedAt = &now
+					other.ResolvedBy = resolvedBy
+					st.quarantine[otherID] = other
+				}
+			}
+		}
+		st.quarantine[id] = q
+		quote = q
+		return nil
+	})
+	return quote, err
+}
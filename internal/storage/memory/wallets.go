@@ -0,0 +1,247 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"main/internal/domain/models"
+	"main/internal/storage"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func (s *Storage) CreateUser(ctx context.Context, user models.User) error {
+	return s.write(ctx, func(st *state) error {
+		for _, u := range st.users {
+			if u.ID == user.ID || u.Username == user.Username || u.Email == user.Email {
+				return storage.ErrUserExists
+			}
+		}
+		if user.Role == "" {
+			user.Role = models.RoleUser
+		}
+		user.PassHash = bytes.Clone(user.PassHash)
+		user.Balances = nil
+		st.users[user.ID] = user
+		return nil
+	})
+}
+
+func (s *Storage) User(ctx context.Context, email string) (models.User, error) {
+	var user models.User
+	err := s.read(ctx, func(st *state) error {
+		for _, u := range st.users {
+			if u.Email == email {
+				user = u
+				return nil
+			}
+		}
+		return storage.ErrUserNotFound
+	})
+	return user, err
+}
+
+func (s *Storage) AddWalletUser(ctx context.Context, userID uuid.UUID) error {
+	return s.write(ctx, func(st *state) error {
+		if _, ok := st.users[userID]; !ok {
+			return fmt.Errorf("%w: %s", storage.ErrUserNotFound, userID)
+		}
+		for _, c := range st.currencies {
+			if c.Enabled {
+				st.ensureWallet(userID, c.Code)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Storage) Wallets(ctx context.Context, userID uuid.UUID) ([]models.UserWallet, error) {
+	var wallets []models.UserWallet
+	err := s.read(ctx, func(st *state) error {
+		for _, w := range st.wallets {
+			if w.UserID == userID {
+				wallets = append(wallets, w)
+			}
+		}
+		return nil
+	})
+	sort.Slice(wallets, func(i, j int) bool {
+		if wallets[i].Currency != wallets[j].Currency {
+			return wallets[i].Currency < wallets[j].Currency
+		}
+		return wallets[i].Name < wallets[j].Name
+	})
+	return wallets, err
+}
+
+// LockWallet возвращает основной кошелек, создавая его при отсутствии. Отдельная блокировка
+// не нужна: транзакции хранилища в памяти и так выполняются по одной.
+func (s *Storage) LockWallet(ctx context.Context, userID uuid.UUID, currency string) (models.UserWallet, error) {
+	var wallet models.UserWallet
+	err := s.write(ctx, func(st *state) error {
+		if _, ok := st.currencies[currency]; !ok {
+			return fmt.Errorf("%w: %s", storage.ErrCurrencyNotFound, currency)
+		}
+		if _, ok := st.users[userID]; !ok {
+			return fmt.Errorf("%w: %s", storage.ErrUserNotFound, userID)
+		}
+		wallet = st.ensureWallet(userID, currency)
+		return nil
+	})
+	return wallet, err
+}
+
+func (s *Storage) LockWalletByID(ctx context.Context, userID uuid.UUID, id uuid.UUID) (models.UserWallet, error) {
+	var wallet models.UserWallet
+	err := s.read(ctx, func(st *state) error {
+		w, ok := st.wallets[id]
+		if !ok || w.UserID != userID {
+			return fmt.Errorf("%w: %s", storage.ErrWalletNotFound, id)
+		}
+		wallet = w
+		return nil
+	})
+	return wallet, err
+}
+
+func (s *Storage) CreateWallet(ctx context.Context, wallet models.UserWallet) (models.UserWallet, error) {
+	err := s.write(ctx, func(st *state) error {
+		if _, ok := st.currencies[wallet.Currency]; !ok {
+			return fmt.Errorf("%w: %s", storage.ErrCurrencyNotFound, wallet.Currency)
+		}
+		if _, ok := st.users[wallet.UserID]; !ok {
+			return fmt.Errorf("%w: %s", storage.ErrUserNotFound, wallet.UserID)
+		}
+		wallet.ID = uuid.New()
+		wallet.Balance = decimal.Zero
+		wallet.Archived = false
+		if st.nameTaken(wallet) {
+			return fmt.Errorf("%w: %s", storage.ErrWalletExists, wallet.Name)
+		}
+		st.wallets[wallet.ID] = wallet
+		return nil
+	})
+	if err != nil {
+		return models.UserWallet{}, err
+	}
+	return wallet, nil
+}
+
+func (s *Storage) SaveWallet(ctx context.Context, wallet models.UserWallet) error {
+	return s.write(ctx, func(st *state) error {
+		stored, ok := st.wallets[wallet.ID]
+		if !ok {
+			return fmt.Errorf("%w: %s", storage.ErrWalletNotFound, wallet.ID)
+		}
+		if wallet.Balance.IsNegative() {
+			return fmt.Errorf("%w: %s", storage.ErrInsufficientFunds, stored.Currency)
+		}
+		stored.Balance = wallet.Balance
+		stored.Name = wallet.Name
+		stored.Archived = wallet.Archived
+		if st.nameTaken(stored) {
+			return fmt.Errorf("%w: %s", storage.ErrWalletExists, stored.Name)
+		}
+		st.wallets[wallet.ID] = stored
+		return nil
+	})
+}
+
+// nameTaken сообщает, что название дополнительного кошелька занято другим неархивным
+// кошельком пользователя. Регистр не учитывается, как в уникальном индексе PostgreSQL.
+func (st *state) nameTaken(wallet models.UserWallet) bool {
+	if wallet.Main() || wallet.Archived {
+		return false
+	}
+	for _, w := range st.wallets {
+		if w.ID != wallet.ID && w.UserID == wallet.UserID && !w.Main() && !w.Archived &&
+			strings.ToLower(w.Name) == strings.ToLower(wallet.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureWallet возвращает основной кошелек пользователя в валюте, создавая пустой
+func (st *state) ensureWallet(userID uuid.UUID, currency string) models.UserWallet {
+	for _, w := range st.wallets {
+		if w.UserID == userID && w.Currency == currency && w.Main() {
+			return w
+		}
+	}
+	w := models.UserWallet{ID: uuid.New(), UserID: userID, Currency: currency}
+	st.wallets[w.ID] = w
+	return w
+}
+
+// backfillWallets создает недостающие кошельки в валюте всем пользователям
+func (st *state) backfillWallets(currency string) {
+	for id := range st.users {
+		st.ensureWallet(id, currency)
+	}
+}
+
+func (s *Storage) Currencies(ctx context.Context, onlyEnabled bool) ([]models.Currency, error) {
+	var currencies []models.Currency
+	err := s.read(ctx, func(st *state) error {
+		for _, c := range st.currencies {
+			if c.Enabled || !onlyEnabled {
+				currencies = append(currencies, c)
+			}
+		}
+		return nil
+	})
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i].Code < currencies[j].Code })
+	return currencies, err
+}
+
+func (s *Storage) Currency(ctx context.Context, code string) (models.Currency, error) {
+	var currency models.Currency
+	err := s.read(ctx, func(st *state) error {
+		c, ok := st.currencies[code]
+		if !ok {
+			return fmt.Errorf("%w: %s", storage.ErrCurrencyNotFound, code)
+		}
+		currency = c
+		return nil
+	})
+	return currency, err
+}
+
+func (s *Storage) SaveCurrency(ctx context.Context, currency models.Currency) (models.Currency, error) {
+	err := s.write(ctx, func(st *state) error {
+		if currency.Kind == "" {
+			currency.Kind = models.KindFiat
+		}
+		st.currencies[currency.Code] = currency
+		if currency.Enabled {
+			st.backfillWallets(currency.Code)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Currency{}, err
+	}
+	return currency, nil
+}
+
+func (s *Storage) SetCurrencyEnabled(ctx context.Context, code string, enabled bool) (models.Currency, error) {
+	var currency models.Currency
+	err := s.write(ctx, func(st *state) error {
+		c, ok := st.currencies[code]
+		if !ok {
+			return fmt.Errorf("%w: %s", storage.ErrCurrencyNotFound, code)
+		}
+		c.Enabled = enabled
+		st.currencies[code] = c
+		if enabled {
+			st.backfillWallets(code)
+		}
+		currency = c
+		return nil
+	})
+	return currency, err
+}
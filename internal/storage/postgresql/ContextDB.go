@@ -1,23 +1,116 @@
 package postgresql
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
 
 type User struct {
 	ID       uuid.UUID    `json:"id" gorm:"primaryKey"`
 	Username string       `json:"username" gorm:"unique"`
 	Email    string       `json:"email" gorm:"unique"`
 	PassHash []byte       `json:"password"`
-	Balances []UserWallet `json:"balances" gorm:"foreignKey:UserID"`
+	Language string       `json:"language"`                 // Предпочитаемый язык сообщений (ru, en)
+	Role     string       `json:"role" gorm:"default:user"` // Роль пользователя (user, admin)
+	Balances []UserWallet `json:"balances" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
 
+// UserWallet — кошелек пользователя в одной валюте. Схема задается миграциями,
+// теги описывают ее для gorm: основной кошелек (без названия) один на (user_id, currency),
+// названия остальных уникальны среди неархивных, баланс не бывает отрицательным.
 type UserWallet struct {
-	ID       uuid.UUID `json:"id" gorm:"primaryKey"`
-	UserID   uuid.UUID `json:"user_id" gorm:"index"`                                                           // Внешний ключ на пользователя
-	Currency string    `json:"currency"`                                                                       // Валюта (USD, EUR, RUB)
-	Balance  float32   `json:"balance" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Баланс в конкретной валюте
+	ID       uuid.UUID       `json:"id" gorm:"primaryKey"`
+	UserID   uuid.UUID       `json:"user_id" gorm:"not null;index"`                                                                       // Внешний ключ на пользователя
+	Currency string          `json:"currency" gorm:"not null"`                                                                            // Валюта из справочника currencies
+	Balance  decimal.Decimal `json:"balance" gorm:"type:numeric(38,18);not null;check:ck_user_wallets_balance_non_negative,balance >= 0"` // Баланс в конкретной валюте
+	Name     string          `json:"name" gorm:"not null;default:''"`                                                                     // Название дополнительного кошелька, у основного пусто
+	Archived bool            `json:"archived" gorm:"not null;default:false"`                                                              // Кошелек в архиве
+}
+
+type RateQuote struct {
+	Base      string          `json:"base" gorm:"primaryKey"`         // Базовый актив пары (например, BTC)
+	Quote     string          `json:"quote" gorm:"primaryKey"`        // Котируемый актив пары (например, EUR)
+	Bid       decimal.Decimal `json:"bid" gorm:"type:numeric(38,18)"` // Цена, по которой покупается Base
+	Ask       decimal.Decimal `json:"ask" gorm:"type:numeric(38,18)"` // Цена, по которой продается Base
+	Source    string          `json:"source"`                         // Источник котировки
+	UpdatedAt time.Time       `json:"updated_at"`                     // Время получения котировки
+}
+
+type Currency struct {
+	Code            string          `json:"code" gorm:"primaryKey"`                          // ISO код валюты (USD, EUR, RUB)
+	Name            string          `json:"name"`                                            // Название валюты
+	Decimals        int             `json:"decimals" gorm:"default:2"`                       // Количество знаков после запятой
+	Enabled         bool            `json:"enabled" gorm:"default:true"`                     // Валюта доступна пользователям
+	DepositEnabled  bool            `json:"deposit_enabled" gorm:"default:true"`             // Разрешено пополнение
+	WithdrawEnabled bool            `json:"withdraw_enabled" gorm:"default:true"`            // Разрешен вывод
+	ExchangeEnabled bool            `json:"exchange_enabled" gorm:"default:true"`            // Разрешен обмен
+	Kind            string          `json:"kind" gorm:"default:fiat"`                        // Тип актива (fiat, crypto)
+	MinAmount       decimal.Decimal `json:"min_amount" gorm:"type:numeric(38,18);default:0"` // Минимальная сумма операции
+	ProviderID      string          `json:"provider_id"`                                     // Идентификатор актива у источника курсов (bitcoin, ethereum)
+}
+
+type QuarantinedQuote struct {
+	ID          uint64          `json:"id" gorm:"primaryKey"`
+	Base        string          `json:"base" gorm:"index:idx_quarantine_pair"`
+	Quote       string          `json:"quote" gorm:"index:idx_quarantine_pair"`
+	Bid         decimal.Decimal `json:"bid" gorm:"type:numeric(38,18)"`
+	Ask         decimal.Decimal `json:"ask" gorm:"type:numeric(38,18)"`
+	Source      string          `json:"source"`
+	PreviousMid decimal.Decimal `json:"previous_mid" gorm:"type:numeric(38,18);default:0"` // Последняя принятая средняя цена
+	Reason      string          `json:"reason"`                                            // Причина (non_positive, deviation, divergence)
+	Status      string          `json:"status" gorm:"index;default:pending"`               // Статус (pending, approved, rejected)
+	CreatedAt   time.Time       `json:"created_at"`
+	ResolvedAt  *time.Time      `json:"resolved_at"`
+	ResolvedBy  string          `json:"resolved_by"` // Email администратора
+}
+
+type OutboxEvent struct {
+	ID            uint64     `json:"id" gorm:"primaryKey"`
+	AggregateID   uuid.UUID  `json:"aggregate_id" gorm:"type:uuid;index"` // Пользователь, к которому относится событие
+	Type          string     `json:"type"`                                // Тип события (WalletCredited, WalletDebited, ...)
+	Payload       []byte     `json:"payload" gorm:"type:jsonb"`
+	CreatedAt     time.Time  `json:"created_at"`
+	PublishedAt   *time.Time `json:"published_at" gorm:"index"` // Время публикации, NULL — ожидает публикации
+	Attempts      int        `json:"attempts" gorm:"default:0"` // Неудачные попытки публикации
+	LastError     string     `json:"last_error"`
+	NextAttemptAt *time.Time `json:"next_attempt_at"` // Не публиковать раньше, NULL — сразу
+	DeadAt        *time.Time `json:"dead_at"`         // Перенесено в dead letter после MaxAttempts неудач
+}
+
+type Webhook struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID     uuid.UUID `json:"user_id" gorm:"type:uuid;index"` // Владелец подписки
+	URL        string    `json:"url"`
+	EventTypes string    `json:"event_types"` // Типы событий через запятую, пусто — все
+	Secret     string    `json:"-"`           // Ключ подписи HMAC-SHA256
+	Active     bool      `json:"active" gorm:"default:true"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID             uint64     `json:"id" gorm:"primaryKey"`
+	WebhookID      uuid.UUID  `json:"webhook_id" gorm:"type:uuid;index"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;index"`
+	EventID        uint64     `json:"event_id"` // Событие outbox
+	EventType      string     `json:"event_type"`
+	Payload        []byte     `json:"payload" gorm:"type:jsonb"`
+	Status         string     `json:"status" gorm:"index;default:pending"` // pending, delivered, dead
+	Attempts       int        `json:"attempts" gorm:"default:0"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"index"`
+	LastStatusCode int        `json:"last_status_code"`
+	LastError      string     `json:"last_error"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
 }
 
-type ExchangeRate struct {
-	Currency  string  `json:"currency" gorm:"primaryKey"` // Валюта (например, USD)
-	RateToUSD float32 `json:"rate_to_usd"`                // Курс относительно базовой валюты USD
+type WebhookAttempt struct {
+	ID         uint64    `json:"id" gorm:"primaryKey"`
+	DeliveryID uint64    `json:"delivery_id" gorm:"index"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
 }
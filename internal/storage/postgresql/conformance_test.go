@@ -0,0 +1,14 @@
+package postgresql
+
+import (
+	"main/internal/storage"
+	"main/internal/storage/storagetest"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Repository {
+		db, _ := openTestDB(t)
+		return &Storage{log: discardLog, db: db}
+	})
+}
@@ -0,0 +1,376 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"main/internal/domain/models"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const insertWalletQuery = `INSERT INTO user_wallets (id, user_id, currency, balance) VALUES ($1, $2, $3, $4)`
+
+func TestWalletConstraints(t *testing.T) {
+	db, _ := testDB(t)
+	userID := createUser(t, db)
+	if err := db.Exec(insertWalletQuery, uuid.New(), userID, "USD", "10").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		args  []any
+		want  error
+	}{
+		{
+			name:  "duplicate currency",
+			query: insertWalletQuery,
+			args:  []any{uuid.New(), userID, "USD", "0"},
+			want:  gorm.ErrDuplicatedKey,
+		},
+		{
+			name:  "duplicate wallet name",
+			query: `INSERT INTO user_wallets (id, user_id, currency, balance, name) VALUES ($1, $2, 'USD', 0, 'Savings'), ($3, $2, 'EUR', 0, 'savings')`,
+			args:  []any{uuid.New(), userID, uuid.New()},
+			want:  gorm.ErrDuplicatedKey,
+		},
+		{
+			name:  "archived main wallet",
+			query: `UPDATE user_wallets SET archived = true WHERE user_id = $1 AND name = ''`,
+			args:  []any{userID},
+			want:  gorm.ErrCheckConstraintViolated,
+		},
+		{
+			name:  "negative balance",
+			query: insertWalletQuery,
+			args:  []any{uuid.New(), userID, "EUR", "-0.01"},
+			want:  gorm.ErrCheckConstraintViolated,
+		},
+		{
+			name:  "negative balance on update",
+			query: `UPDATE user_wallets SET balance = balance - 11 WHERE user_id = $1 AND currency = $2`,
+			args:  []any{userID, "USD"},
+			want:  gorm.ErrCheckConstraintViolated,
+		},
+		{
+			name:  "unknown user",
+			query: insertWalletQuery,
+			args:  []any{uuid.New(), uuid.New(), "USD", "0"},
+			want:  gorm.ErrForeignKeyViolated,
+		},
+		{
+			name:  "unknown currency",
+			query: insertWalletQuery,
+			args:  []any{uuid.New(), userID, "XXX", "0"},
+			want:  gorm.ErrForeignKeyViolated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.Exec(tt.query, tt.args...).Error
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWalletsDeletedWithUser(t *testing.T) {
+	db, _ := testDB(t)
+	userID := createUser(t, db)
+	if err := addWallets(db, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Exec(`DELETE FROM users WHERE id = $1`, userID).Error; err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	if err := db.Model(&UserWallet{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatalf("%d wallets left after user deletion", count)
+	}
+}
+
+func TestLockWalletConcurrent(t *testing.T) {
+	db, _ := testDB(t)
+	s := &Storage{log: discardLog, db: db}
+	userID := createUser(t, db)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.LockWallet(context.Background(), userID, "EUR"); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	var count int64
+	if err := db.Model(&UserWallet{}).Where("user_id = ? AND currency = ?", userID, "EUR").Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("got %d EUR wallets, want 1", count)
+	}
+}
+
+func TestRateQuoteConstraints(t *testing.T) {
+	db, _ := testDB(t)
+
+	tests := []struct {
+		name     string
+		base     string
+		quote    string
+		bid, ask string
+	}{
+		{name: "zero bid", base: "USD", quote: "EUR", bid: "0", ask: "1"},
+		{name: "negative ask", base: "USD", quote: "EUR", bid: "-1", ask: "-1"},
+		{name: "bid above ask", base: "USD", quote: "EUR", bid: "1.1", ask: "1"},
+		{name: "same currency", base: "USD", quote: "USD", bid: "1", ask: "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.Exec(upsertQuoteQuery, tt.base, tt.quote, tt.bid, tt.ask, "test", time.Now()).Error
+			if !errors.Is(err, gorm.ErrCheckConstraintViolated) {
+				t.Fatalf("got %v, want %v", err, gorm.ErrCheckConstraintViolated)
+			}
+		})
+	}
+
+	if err := db.Exec(upsertQuoteQuery, "USD", "EUR", "0.9", "0.91", "test", time.Now()).Error; err != nil {
+		t.Fatalf("valid quote rejected: %v", err)
+	}
+}
+
+func TestCurrencyConstraints(t *testing.T) {
+	db, _ := testDB(t)
+
+	err := db.Exec(`UPDATE currencies SET decimals = 19 WHERE code = 'USD'`).Error
+	if !errors.Is(err, gorm.ErrCheckConstraintViolated) {
+		t.Fatalf("decimals: got %v, want %v", err, gorm.ErrCheckConstraintViolated)
+	}
+	err = db.Exec(`UPDATE currencies SET min_amount = -1 WHERE code = 'USD'`).Error
+	if !errors.Is(err, gorm.ErrCheckConstraintViolated) {
+		t.Fatalf("min_amount: got %v, want %v", err, gorm.ErrCheckConstraintViolated)
+	}
+}
+
+// Миграция ограничений сводит дубликаты кошельков, оставшиеся от схемы без уникального индекса
+func TestConstraintsMigrationMergesDuplicates(t *testing.T) {
+	db, m := testDB(t)
+	ctx := context.Background()
+	if err := m.To(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	userID := createUser(t, db)
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+	for i, balance := range []string{"1.5", "2.25"} {
+		if err := db.Exec(insertWalletQuery, ids[i], userID, "USD", balance).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Остается кошелек с наименьшим id (uuid сравниваются побайтно, как строки)
+	kept := ids[0]
+	if ids[1].String() < kept.String() {
+		kept = ids[1]
+	}
+	var wallets []UserWallet
+	if err := db.Where("user_id = ? AND currency = ?", userID, "USD").Find(&wallets).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(wallets) != 1 || wallets[0].ID != kept || wallets[0].Balance.String() != "3.75" {
+		t.Fatalf("got %+v, want one USD wallet %s with 3.75", wallets, kept)
+	}
+}
+
+// Схема, которую создавал gorm AutoMigrate до версионных миграций
+const baselineSchema = `
+CREATE TABLE users (
+    id uuid PRIMARY KEY,
+    username text CONSTRAINT uni_users_username UNIQUE,
+    email text CONSTRAINT uni_users_email UNIQUE,
+    pass_hash bytea
+);
+CREATE TABLE user_wallets (
+    id uuid PRIMARY KEY,
+    user_id uuid,
+    currency text,
+    balance real,
+    CONSTRAINT fk_users_balances FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX idx_user_wallets_user_id ON user_wallets (user_id);
+CREATE TABLE exchange_rates (currency text PRIMARY KEY, rate_to_usd real);
+`
+
+// Миграции доводят базу исходной версии до текущей схемы без потери данных
+func TestMigrationsUpgradeBaseline(t *testing.T) {
+	db, sqlDB := openEmptyDB(t)
+	ctx := context.Background()
+	if err := db.Exec(baselineSchema).Error; err != nil {
+		t.Fatal(err)
+	}
+	oldUser := uuid.New()
+	if err := db.Exec(`INSERT INTO users (id, username, email, pass_hash) VALUES ($1, 'old', 'old@example.com', 'hash')`, oldUser).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(insertWalletQuery, uuid.New(), oldUser, "USD", 12.5).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(`INSERT INTO exchange_rates VALUES ('EUR', 0.9)`).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewMigrator(discardLog, sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// Как при запуске: справочник дополняется после миграций
+	if err := seedCurrencies(db); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Storage{log: discardLog, db: db}
+	name := uuid.NewString()[:8]
+	newUser := uuid.New()
+	err = s.CreateUser(ctx, models.User{ID: newUser, Username: name, Email: name + "@example.com", PassHash: []byte("hash"), Language: "en"})
+	if err != nil {
+		t.Fatalf("CreateUser after upgrade: %v", err)
+	}
+	if err := s.AddWalletUser(ctx, newUser); err != nil {
+		t.Fatalf("AddWalletUser after upgrade: %v", err)
+	}
+
+	// Валюта существующих кошельков — полноценная валюта справочника, а не выключенная заглушка
+	usd, err := s.Currency(ctx, "USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range []string{models.OperationDeposit, models.OperationWithdraw, models.OperationExchange} {
+		if !usd.Allows(op) {
+			t.Fatalf("USD after upgrade does not allow %s: %+v", op, usd)
+		}
+	}
+	if usd.Name != "US Dollar" || !usd.MinAmount.Equal(decimal.RequireFromString("0.01")) {
+		t.Fatalf("USD after upgrade = %+v, want the default currency", usd)
+	}
+	enabled, err := s.Currencies(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(enabled) != len(defaultCurrencies) {
+		t.Fatalf("enabled currencies = %d, want %d", len(enabled), len(defaultCurrencies))
+	}
+	// Кошельки во всех включенных валютах есть и у старого, и у нового пользователя
+	for _, id := range []uuid.UUID{oldUser, newUser} {
+		var count int64
+		if err := db.Model(&UserWallet{}).Where("user_id = ?", id).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != int64(len(enabled)) {
+			t.Fatalf("user %s has %d wallets, want %d", id, count, len(enabled))
+		}
+	}
+
+	var role string
+	if err := db.Raw(`SELECT role FROM users WHERE id = ?`, oldUser).Scan(&role).Error; err != nil {
+		t.Fatal(err)
+	}
+	if role != models.RoleUser {
+		t.Fatalf("existing user role = %q, want %q", role, models.RoleUser)
+	}
+
+	var column struct {
+		DataType                       string
+		NumericPrecision, NumericScale int
+	}
+	err = db.Raw(`SELECT data_type, numeric_precision, numeric_scale FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'user_wallets' AND column_name = 'balance'`).Scan(&column).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if column.DataType != "numeric" || column.NumericPrecision != 38 || column.NumericScale != 18 {
+		t.Fatalf("balance column = %+v, want numeric(38,18)", column)
+	}
+	var wallet UserWallet
+	if err := db.Where("user_id = ? AND currency = ?", oldUser, "USD").First(&wallet).Error; err != nil {
+		t.Fatal(err)
+	}
+	if wallet.Balance.String() != "12.5" {
+		t.Fatalf("balance = %s, want 12.5", wallet.Balance)
+	}
+
+	var leftover bool
+	if err := db.Raw(`SELECT to_regclass('exchange_rates') IS NOT NULL`).Scan(&leftover).Error; err != nil {
+		t.Fatal(err)
+	}
+	if leftover {
+		t.Fatal("exchange_rates table left after upgrade")
+	}
+}
+
+func TestMigrationsRoundTrip(t *testing.T) {
+	_, m := testDB(t)
+	ctx := context.Background()
+
+	if err := m.To(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, st := range statuses {
+		if st.Applied {
+			t.Fatalf("version %d still applied after migrating to 0", st.Version)
+		}
+	}
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Down(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationsFS, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, mig := range migrations {
+		if mig.Version != int64(i+1) {
+			t.Errorf("migration %s has version %d, want %d", mig.Name, mig.Version, i+1)
+		}
+		if mig.Down == "" {
+			t.Errorf("migration %04d_%s has no down file", mig.Version, mig.Name)
+		}
+	}
+}
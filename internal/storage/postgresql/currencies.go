@@ -0,0 +1,164 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"main/internal/domain/models"
+	"main/internal/storage"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Валюты по умолчанию, которые добавляются в справочник при запуске.
+// Тот же список миграция 0002 добавляет до проверки валют кошельков.
+var defaultCurrencies = []models.Currency{
+	{Code: "USD", Name: "US Dollar", Decimals: 2, Kind: models.KindFiat, MinAmount: decimal.RequireFromString("0.01")},
+	{Code: "RUB", Name: "Российский рубль", Decimals: 2, Kind: models.KindFiat, MinAmount: decimal.RequireFromString("0.01")},
+	{Code: "EUR", Name: "Euro", Decimals: 2, Kind: models.KindFiat, MinAmount: decimal.RequireFromString("0.01")},
+	{Code: "BTC", Name: "Bitcoin", Decimals: 8, Kind: models.KindCrypto, MinAmount: decimal.RequireFromString("0.00001"), ProviderID: "bitcoin"},
+	{Code: "ETH", Name: "Ethereum", Decimals: 18, Kind: models.KindCrypto, MinAmount: decimal.RequireFromString("0.0001"), ProviderID: "ethereum"},
+	{Code: "USDT", Name: "Tether", Decimals: 6, Kind: models.KindCrypto, MinAmount: decimal.RequireFromString("1"), ProviderID: "tether"},
+}
+
+const insertCurrencyQuery = `
+	INSERT INTO currencies (code, name, decimals, enabled, deposit_enabled, withdraw_enabled, exchange_enabled, kind, min_amount, provider_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+const upsertCurrencyQuery = insertCurrencyQuery + `
+	ON CONFLICT (code)
+	DO UPDATE SET name = EXCLUDED.name,
+		decimals = EXCLUDED.decimals,
+		enabled = EXCLUDED.enabled,
+		deposit_enabled = EXCLUDED.deposit_enabled,
+		withdraw_enabled = EXCLUDED.withdraw_enabled,
+		exchange_enabled = EXCLUDED.exchange_enabled,
+		kind = EXCLUDED.kind,
+		min_amount = EXCLUDED.min_amount,
+		provider_id = EXCLUDED.provider_id`
+
+// Создает недостающие основные кошельки в валюте code для всех пользователей
+const backfillWalletsQuery = `
+	INSERT INTO user_wallets (id, user_id, currency, balance)
+	SELECT gen_random_uuid(), u.id, $1, 0
+	FROM users u
+	WHERE NOT EXISTS (
+		SELECT 1 FROM user_wallets w WHERE w.user_id = u.id AND w.currency = $1 AND w.name = ''
+	)
+	ON CONFLICT (user_id, currency) WHERE name = '' DO NOTHING`
+
+// seedCurrencies добавляет в справочник недостающие валюты по умолчанию.
+// Уже существующие записи (в том числе отключенные администратором) не изменяются.
+func seedCurrencies(db *gorm.DB) error {
+	for _, c := range defaultCurrencies {
+		c.Enabled, c.DepositEnabled, c.WithdrawEnabled, c.ExchangeEnabled = true, true, true, true
+		res := db.Exec(insertCurrencyQuery+` ON CONFLICT (code) DO NOTHING`, currencyArgs(c)...)
+		if res.Error != nil {
+			return fmt.Errorf("Ошибка добавления валюты %s: %w", c.Code, res.Error)
+		}
+		if res.RowsAffected > 0 {
+			if err := backfillWallets(db, c.Code); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func currencyArgs(c models.Currency) []any {
+	if c.Kind == "" {
+		c.Kind = models.KindFiat
+	}
+	return []any{c.Code, c.Name, c.Decimals, c.Enabled, c.DepositEnabled, c.WithdrawEnabled,
+		c.ExchangeEnabled, c.Kind, c.MinAmount, c.ProviderID}
+}
+
+// enabledCurrencyCodes возвращает коды включенных валют
+func enabledCurrencyCodes(db *gorm.DB) ([]string, error) {
+	var codes []string
+	if err := db.Model(&models.Currency{}).Where("enabled").Order("code").Pluck("code", &codes).Error; err != nil {
+		return nil, fmt.Errorf("Ошибка получения списка валют: %w", err)
+	}
+	return codes, nil
+}
+
+// Currencies возвращает справочник валют
+func (s *Storage) Currencies(ctx context.Context, onlyEnabled bool) ([]models.Currency, error) {
+	db := s.read(ctx, uuid.Nil)
+	query := db.Order("code")
+	if onlyEnabled {
+		query = query.Where("enabled")
+	}
+
+	var currencies []models.Currency
+	if err := query.Find(&currencies).Error; err != nil {
+		return nil, fmt.Errorf("Ошибка получения списка валют: %w", err)
+	}
+	return currencies, nil
+}
+
+// SaveCurrency добавляет или обновляет валюту.
+// Если валюта включена, всем пользователям создаются недостающие кошельки.
+func (s *Storage) SaveCurrency(ctx context.Context, currency models.Currency) (models.Currency, error) {
+	s.wrote(uuid.Nil)
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		tx := s.conn(ctx)
+		if err := tx.Exec(upsertCurrencyQuery, currencyArgs(currency)...).Error; err != nil {
+			return fmt.Errorf("Ошибка сохранения валюты %s: %w", currency.Code, err)
+		}
+		if currency.Enabled {
+			return backfillWallets(tx, currency.Code)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Currency{}, err
+	}
+
+	return s.Currency(ctx, currency.Code)
+}
+
+// SetCurrencyEnabled включает или отключает валюту
+func (s *Storage) SetCurrencyEnabled(ctx context.Context, code string, enabled bool) (models.Currency, error) {
+	s.wrote(uuid.Nil)
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		tx := s.conn(ctx)
+		res := tx.Model(&models.Currency{}).Where("code = ?", code).Update("enabled", enabled)
+		if res.Error != nil {
+			return fmt.Errorf("Ошибка обновления валюты %s: %w", code, res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("%w: %s", storage.ErrCurrencyNotFound, code)
+		}
+		if enabled {
+			return backfillWallets(tx, code)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Currency{}, err
+	}
+
+	return s.Currency(ctx, code)
+}
+
+// Currency возвращает валюту справочника
+func (s *Storage) Currency(ctx context.Context, code string) (models.Currency, error) {
+	var currency models.Currency
+	if err := s.conn(ctx).First(&currency, "code = ?", code).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Currency{}, fmt.Errorf("%w: %s", storage.ErrCurrencyNotFound, code)
+		}
+		return models.Currency{}, fmt.Errorf("Ошибка получения валюты %s: %w", code, err)
+	}
+	return currency, nil
+}
+
+func backfillWallets(tx *gorm.DB, code string) error {
+	if err := tx.Exec(backfillWalletsQuery, code).Error; err != nil {
+		return fmt.Errorf("Ошибка создания кошельков %s: %w", code, err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Ping проверяет соединение с primary и реплики. Ошибка возвращается только при
+// недоступном primary: недоступная или отстающая реплика сразу исключается из
+// чтения, и запросы идут на primary, поэтому готовность экземпляра от реплик не зависит.
+func (s *Storage) Ping(ctx context.Context) error {
+	const op = "postgresql.Ping"
+
+	db, err := s.db.DB()
+	if err != nil {
+		return fmt.Errorf("Ошибка получения соединения с базой: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("База недоступна: %w", err)
+	}
+
+	if s.replicas != nil && len(s.replicas.nodes) > 0 {
+		if s.replicas.check(ctx) == 0 {
+			s.log.WarnContext(ctx, "no replicas available, reads go to primary",
+				slog.String("op", op),
+				slog.Int("replicas", len(s.replicas.nodes)),
+			)
+		}
+	}
+	return nil
+}
+
+// RatesUpdatedAt возвращает время самой свежей сохраненной котировки.
+// Нулевое время — котировок нет.
+func (s *Storage) RatesUpdatedAt(ctx context.Context) (time.Time, error) {
+	var updated sql.NullTime
+	if err := s.conn(ctx).Raw("SELECT max(updated_at) FROM rate_quotes").Scan(&updated).Error; err != nil {
+		return time.Time{}, fmt.Errorf("Ошибка получения времени обновления курсов: %w", err)
+	}
+	return updated.Time, nil
+}
@@ -0,0 +1,360 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"main/internal/lib/logger/sl"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// Ключ pg_advisory_lock, под которым выполняются миграции.
+// Реплики, стартующие одновременно, ждут друг друга, а не накатывают схему параллельно.
+const migrationLockKey int64 = 0x77616c6c6574 // "wallet"
+
+const createMigrationsTableQuery = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    bigint PRIMARY KEY,
+		name       text NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`
+
+// Migration — версия схемы: пара файлов NNNN_name.up.sql и NNNN_name.down.sql
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus — состояние версии в базе
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator накатывает и откатывает встроенные в бинарник SQL-миграции
+type Migrator struct {
+	log        *slog.Logger
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator читает миграции из встроенных файлов
+func NewMigrator(log *slog.Logger, db *sql.DB) (*Migrator, error) {
+	const op = "storage.NewMigrator"
+
+	migrations, err := loadMigrations(migrationsFS, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &Migrator{log: log, db: db, migrations: migrations}, nil
+}
+
+// OpenMigrator подключается к базе по DSN. Используется подкомандой migrate,
+// которой не нужен весь Storage (загрузка курсов, справочник валют).
+func OpenMigrator(log *slog.Logger, storagePath string) (*Migrator, func() error, error) {
+	const op = "storage.OpenMigrator"
+
+	db, err := sql.Open("pgx", storagePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	m, err := NewMigrator(log, db)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return m, db.Close, nil
+}
+
+// Latest возвращает последнюю известную бинарнику версию
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up накатывает все недостающие миграции
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.Latest())
+}
+
+// Down откатывает последнюю примененную миграцию
+func (m *Migrator) Down(ctx context.Context) error {
+	const op = "storage.Migrator.Down"
+
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		current := currentVersion(applied)
+		if current == 0 {
+			m.log.Info("nothing to roll back", slog.String("op", op))
+			return nil
+		}
+		if m.find(current) == nil {
+			return fmt.Errorf("%s: Версия %d в базе неизвестна этой сборке", op, current)
+		}
+		return m.migrateTo(ctx, conn, applied, m.previous(current))
+	})
+}
+
+// To приводит схему к версии version: накатывает недостающие миграции
+// или откатывает примененные версии выше указанной. Версия 0 — пустая схема.
+func (m *Migrator) To(ctx context.Context, version int64) error {
+	const op = "storage.Migrator.To"
+
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("%s: Неизвестная версия миграции %d", op, version)
+	}
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return m.migrateTo(ctx, conn, applied, version)
+	})
+}
+
+// Status возвращает состояние всех известных миграций. Версии, примененные
+// в базе, но отсутствующие в бинарнике (база новее кода), тоже попадают в список.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	const op = "storage.Migrator.Status"
+
+	var result []MigrationStatus
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		for _, mig := range m.migrations {
+			st := MigrationStatus{Version: mig.Version, Name: mig.Name}
+			if a, ok := applied[mig.Version]; ok {
+				st.Applied, st.AppliedAt = true, a.AppliedAt
+				delete(applied, mig.Version)
+			}
+			result = append(result, st)
+		}
+		for _, a := range applied {
+			result = append(result, a)
+		}
+		sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+		return nil
+	})
+	return result, err
+}
+
+func (m *Migrator) migrateTo(ctx context.Context, conn *sql.Conn, applied map[int64]MigrationStatus, version int64) error {
+	// Откатываем лишние версии, начиная с последней
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mig := m.migrations[i]
+		if mig.Version <= version {
+			break
+		}
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		if err := m.apply(ctx, conn, mig, false); err != nil {
+			return err
+		}
+	}
+	// Накатываем недостающие по возрастанию
+	for _, mig := range m.migrations {
+		if mig.Version > version {
+			break
+		}
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		if err := m.apply(ctx, conn, mig, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply выполняет одну миграцию и запись о ней в schema_migrations в одной транзакции
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, mig Migration, up bool) error {
+	const op = "storage.Migrator.apply"
+	log := m.log.With(
+		slog.String("op", op),
+		slog.Int64("version", mig.Version),
+		slog.String("name", mig.Name),
+		slog.Bool("up", up),
+	)
+
+	if !up && strings.TrimSpace(mig.Down) == "" {
+		return fmt.Errorf("%s: Миграция %04d_%s не поддерживает откат", op, mig.Version, mig.Name)
+	}
+
+	script, record, args := mig.Up, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, []any{mig.Version, mig.Name}
+	if !up {
+		script, record, args = mig.Down, `DELETE FROM schema_migrations WHERE version = $1`, []any{mig.Version}
+	}
+
+	start := time.Now()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("Ошибка миграции %04d_%s: %w", mig.Version, mig.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Ошибка миграции %04d_%s: %w", mig.Version, mig.Name, err)
+	}
+	log.Info("migration applied", slog.Duration("duration", time.Since(start)))
+	return nil
+}
+
+// locked выполняет fn на отдельном соединении под advisory lock
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	const op = "storage.Migrator.locked"
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return fmt.Errorf("%s: Не удалось получить блокировку миграций: %w", op, err)
+	}
+	defer func() {
+		// Блокировка сессионная: снимаем ее даже при отмене ctx, иначе она доживет до закрытия соединения в пуле
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey); err != nil {
+			m.log.Error("failed to release migration lock", slog.String("op", op), sl.Err(err))
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, createMigrationsTableQuery); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return fn(conn)
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// previous возвращает версию, предшествующую version, или 0
+func (m *Migrator) previous(version int64) int64 {
+	var prev int64
+	for _, mig := range m.migrations {
+		if mig.Version >= version {
+			break
+		}
+		prev = mig.Version
+	}
+	return prev
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]MigrationStatus, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка чтения schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]MigrationStatus)
+	for rows.Next() {
+		st := MigrationStatus{Applied: true}
+		if err := rows.Scan(&st.Version, &st.Name, &st.AppliedAt); err != nil {
+			return nil, fmt.Errorf("Ошибка чтения schema_migrations: %w", err)
+		}
+		applied[st.Version] = st
+	}
+	return applied, rows.Err()
+}
+
+func currentVersion(applied map[int64]MigrationStatus) int64 {
+	var current int64
+	for v := range applied {
+		current = max(current, v)
+	}
+	return current
+}
+
+// loadMigrations собирает пары up/down из каталога dir, отсортированные по версии
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		file := e.Name()
+		base, direction, ok := strings.Cut(strings.TrimSuffix(file, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("Некорректное имя файла миграции %s", file)
+		}
+		num, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("Некорректное имя файла миграции %s", file)
+		}
+		version, err := strconv.ParseInt(num, 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("Некорректная версия в имени файла миграции %s", file)
+		}
+
+		body, err := fs.ReadFile(fsys, path.Join(dir, file))
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: name}
+			byVersion[version] = mig
+		} else if mig.Name != name {
+			return nil, fmt.Errorf("Разные имена миграции %d: %s и %s", version, mig.Name, name)
+		}
+		if direction == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, errors.New("Отсутствует up-файл миграции " + strconv.FormatInt(mig.Version, 10))
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS webhook_attempts;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+DROP TABLE IF EXISTS outbox_events;
+DROP TABLE IF EXISTS quarantined_quotes;
+DROP TABLE IF EXISTS currencies;
+DROP TABLE IF EXISTS rate_quotes;
+DROP TABLE IF EXISTS user_wallets;
+DROP TABLE IF EXISTS users;
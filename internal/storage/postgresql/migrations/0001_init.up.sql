@@ -0,0 +1,132 @@
+-- Начальная схема. Повторяет таблицы, которые раньше создавал gorm AutoMigrate.
+-- На уже развернутых базах существующие таблицы не пересоздаются: в конце файла
+-- их схема доводится до текущей (недостающие столбцы, точный тип баланса).
+
+CREATE TABLE IF NOT EXISTS users (
+    id        uuid PRIMARY KEY,
+    username  text UNIQUE,
+    email     text UNIQUE,
+    pass_hash bytea,
+    language  text,
+    role      text DEFAULT 'user'
+);
+
+CREATE TABLE IF NOT EXISTS user_wallets (
+    id       uuid PRIMARY KEY,
+    user_id  uuid,
+    currency text,
+    balance  numeric(38,18),
+    CONSTRAINT fk_users_balances FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_wallets_user_id ON user_wallets (user_id);
+
+CREATE TABLE IF NOT EXISTS rate_quotes (
+    base       text,
+    quote      text,
+    bid        numeric(38,18),
+    ask        numeric(38,18),
+    source     text,
+    updated_at timestamptz,
+    PRIMARY KEY (base, quote)
+);
+
+CREATE TABLE IF NOT EXISTS currencies (
+    code             text PRIMARY KEY,
+    name             text,
+    decimals         bigint DEFAULT 2,
+    enabled          boolean DEFAULT true,
+    deposit_enabled  boolean DEFAULT true,
+    withdraw_enabled boolean DEFAULT true,
+    exchange_enabled boolean DEFAULT true,
+    kind             text DEFAULT 'fiat',
+    min_amount       numeric(38,18) DEFAULT 0,
+    provider_id      text
+);
+
+CREATE TABLE IF NOT EXISTS quarantined_quotes (
+    id           bigserial PRIMARY KEY,
+    base         text,
+    quote        text,
+    bid          numeric(38,18),
+    ask          numeric(38,18),
+    source       text,
+    previous_mid numeric(38,18) DEFAULT 0,
+    reason       text,
+    status       text DEFAULT 'pending',
+    created_at   timestamptz,
+    resolved_at  timestamptz,
+    resolved_by  text
+);
+CREATE INDEX IF NOT EXISTS idx_quarantine_pair ON quarantined_quotes (base, quote);
+CREATE INDEX IF NOT EXISTS idx_quarantined_quotes_status ON quarantined_quotes (status);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id           bigserial PRIMARY KEY,
+    aggregate_id uuid,
+    type         text,
+    payload      jsonb,
+    created_at   timestamptz,
+    published_at timestamptz,
+    attempts     bigint DEFAULT 0,
+    last_error   text
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate_id ON outbox_events (aggregate_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_published_at ON outbox_events (published_at);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id          uuid PRIMARY KEY,
+    user_id     uuid,
+    url         text,
+    event_types text,
+    secret      text,
+    active      boolean DEFAULT true,
+    created_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_webhooks_user_id ON webhooks (user_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id               bigserial PRIMARY KEY,
+    webhook_id       uuid,
+    user_id          uuid,
+    event_id         bigint,
+    event_type       text,
+    payload          jsonb,
+    status           text DEFAULT 'pending',
+    attempts         bigint DEFAULT 0,
+    next_attempt_at  timestamptz,
+    last_status_code bigint,
+    last_error       text,
+    created_at       timestamptz,
+    delivered_at     timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_user_id ON webhook_deliveries (user_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries (status);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_next_attempt_at ON webhook_deliveries (next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+    id          bigserial PRIMARY KEY,
+    delivery_id bigint,
+    attempt     bigint,
+    status_code bigint,
+    error       text,
+    duration_ms bigint,
+    created_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_attempts_delivery_id ON webhook_attempts (delivery_id);
+
+-- Базы, созданные AutoMigrate ранних версий
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS language text,
+    ADD COLUMN IF NOT EXISTS role text DEFAULT 'user';
+
+ALTER TABLE user_wallets
+    ALTER COLUMN balance TYPE numeric(38,18) USING balance::numeric;
+
+ALTER TABLE currencies
+    ADD COLUMN IF NOT EXISTS kind text DEFAULT 'fiat',
+    ADD COLUMN IF NOT EXISTS min_amount numeric(38,18) DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS provider_id text;
+
+-- Курсы к USD заменены котировками пар rate_quotes, которые загружаются из источников
+DROP TABLE IF EXISTS exchange_rates;
@@ -0,0 +1,26 @@
+ALTER TABLE rate_quotes
+    DROP CONSTRAINT IF EXISTS ck_rate_quotes_positive,
+    DROP CONSTRAINT IF EXISTS ck_rate_quotes_pair,
+    ALTER COLUMN updated_at DROP NOT NULL,
+    ALTER COLUMN ask DROP NOT NULL,
+    ALTER COLUMN bid DROP NOT NULL;
+
+ALTER TABLE currencies
+    DROP CONSTRAINT IF EXISTS ck_currencies_min_amount_non_negative,
+    DROP CONSTRAINT IF EXISTS ck_currencies_decimals,
+    ALTER COLUMN min_amount DROP NOT NULL,
+    ALTER COLUMN decimals DROP NOT NULL,
+    ALTER COLUMN name DROP NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_user_wallets_user_id ON user_wallets (user_id);
+
+ALTER TABLE user_wallets
+    DROP CONSTRAINT IF EXISTS ck_user_wallets_balance_non_negative,
+    DROP CONSTRAINT IF EXISTS uq_user_wallets_user_currency,
+    DROP CONSTRAINT IF EXISTS fk_user_wallets_currency,
+    DROP CONSTRAINT IF EXISTS fk_user_wallets_user,
+    ALTER COLUMN balance DROP DEFAULT,
+    ALTER COLUMN balance DROP NOT NULL,
+    ALTER COLUMN currency DROP NOT NULL,
+    ALTER COLUMN user_id DROP NOT NULL,
+    ADD CONSTRAINT fk_users_balances FOREIGN KEY (user_id) REFERENCES users (id);
@@ -0,0 +1,84 @@
+-- Ограничения целостности кошельков и курсов.
+
+-- Точный тип баланса до сведения дубликатов, чтобы суммы не теряли копейки.
+-- Нужен и здесь: на базах, где 0001 применили раньше, баланс мог остаться float.
+ALTER TABLE user_wallets
+    ALTER COLUMN balance TYPE numeric(38,18) USING balance::numeric;
+
+-- Кошельки удаленных пользователей
+DELETE FROM user_wallets w WHERE NOT EXISTS (SELECT 1 FROM users u WHERE u.id = w.user_id);
+
+-- Дубликаты (user_id, currency), созданные гонкой FirstOrCreate. Времени создания у кошельков
+-- нет, поэтому балансы сводим в кошелек с наименьшим id, остальные удаляем
+WITH ranked AS (
+    SELECT id, user_id, currency,
+           row_number() OVER (PARTITION BY user_id, currency ORDER BY id) AS rn,
+           sum(coalesce(balance, 0)) OVER (PARTITION BY user_id, currency) AS total
+    FROM user_wallets
+)
+UPDATE user_wallets w SET balance = r.total
+FROM ranked r
+WHERE w.id = r.id AND r.rn = 1;
+
+DELETE FROM user_wallets w
+USING user_wallets d
+WHERE w.user_id = d.user_id AND w.currency = d.currency AND w.id > d.id;
+
+-- Валюты по умолчанию (как defaultCurrencies в currencies.go). На базах до справочника
+-- он здесь еще пуст, а seedCurrencies выполняется только после миграций и существующие
+-- записи не меняет: без этого валюты кошельков стали бы выключенными заглушками ниже
+INSERT INTO currencies (code, name, decimals, enabled, deposit_enabled, withdraw_enabled, exchange_enabled, kind, min_amount, provider_id)
+VALUES
+    ('USD', 'US Dollar', 2, true, true, true, true, 'fiat', 0.01, ''),
+    ('RUB', 'Российский рубль', 2, true, true, true, true, 'fiat', 0.01, ''),
+    ('EUR', 'Euro', 2, true, true, true, true, 'fiat', 0.01, ''),
+    ('BTC', 'Bitcoin', 8, true, true, true, true, 'crypto', 0.00001, 'bitcoin'),
+    ('ETH', 'Ethereum', 18, true, true, true, true, 'crypto', 0.0001, 'ethereum'),
+    ('USDT', 'Tether', 6, true, true, true, true, 'crypto', 1, 'tether')
+ON CONFLICT (code) DO NOTHING;
+
+-- Кошельки в добавленных валютах для существующих пользователей, как делает seedCurrencies
+INSERT INTO user_wallets (id, user_id, currency, balance)
+SELECT gen_random_uuid(), u.id, c.code, 0
+FROM users u CROSS JOIN currencies c
+WHERE c.enabled
+  AND NOT EXISTS (SELECT 1 FROM user_wallets w WHERE w.user_id = u.id AND w.currency = c.code);
+
+-- Валюты кошельков, которых нет в справочнике, добавляем выключенными
+INSERT INTO currencies (code, name, enabled, deposit_enabled, withdraw_enabled, exchange_enabled)
+SELECT DISTINCT w.currency, w.currency, false, false, false, false
+FROM user_wallets w
+WHERE w.currency IS NOT NULL
+ON CONFLICT (code) DO NOTHING;
+
+UPDATE user_wallets SET balance = 0 WHERE balance IS NULL;
+
+ALTER TABLE user_wallets
+    DROP CONSTRAINT IF EXISTS fk_users_balances,
+    ALTER COLUMN user_id SET NOT NULL,
+    ALTER COLUMN currency SET NOT NULL,
+    ALTER COLUMN balance SET NOT NULL,
+    ALTER COLUMN balance SET DEFAULT 0,
+    ADD CONSTRAINT fk_user_wallets_user FOREIGN KEY (user_id) REFERENCES users (id)
+        ON UPDATE CASCADE ON DELETE CASCADE,
+    ADD CONSTRAINT fk_user_wallets_currency FOREIGN KEY (currency) REFERENCES currencies (code)
+        ON UPDATE CASCADE,
+    ADD CONSTRAINT uq_user_wallets_user_currency UNIQUE (user_id, currency),
+    ADD CONSTRAINT ck_user_wallets_balance_non_negative CHECK (balance >= 0);
+
+-- Уникальный индекс (user_id, currency) покрывает поиск по user_id
+DROP INDEX IF EXISTS idx_user_wallets_user_id;
+
+ALTER TABLE currencies
+    ALTER COLUMN name SET NOT NULL,
+    ALTER COLUMN decimals SET NOT NULL,
+    ALTER COLUMN min_amount SET NOT NULL,
+    ADD CONSTRAINT ck_currencies_decimals CHECK (decimals BETWEEN 0 AND 18),
+    ADD CONSTRAINT ck_currencies_min_amount_non_negative CHECK (min_amount >= 0);
+
+ALTER TABLE rate_quotes
+    ALTER COLUMN bid SET NOT NULL,
+    ALTER COLUMN ask SET NOT NULL,
+    ALTER COLUMN updated_at SET NOT NULL,
+    ADD CONSTRAINT ck_rate_quotes_pair CHECK (base <> quote),
+    ADD CONSTRAINT ck_rate_quotes_positive CHECK (bid > 0 AND ask >= bid);
@@ -0,0 +1,28 @@
+-- Средства дополнительных кошельков возвращаются на основной кошелек валюты
+INSERT INTO user_wallets (id, user_id, currency, balance)
+SELECT gen_random_uuid(), s.user_id, s.currency, 0
+FROM (SELECT DISTINCT user_id, currency FROM user_wallets WHERE name <> '') s
+WHERE NOT EXISTS (
+    SELECT 1 FROM user_wallets m WHERE m.user_id = s.user_id AND m.currency = s.currency AND m.name = ''
+);
+
+UPDATE user_wallets m SET balance = m.balance + s.total
+FROM (
+    SELECT user_id, currency, sum(balance) AS total
+    FROM user_wallets
+    WHERE name <> ''
+    GROUP BY user_id, currency
+) s
+WHERE m.user_id = s.user_id AND m.currency = s.currency AND m.name = '';
+
+DELETE FROM user_wallets WHERE name <> '';
+
+DROP INDEX IF EXISTS idx_user_wallets_user_id;
+DROP INDEX IF EXISTS uq_user_wallets_name;
+DROP INDEX IF EXISTS uq_user_wallets_main;
+
+ALTER TABLE user_wallets
+    DROP CONSTRAINT IF EXISTS ck_user_wallets_main_not_archived,
+    ADD CONSTRAINT uq_user_wallets_user_currency UNIQUE (user_id, currency),
+    DROP COLUMN IF EXISTS archived,
+    DROP COLUMN IF EXISTS name;
@@ -0,0 +1,15 @@
+-- Дополнительные кошельки с названием. Основной кошелек валюты — без названия,
+-- он по-прежнему один на пользователя и валюту; названия дополнительных кошельков
+-- уникальны среди неархивных кошельков пользователя без учета регистра.
+
+ALTER TABLE user_wallets
+    ADD COLUMN IF NOT EXISTS name text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS archived boolean NOT NULL DEFAULT false,
+    DROP CONSTRAINT IF EXISTS uq_user_wallets_user_currency,
+    ADD CONSTRAINT ck_user_wallets_main_not_archived CHECK (name <> '' OR NOT archived);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_user_wallets_main ON user_wallets (user_id, currency) WHERE name = '';
+CREATE UNIQUE INDEX IF NOT EXISTS uq_user_wallets_name ON user_wallets (user_id, lower(name)) WHERE name <> '' AND NOT archived;
+
+-- Частичные индексы не покрывают выборку всех кошельков пользователя
+CREATE INDEX IF NOT EXISTS idx_user_wallets_user_id ON user_wallets (user_id);
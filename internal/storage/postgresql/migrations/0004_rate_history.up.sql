@@ -0,0 +1,18 @@
+-- История котировок для оценки по курсам на прошедшую дату. Каждая сохраненная
+-- котировка добавляется сюда; rate_quotes по-прежнему хранит только последнюю.
+CREATE TABLE IF NOT EXISTS rate_history (
+    base       text NOT NULL,
+    quote      text NOT NULL,
+    bid        numeric(38,18) NOT NULL,
+    ask        numeric(38,18) NOT NULL,
+    source     text NOT NULL DEFAULT '',
+    updated_at timestamptz NOT NULL,
+    PRIMARY KEY (base, quote, updated_at),
+    CONSTRAINT ck_rate_history_pair CHECK (base <> quote),
+    CONSTRAINT ck_rate_history_positive CHECK (bid > 0 AND ask >= bid)
+);
+
+-- Текущие котировки становятся началом истории
+INSERT INTO rate_history (base, quote, bid, ask, source, updated_at)
+SELECT base, quote, bid, ask, coalesce(source, ''), updated_at FROM rate_quotes
+ON CONFLICT DO NOTHING;
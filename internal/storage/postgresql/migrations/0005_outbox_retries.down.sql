@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_outbox_events_pending;
+
+ALTER TABLE outbox_events
+    DROP COLUMN IF EXISTS dead_at,
+    DROP COLUMN IF EXISTS next_attempt_at;
@@ -0,0 +1,10 @@
+-- Повторные попытки публикации событий с задержкой и dead letter.
+-- Событие с next_attempt_at в будущем задерживает и следующие события своего
+-- пользователя; событие с dead_at больше не публикуется.
+ALTER TABLE outbox_events
+    ADD COLUMN IF NOT EXISTS next_attempt_at timestamptz,
+    ADD COLUMN IF NOT EXISTS dead_at timestamptz;
+
+-- Выборка ожидающих событий и проверка, не задержан ли их пользователь
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events (aggregate_id, id)
+    WHERE published_at IS NULL AND dead_at IS NULL;
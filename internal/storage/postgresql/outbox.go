@@ -0,0 +1,132 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"main/internal/domain/models"
+	"main/internal/lib/text"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Длина сохраняемого текста ошибки публикации, символов
+const maxOutboxError = 1000
+
+// Пространство ключей advisory-блокировок записи событий (первый ключ пары)
+const outboxLockClass = 1
+
+// Блокировка записи событий пользователя до конца транзакции
+const lockAggregateQuery = `SELECT pg_advisory_xact_lock($1, hashtext($2))`
+
+// Ожидающие события, кроме событий пользователей, чье более раннее (или это же)
+// событие ждет повторной попытки: иначе они заняли бы всю пачку, а опубликовать
+// их до этой попытки все равно нельзя
+const pendingEventsQuery = `
+	SELECT * FROM outbox_events e
+	WHERE e.published_at IS NULL AND e.dead_at IS NULL
+		AND NOT EXISTS (
+			SELECT 1 FROM outbox_events b
+			WHERE b.aggregate_id = e.aggregate_id
+				AND b.published_at IS NULL AND b.dead_at IS NULL
+				AND b.id <= e.id AND b.next_attempt_at > $1
+		)
+	ORDER BY e.id
+	LIMIT $2`
+
+// writeEvents записывает события в outbox в рамках транзакции tx
+// и ставит их в очередь доставки подписанным webhook
+func writeEvents(tx *gorm.DB, events ...models.OutboxEvent) error {
+	if err := lockAggregates(tx, events); err != nil {
+		return err
+	}
+	for i := range events {
+		if err := tx.Create(&events[i]).Error; err != nil {
+			return fmt.Errorf("Ошибка записи события %s: %w", events[i].Type, err)
+		}
+	}
+	return enqueueWebhooks(tx, events...)
+}
+
+// lockAggregates сериализует запись событий каждого пользователя до конца транзакции.
+// Номер события выдается при вставке, а видно оно становится при фиксации, поэтому
+// без блокировки две транзакции одного пользователя могли бы зафиксироваться не
+// в порядке номеров, и Relay опубликовал бы более позднее событие раньше. С
+// блокировкой следующая транзакция получает номер только после фиксации предыдущей.
+// Блокировки берутся в порядке идентификаторов, чтобы не ждать друг друга по кругу.
+func lockAggregates(tx *gorm.DB, events []models.OutboxEvent) error {
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.AggregateID.String())
+	}
+	slices.Sort(ids)
+	for _, id := range slices.Compact(ids) {
+		if err := tx.Exec(lockAggregateQuery, outboxLockClass, id).Error; err != nil {
+			return fmt.Errorf("Ошибка блокировки событий пользователя %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// AppendEvents записывает события в outbox
+func (s *Storage) AppendEvents(ctx context.Context, events []models.OutboxEvent) error {
+	// События ставят в очередь доставки webhook их пользователей
+	for _, e := range events {
+		s.wrote(e.AggregateID)
+	}
+	return s.WithinTx(ctx, func(ctx context.Context) error {
+		return writeEvents(s.conn(ctx), events...)
+	})
+}
+
+// PendingEvents возвращает готовые к публикации события в порядке записи
+func (s *Storage) PendingEvents(ctx context.Context, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := s.conn(ctx).Raw(pendingEventsQuery, time.Now(), limit).Scan(&events).Error
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка получения событий outbox: %w", err)
+	}
+	return events, nil
+}
+
+// MarkPublished отмечает события опубликованными
+func (s *Storage) MarkPublished(ctx context.Context, ids []uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	err := s.conn(ctx).
+		Model(&models.OutboxEvent{}).
+		Where("id IN ?", ids).
+		Update("published_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("Ошибка обновления событий outbox: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed сохраняет ошибку публикации события и откладывает его до retryAt
+func (s *Storage) MarkFailed(ctx context.Context, id uint64, cause error, retryAt time.Time) error {
+	return s.markFailed(ctx, id, cause, "next_attempt_at", retryAt)
+}
+
+// MarkDead сохраняет ошибку публикации и переносит событие в dead letter
+func (s *Storage) MarkDead(ctx context.Context, id uint64, cause error) error {
+	return s.markFailed(ctx, id, cause, "dead_at", time.Now())
+}
+
+func (s *Storage) markFailed(ctx context.Context, id uint64, cause error, column string, at time.Time) error {
+	msg := text.Truncate(cause.Error(), maxOutboxError)
+	err := s.conn(ctx).
+		Model(&models.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"attempts":   gorm.Expr("attempts + 1"),
+			"last_error": msg,
+			column:       at,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("Ошибка обновления события outbox %d: %w", id, err)
+	}
+	return nil
+}
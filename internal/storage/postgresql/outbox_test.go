@@ -0,0 +1,62 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"main/internal/domain/models"
+	"testing"
+	"time"
+)
+
+// Вторая транзакция пользователя получает номер события только после фиксации
+// первой, поэтому порядок номеров совпадает с порядком фиксации
+func TestWriteEventsSerializesUser(t *testing.T) {
+	db, _ := testDB(t)
+	s := &Storage{log: discardLog, db: db}
+	userID := createUser(t, db)
+	event := func(typ string) models.OutboxEvent {
+		return models.OutboxEvent{AggregateID: userID, Type: typ, Payload: []byte(`{}`)}
+	}
+
+	written := make(chan struct{})
+	commit := make(chan struct{})
+	first := make(chan error, 1)
+	go func() {
+		first <- s.WithinTx(context.Background(), func(ctx context.Context) error {
+			if err := writeEvents(s.conn(ctx), event("first")); err != nil {
+				return err
+			}
+			close(written)
+			<-commit
+			return nil
+		})
+	}()
+	select {
+	case <-written:
+	case err := <-first:
+		t.Fatal(err)
+	}
+
+	second := make(chan error, 1)
+	go func() {
+		second <- s.AppendEvents(context.Background(), []models.OutboxEvent{event("second")})
+	}()
+	select {
+	case err := <-second:
+		t.Fatalf("second write finished before the first commit: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	close(commit)
+	if err := errors.Join(<-first, <-second); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := s.PendingEvents(context.Background(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Type != "first" || events[1].Type != "second" {
+		t.Fatalf("events = %+v, want first then second", events)
+	}
+}
@@ -4,348 +4,301 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"main/internal/domain/models"
+	"main/internal/metrics"
 	"main/internal/storage"
-
-	jwt "main/internal/lib/jwt"
+	"strconv"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/shopspring/decimal"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	otelgorm "gorm.io/plugin/opentelemetry/tracing"
 )
 
 type Storage struct {
-	db *gorm.DB
+	log      *slog.Logger
+	db       *gorm.DB
+	replicas *Replicas // nil — чтение только с primary
+}
+
+var _ storage.Repository = (*Storage)(nil)
+
+// Options — параметры пула соединений и реплик
+type Options struct {
+	MaxOpenConns     int // 0 — без ограничения
+	MaxIdleConns     int
+	ConnMaxLifetime  time.Duration // 0 — соединения не пересоздаются
+	ConnMaxIdleTime  time.Duration
+	StatementTimeout time.Duration // statement_timeout сессии, 0 — без ограничения
+
+	Replicas             []string      // DSN реплик для чтения
+	ReplicaMaxLag        time.Duration // Реплика с большим отставанием не используется
+	ReplicaCheckInterval time.Duration // Период проверки отставания
+	ReadYourWrites       time.Duration // Сколько после записи читать данные пользователя с primary
 }
 
-func New(storagePath string) (*Storage, error) {
+func New(log *slog.Logger, storagePath string, opts Options) (*Storage, error) {
 	const op = "storage.New"
 
-	db, err := gorm.Open(postgres.Open(storagePath), &gorm.Config{})
+	db, _, err := open(storagePath, "postgres", opts)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	err = AutoMigrate(db)
-	if err != nil {
-		log.Fatalf("Ошибка миграции: %v", err)
+	if err := migrate(log, db); err != nil {
+		closeDB(db)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	// Обновляем курсы валют при инициализации
-	err = UpdateExchangeRates(db)
-	if err != nil {
-		log.Printf("Ошибка обновления курсов валют: %v", err)
-	} else {
-		log.Println("Курсы валют успешно обновлены.")
+	if err := seedCurrencies(db); err != nil {
+		closeDB(db)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	return &Storage{db: db}, nil
-}
-
-func AutoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(&User{}, &UserWallet{}, &ExchangeRate{})
-}
 
-// SaveUser saves user to db.
-func (s *Storage) SaveUser(ctx context.Context, username, email string, passHash []byte) (string, error) {
-
-	tx := s.db.Begin()
-	id := uuid.New()
-
-	query := `INSERT INTO users (id, username, email, pass_hash) VALUES ($1, $2, $3, $4)`
-	if err := s.db.Exec(query, id, username, email, passHash).Error; err != nil {
-		tx.Rollback()
-		return "", fmt.Errorf("Ошибка создания пользователя: %v", err)
+	s := &Storage{log: log, db: db}
+	if len(opts.Replicas) == 0 {
+		return s, nil
 	}
 
-	err := s.AddWalletUser(ctx, id)
-	if err != nil {
-		tx.Rollback()
-		return "", fmt.Errorf("Ошибка создания кошелька: %v", err)
+	s.replicas = newReplicas(log, opts)
+	for i, dsn := range opts.Replicas {
+		replica, host, err := open(dsn, fmt.Sprintf("postgres_replica_%d", i), opts)
+		if err != nil {
+			s.replicas.close()
+			closeDB(db)
+			return nil, fmt.Errorf("%s: реплика %d: %w", op, i, err)
+		}
+		s.replicas.add(host, replica)
 	}
+	// Реплики начинают обслуживать чтение только после первой проверки отставания
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.replicas.check(ctx)
 
-	tx.Commit()
-	return "success", nil
+	return s, nil
 }
 
-func GetExchangeRates(db *gorm.DB) ([]models.ExchangeRate, error) {
-
-	// Обновляем курсы валют при запросе курсов
-	err := UpdateExchangeRates(db)
+// open подключается к базе по dsn с параметрами пула opts и возвращает
+// соединение и адрес сервера для логов. name различает пулы в метриках.
+func open(dsn string, name string, opts Options) (*gorm.DB, string, error) {
+	cfg, err := pgx.ParseConfig(dsn)
 	if err != nil {
-		log.Printf("Ошибка обновления курсов валют: %v", err)
-	} else {
-		log.Println("Курсы валют успешно обновлены.")
+		return nil, "", err
 	}
-
-	curModel := []models.ExchangeRate{}
-	err = db.Find(&curModel).Error
-	if err != nil {
-		return nil, fmt.Errorf("Ошибка получения списка валют: %v", err)
+	if opts.StatementTimeout > 0 {
+		cfg.RuntimeParams["statement_timeout"] = strconv.FormatInt(opts.StatementTimeout.Milliseconds(), 10)
 	}
 
-	return curModel, nil
-}
-
-func (s *Storage) AddWalletUser(ctx context.Context, idUser uuid.UUID) error {
+	sqlDB := stdlib.OpenDB(*cfg)
+	sqlDB.SetMaxOpenConns(opts.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(opts.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
 
-	currExch, err := GetExchangeRates(s.db)
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{TranslateError: true})
 	if err != nil {
-		return fmt.Errorf("Ошибка получения списка валют: %v", err)
+		sqlDB.Close()
+		return nil, "", err
 	}
 
-	fmt.Printf("Список валют: %v\n", currExch)
-
-	iduuid := make([]uuid.UUID, len(currExch))
-	for i := range currExch {
-		iduuid[i] = uuid.New()
+	if err := metrics.InstrumentDB(db, name); err != nil {
+		sqlDB.Close()
+		return nil, "", err
 	}
-
-	query := `INSERT INTO user_wallets (id, user_id, currency, balance) VALUES ($1, $2, $3, $4)`
-	for i, exchange := range currExch {
-		if err := s.db.Exec(query, iduuid[i], idUser, exchange.Currency, 0).Error; err != nil {
-			return fmt.Errorf("Ошибка создания кошелька %s для пользователя: %v", exchange.Currency, err)
-		}
+	// Значения параметров в спаны не пишем: в запросах есть хеши паролей и секреты webhook
+	if err := db.Use(otelgorm.NewPlugin(otelgorm.WithoutQueryVariables(), otelgorm.WithoutMetrics())); err != nil {
+		sqlDB.Close()
+		return nil, "", err
 	}
-
-	// Логируем успешную вставку
-	log.Printf("Кошельки успешно добавлены для пользователя с ID: %s\n", idUser)
-	return nil
+	return db, fmt.Sprintf("%s:%d", cfg.Host, cfg.Port), nil
 }
 
-func GetBalanceAfterOperation(db *gorm.DB, ctx context.Context, userID uuid.UUID) (map[string]float32, error) {
-	var wallets []models.UserWallet
-	if err := db.Where("user_id = ?", userID).Find(&wallets).Error; err != nil {
-		return nil, fmt.Errorf("Ошибка получения баланса: %v", err)
-	}
-
-	balances := make(map[string]float32)
-	for _, wallet := range wallets {
-		balances[wallet.Currency] = wallet.Balance
+// closeDB закрывает пул соединений db, когда Storage так и не был создан
+func closeDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
 	}
-
-	return balances, nil
 }
 
-func (s *Storage) GetBalance(ctx context.Context, token string) (map[string]float32, error) {
-
-	claims, err := jwt.ValidateToken(token)
+// migrate накатывает недостающие миграции схемы при запуске
+func migrate(log *slog.Logger, db *gorm.DB) error {
+	sqlDB, err := db.DB()
 	if err != nil {
-		return nil, fmt.Errorf("Ошибка в валидации токена: %v", err)
+		return err
 	}
-
-	userID := claims.UserID
-
-	balances, err := GetBalanceAfterOperation(s.db, ctx, userID)
+	m, err := NewMigrator(log, sqlDB)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка получения баланса: %w", err)
+		return err
 	}
-
-	return balances, nil
+	return m.Up(context.Background())
 }
 
-func (s *Storage) Withdraw(ctx context.Context, token string, amount float32, currency string) (string, map[string]float32, error) {
-
-	claims, err := jwt.ValidateToken(token)
-	if err != nil {
-		return "", nil, fmt.Errorf("Ошибка в валидации токена: %v", err)
+// CreateUser добавляет пользователя
+func (s *Storage) CreateUser(ctx context.Context, user models.User) error {
+	if user.Role == "" {
+		user.Role = models.RoleUser
 	}
-
-	currExch, err := GetExchangeRates(s.db)
+	query := `INSERT INTO users (id, username, email, pass_hash, language, role) VALUES ($1, $2, $3, $4, $5, $6)`
+	err := s.conn(ctx).Exec(query, user.ID, user.Username, user.Email, user.PassHash, user.Language, user.Role).Error
 	if err != nil {
-		return "", nil, fmt.Errorf("Ошибка получения списка валют: %v", err)
-	}
-
-	validCurrencies := map[string]bool{}
-	for _, exchange := range currExch {
-		validCurrencies[exchange.Currency] = true
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return storage.ErrUserExists
+		}
+		return fmt.Errorf("Ошибка создания пользователя: %w", err)
 	}
+	return nil
+}
 
-	if !validCurrencies[currency] {
-		return "", nil, fmt.Errorf("Неверная валюта: %s", currency)
-	}
+// AddWalletUser создает пользователю кошельки во всех включенных валютах справочника
+func (s *Storage) AddWalletUser(ctx context.Context, idUser uuid.UUID) error {
+	s.wrote(idUser)
+	return s.WithinTx(ctx, func(ctx context.Context) error {
+		return addWallets(s.conn(ctx), idUser)
+	})
+}
 
-	if amount <= 0 {
-		return "", nil, fmt.Errorf("Сумма должна быть больше нуля, запрашиваемая сумма %.2f", amount)
-	}
+func addWallets(db *gorm.DB, idUser uuid.UUID) error {
 
-	userID := claims.UserID
-	var wallet UserWallet
-	err = s.db.FirstOrCreate(&wallet, UserWallet{UserID: userID, Currency: currency}).Error
+	currencies, err := enabledCurrencyCodes(db)
 	if err != nil {
-		return "", nil, fmt.Errorf("Ошибка получения кошелька: %w", err)
-	}
-
-	if wallet.Balance < amount {
-		return "", nil, fmt.Errorf("недостаточно средств на счете: текущий баланс %.2f %s, запрашиваемая сумма %.2f %s", wallet.Balance, currency, amount, currency)
+		return err
 	}
 
-	wallet.Balance -= amount
-	if err := s.db.Save(&wallet).Error; err != nil {
-		return "", nil, fmt.Errorf("Ошибка обновления баланса: %w", err)
-	}
-	newBalance, err := GetBalanceAfterOperation(s.db, ctx, userID)
-	if err != nil {
-		return "", nil, fmt.Errorf("Ошибка получения баланса: %w", err)
+	query := `INSERT INTO user_wallets (id, user_id, currency, balance) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, currency) WHERE name = '' DO NOTHING`
+	for _, currency := range currencies {
+		if err := db.Exec(query, uuid.New(), idUser, currency, 0).Error; err != nil {
+			if errors.Is(err, gorm.ErrForeignKeyViolated) {
+				return fmt.Errorf("%w: %s", storage.ErrUserNotFound, idUser)
+			}
+			return fmt.Errorf("Ошибка создания кошелька %s для пользователя: %w", currency, err)
+		}
 	}
-
-	return "Withdrawal successful", newBalance, nil
+	return nil
 }
 
-func (s *Storage) Deposit(ctx context.Context, token string, amount float32, currency string) (string, map[string]float32, error) {
+// Создает основной кошелек, только если валюта есть в справочнике: отсутствие строки
+// после вставки означает неизвестную валюту
+const lockWalletInsertQuery = `
+	INSERT INTO user_wallets (id, user_id, currency, balance)
+	SELECT $1, $2, code, 0 FROM currencies WHERE code = $3
+	ON CONFLICT (user_id, currency) WHERE name = '' DO NOTHING`
 
-	claims, err := jwt.ValidateToken(token)
-	if err != nil {
-		return "", nil, fmt.Errorf("Ошибка в валидации токена: %v", err)
+// Создает дополнительный кошелек, если валюта есть в справочнике
+const createWalletQuery = `
+	INSERT INTO user_wallets (id, user_id, currency, balance, name)
+	SELECT $1, $2, code, 0, $4 FROM currencies WHERE code = $3`
+
+// Wallets возвращает кошельки пользователя
+func (s *Storage) Wallets(ctx context.Context, userID uuid.UUID) ([]models.UserWallet, error) {
+	var wallets []models.UserWallet
+	if err := s.read(ctx, userID).Where("user_id = ?", userID).Order("currency, name").Find(&wallets).Error; err != nil {
+		return nil, fmt.Errorf("Ошибка получения кошельков: %w", err)
 	}
+	return wallets, nil
+}
 
-	userID := claims.UserID
-	if amount <= 0 {
-		return "", nil, fmt.Errorf("Сумма должна быть больше нуля")
+// LockWallet возвращает основной кошелек пользователя, создавая его при отсутствии,
+// и блокирует строку (SELECT ... FOR UPDATE) до конца транзакции
+func (s *Storage) LockWallet(ctx context.Context, userID uuid.UUID, currency string) (models.UserWallet, error) {
+	s.wrote(userID)
+	db := s.conn(ctx)
+	if err := db.Exec(lockWalletInsertQuery, uuid.New(), userID, currency).Error; err != nil {
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return models.UserWallet{}, fmt.Errorf("%w: %s", storage.ErrUserNotFound, userID)
+		}
+		return models.UserWallet{}, fmt.Errorf("Ошибка создания кошелька %s: %w", currency, err)
 	}
 
-	currExch, err := GetExchangeRates(s.db)
+	var wallet models.UserWallet
+	err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND currency = ? AND name = ''", userID, currency).
+		First(&wallet).Error
 	if err != nil {
-		return "", nil, fmt.Errorf("Ошибка получения списка валют: %v", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.UserWallet{}, fmt.Errorf("%w: %s", storage.ErrCurrencyNotFound, currency)
+		}
+		return models.UserWallet{}, fmt.Errorf("Ошибка получения кошелька: %w", err)
 	}
+	return wallet, nil
+}
 
-	validCurrencies := map[string]bool{}
-	for _, exchange := range currExch {
-		validCurrencies[exchange.Currency] = true
+// LockWalletByID возвращает кошелек пользователя по идентификатору и блокирует строку
+// до конца транзакции
+func (s *Storage) LockWalletByID(ctx context.Context, userID uuid.UUID, id uuid.UUID) (models.UserWallet, error) {
+	s.wrote(userID)
+	var wallet models.UserWallet
+	err := s.conn(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND user_id = ?", id, userID).
+		First(&wallet).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.UserWallet{}, fmt.Errorf("%w: %s", storage.ErrWalletNotFound, id)
+		}
+		return models.UserWallet{}, fmt.Errorf("Ошибка получения кошелька: %w", err)
 	}
+	return wallet, nil
+}
 
-	if !validCurrencies[currency] {
-		return "", nil, fmt.Errorf("Неверная валюта: %s", currency)
+// CreateWallet создает дополнительный кошелек с названием
+func (s *Storage) CreateWallet(ctx context.Context, wallet models.UserWallet) (models.UserWallet, error) {
+	s.wrote(wallet.UserID)
+	wallet.ID = uuid.New()
+	wallet.Balance = decimal.Zero
+	wallet.Archived = false
+	res := s.conn(ctx).Exec(createWalletQuery, wallet.ID, wallet.UserID, wallet.Currency, wallet.Name)
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, gorm.ErrDuplicatedKey):
+			return models.UserWallet{}, fmt.Errorf("%w: %s", storage.ErrWalletExists, wallet.Name)
+		case errors.Is(res.Error, gorm.ErrForeignKeyViolated):
+			return models.UserWallet{}, fmt.Errorf("%w: %s", storage.ErrUserNotFound, wallet.UserID)
+		}
+		return models.UserWallet{}, fmt.Errorf("Ошибка создания кошелька %s: %w", wallet.Name, res.Error)
 	}
-
-	var wallet UserWallet
-	err = s.db.FirstOrCreate(&wallet, UserWallet{UserID: userID, Currency: currency}).Error
-	if err != nil {
-		return "", nil, fmt.Errorf("Ошибка получения кошелька: %w", err)
+	if res.RowsAffected == 0 {
+		return models.UserWallet{}, fmt.Errorf("%w: %s", storage.ErrCurrencyNotFound, wallet.Currency)
 	}
+	return wallet, nil
+}
 
-	wallet.Balance += amount
-	if err := s.db.Save(&wallet).Error; err != nil {
-		return "", nil, fmt.Errorf("Ошибка обновления баланса: %w", err)
+// SaveWallet сохраняет баланс, название и признак архива кошелька
+func (s *Storage) SaveWallet(ctx context.Context, wallet models.UserWallet) error {
+	s.wrote(wallet.UserID)
+	res := s.conn(ctx).Model(&models.UserWallet{}).Where("id = ?", wallet.ID).Updates(map[string]any{
+		"balance":  wallet.Balance,
+		"name":     wallet.Name,
+		"archived": wallet.Archived,
+	})
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, gorm.ErrCheckConstraintViolated):
+			return fmt.Errorf("%w: %s", storage.ErrInsufficientFunds, wallet.Currency)
+		case errors.Is(res.Error, gorm.ErrDuplicatedKey):
+			return fmt.Errorf("%w: %s", storage.ErrWalletExists, wallet.Name)
+		}
+		return fmt.Errorf("Ошибка обновления баланса %s: %w", wallet.Currency, res.Error)
 	}
-	newBalance, err := GetBalanceAfterOperation(s.db, ctx, userID)
-	if err != nil {
-		return "", nil, fmt.Errorf("Ошибка получения баланса: %w", err)
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", storage.ErrWalletNotFound, wallet.ID)
 	}
-
-	return "Account topped up successfully", newBalance, nil
+	return nil
 }
 
 func (s *Storage) User(ctx context.Context, email string) (models.User, error) {
+	db := s.conn(ctx)
 	var user models.User
-	if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
+	if err := db.Where("email = ?", email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return models.User{}, storage.ErrUserNotFound
 		}
-		return models.User{}, fmt.Errorf("Ошибка получения пользователя: %v", err)
+		return models.User{}, fmt.Errorf("Ошибка получения пользователя: %w", err)
 	}
 	return user, nil
 }
-
-func (s *Storage) GetExchangeRates(ctx context.Context, token string) (string, map[string]float32, error) {
-
-	_, err := jwt.ValidateToken(token)
-	if err != nil {
-		return "", nil, fmt.Errorf("Ошибка в валидации токена: %v", err)
-	}
-
-	// Извлечение всех курсов валют из базы данных
-	var exchangeRates []ExchangeRate
-	if err := s.db.Find(&exchangeRates).Error; err != nil {
-		return "", nil, fmt.Errorf("не удалось получить курсы валют: %w", err)
-	}
-
-	// Формирование карты курсов валют
-	rates := make(map[string]float32)
-	for _, rate := range exchangeRates {
-		rates[rate.Currency] = rate.RateToUSD
-	}
-
-	return "Курсы валют успешно получены", rates, nil
-}
-
-func (s *Storage) ExchangeCurrency(ctx context.Context, token string,
-	from_currency string, to_currency string, amount float32) (string, float32, map[string]float32, error) {
-
-	claims, err := jwt.ValidateToken(token)
-	if err != nil {
-		return "", 0, nil, fmt.Errorf("Ошибка в валидации токена: %v", err)
-	}
-
-	if amount <= 0 {
-		return "", 0, nil, fmt.Errorf("сумма обмена должна быть больше нуля")
-	}
-
-	// Обновляем курсы валют при обмене
-	err = UpdateExchangeRates(s.db)
-	if err != nil {
-		log.Printf("Ошибка обновления курсов валют: %v", err)
-	} else {
-		log.Println("Курсы валют успешно обновлены.")
-	}
-
-	currExch, err := GetExchangeRates(s.db)
-	if err != nil {
-		return "", 0, nil, fmt.Errorf("Ошибка получения списка валют: %v", err)
-	}
-
-	validCurrencies := map[string]bool{}
-	for _, exchange := range currExch {
-		validCurrencies[exchange.Currency] = true
-	}
-
-	if !validCurrencies[from_currency] || !validCurrencies[to_currency] {
-		return "", 0, nil, fmt.Errorf("недопустимые валюты: %s или %s", from_currency, to_currency)
-	}
-
-	userID := claims.UserID
-
-	// Получение кошельков пользователя
-	var fromWallet, toWallet UserWallet
-	if err := s.db.FirstOrCreate(&fromWallet, UserWallet{UserID: userID, Currency: from_currency}).Error; err != nil {
-		return "", 0, nil, fmt.Errorf("не удалось найти или создать кошелек: %w", err)
-	}
-	if err := s.db.FirstOrCreate(&toWallet, UserWallet{UserID: userID, Currency: to_currency}).Error; err != nil {
-		return "", 0, nil, fmt.Errorf("не удалось найти или создать кошелек: %w", err)
-	}
-
-	// Проверка достаточности средств для обмена
-	if fromWallet.Balance < amount {
-		return "", 0, nil, fmt.Errorf("недостаточно средств на счете %s: текущий баланс %.2f, запрашиваемая сумма %.2f", from_currency, fromWallet.Balance, amount)
-	}
-
-	// Получение курсов валют
-	var fromRate, toRate ExchangeRate
-	if err := s.db.First(&fromRate, "currency = ?", from_currency).Error; err != nil {
-		return "", 0, nil, fmt.Errorf("не удалось получить курс валюты %s: %w", from_currency, err)
-	}
-	if err := s.db.First(&toRate, "currency = ?", to_currency).Error; err != nil {
-		return "", 0, nil, fmt.Errorf("не удалось получить курс валюты %s: %w", to_currency, err)
-	}
-
-	amountInUSD := amount / fromRate.RateToUSD
-	exchangedAmount := amountInUSD * toRate.RateToUSD
-
-	fromWallet.Balance -= amount
-	toWallet.Balance += exchangedAmount
-
-	if err := s.db.Save(&fromWallet).Error; err != nil {
-		return "", 0, nil, fmt.Errorf("не удалось обновить баланс %s: %w", from_currency, err)
-	}
-	if err := s.db.Save(&toWallet).Error; err != nil {
-		return "", 0, nil, fmt.Errorf("не удалось обновить баланс %s: %w", to_currency, err)
-	}
-
-	// Получение нового баланса
-	newBalance, err := GetBalanceAfterOperation(s.db, ctx, userID)
-	if err != nil {
-		return "", 0, nil, fmt.Errorf("не удалось получить новый баланс: %w", err)
-	}
-
-	return "Обмен успешно завершен", exchangedAmount, newBalance, nil
-}
@@ -0,0 +1,107 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"main/internal/domain/models"
+	"main/internal/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// haltedPairs возвращает пары, по которым есть котировки в карантине
+func haltedPairs(db *gorm.DB) (map[[2]string]bool, error) {
+	var pending []models.QuarantinedQuote
+	err := db.Select("base", "quote").
+		Where("status = ?", models.QuarantinePending).
+		Find(&pending).Error
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка получения остановленных пар: %w", err)
+	}
+
+	halted := make(map[[2]string]bool, len(pending))
+	for _, q := range pending {
+		halted[[2]string{q.Base, q.Quote}] = true
+	}
+	return halted, nil
+}
+
+// HaltedPairs возвращает пары, обмен по которым остановлен до решения администратора
+func (s *Storage) HaltedPairs(ctx context.Context) (map[[2]string]bool, error) {
+	return haltedPairs(s.conn(ctx))
+}
+
+// QuarantineQuotes сохраняет задержанные котировки
+func (s *Storage) QuarantineQuotes(ctx context.Context, quotes []models.QuarantinedQuote) error {
+	if len(quotes) == 0 {
+		return nil
+	}
+	if err := s.conn(ctx).Create(&quotes).Error; err != nil {
+		return fmt.Errorf("Ошибка сохранения котировок в карантин: %w", err)
+	}
+	return nil
+}
+
+// QuarantinedQuotes возвращает котировки из карантина, новые первыми
+func (s *Storage) QuarantinedQuotes(ctx context.Context, onlyPending bool) ([]models.QuarantinedQuote, error) {
+	db := s.conn(ctx)
+	query := db.Order("created_at DESC, id DESC")
+	if onlyPending {
+		query = query.Where("status = ?", models.QuarantinePending)
+	}
+
+	var quotes []models.QuarantinedQuote
+	if err := query.Find(&quotes).Error; err != nil {
+		return nil, fmt.Errorf("Ошибка получения котировок из карантина: %w", err)
+	}
+	return quotes, nil
+}
+
+// ResolveQuarantine применяет или отклоняет котировку из карантина.
+// При подтверждении котировка записывается в rate_quotes, а остальные
+// ожидающие котировки той же пары отклоняются — обмен по паре возобновляется.
+func (s *Storage) ResolveQuarantine(ctx context.Context, id uint64, approve bool, resolvedBy string) (models.QuarantinedQuote, error) {
+	s.wrote(uuid.Nil)
+	var quote models.QuarantinedQuote
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		tx := s.conn(ctx)
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&quote, "id = ? AND status = ?", id, models.QuarantinePending).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: %d", storage.ErrQuoteNotFound, id)
+			}
+			return fmt.Errorf("Ошибка получения котировки %d: %w", id, err)
+		}
+
+		now := time.Now()
+		quote.ResolvedAt = &now
+		quote.ResolvedBy = resolvedBy
+		quote.Status = models.QuarantineRejected
+
+		if approve {
+			quote.Status = models.QuarantineApproved
+			q := quote.RateQuote()
+			if err := upsertQuote(tx, q); err != nil {
+				return err
+			}
+			err := tx.Model(&models.QuarantinedQuote{}).
+				Where("base = ? AND quote = ? AND status = ? AND id <> ?", quote.Base, quote.Quote, models.QuarantinePending, quote.ID).
+				Updates(map[string]any{"status": models.QuarantineRejected, "resolved_at": now, "resolved_by": resolvedBy}).Error
+			if err != nil {
+				return fmt.Errorf("Ошибка отклонения котировок %s/%s: %w", quote.Base, quote.Quote, err)
+			}
+		}
+
+		return tx.Model(&quote).
+			Updates(map[string]any{"status": quote.Status, "resolved_at": now, "resolved_by": resolvedBy}).Error
+	})
+	if err != nil {
+		return models.QuarantinedQuote{}, err
+	}
+	return quote, nil
+}
@@ -0,0 +1,81 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"main/internal/domain/models"
+	"main/internal/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const upsertQuoteQuery = `
+	INSERT INTO rate_quotes (base, quote, bid, ask, source, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (base, quote)
+	DO UPDATE SET bid = EXCLUDED.bid,
+		ask = EXCLUDED.ask,
+		source = EXCLUDED.source,
+		updated_at = EXCLUDED.updated_at`
+
+// Котировка попадает в историю один раз: повторное сохранение с тем же временем ничего не меняет
+const insertHistoryQuery = `
+	INSERT INTO rate_history (base, quote, bid, ask, source, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (base, quote, updated_at) DO NOTHING`
+
+// Последняя котировка каждой пары не позже заданного момента
+const quotesAtQuery = `
+	SELECT DISTINCT ON (base, quote) base, quote, bid, ask, source, updated_at
+	FROM rate_history
+	WHERE updated_at <= $1
+	ORDER BY base, quote, updated_at DESC`
+
+// Quotes возвращает сохраненные котировки
+func (s *Storage) Quotes(ctx context.Context) ([]models.RateQuote, error) {
+	var quotes []models.RateQuote
+	if err := s.read(ctx, uuid.Nil).Order("base, quote").Find(&quotes).Error; err != nil {
+		return nil, fmt.Errorf("не удалось получить котировки: %w", err)
+	}
+	return quotes, nil
+}
+
+// SaveQuotes добавляет или заменяет котировки пар
+func (s *Storage) SaveQuotes(ctx context.Context, quotes []models.RateQuote) error {
+	s.wrote(uuid.Nil)
+	return s.WithinTx(ctx, func(ctx context.Context) error {
+		db := s.conn(ctx)
+		for _, q := range quotes {
+			if err := upsertQuote(db, q); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// QuotesAt возвращает котировки пар на момент at по истории котировок
+func (s *Storage) QuotesAt(ctx context.Context, at time.Time) ([]models.RateQuote, error) {
+	var quotes []models.RateQuote
+	if err := s.read(ctx, uuid.Nil).Raw(quotesAtQuery, at).Scan(&quotes).Error; err != nil {
+		return nil, fmt.Errorf("не удалось получить котировки на %s: %w", at.Format(time.RFC3339), err)
+	}
+	return quotes, nil
+}
+
+// upsertQuote заменяет котировку пары и добавляет ее в историю
+func upsertQuote(db *gorm.DB, q models.RateQuote) error {
+	if err := db.Exec(upsertQuoteQuery, q.Base, q.Quote, q.Bid, q.Ask, q.Source, q.UpdatedAt).Error; err != nil {
+		if errors.Is(err, gorm.ErrCheckConstraintViolated) {
+			return fmt.Errorf("%w: %s/%s bid %s ask %s", storage.ErrInvalidQuote, q.Base, q.Quote, q.Bid, q.Ask)
+		}
+		return fmt.Errorf("Ошибка добавления или обновления курса %s/%s: %w", q.Base, q.Quote, err)
+	}
+	if err := db.Exec(insertHistoryQuery, q.Base, q.Quote, q.Bid, q.Ask, q.Source, q.UpdatedAt).Error; err != nil {
+		return fmt.Errorf("Ошибка сохранения истории курса %s/%s: %w", q.Base, q.Quote, err)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"main/internal/lib/logger/sl"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Отставание реплики: ноль, если все полученные изменения применены, иначе время
+// с последней примененной транзакции. На primary функции возвращают NULL.
+const replicaLagQuery = `SELECT COALESCE(CASE
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+END, 0)`
+
+// lagUnknown — реплика недоступна или еще не проверялась
+const lagUnknown = -1
+
+// Replicas направляет чтение на реплики и следит за их отставанием.
+// Реплика используется, только если ее отставание не больше maxLag. Чтобы
+// пользователь видел свои изменения, после записи его данные и общие справочники
+// читаются с primary в течение sticky.
+type Replicas struct {
+	log      *slog.Logger
+	nodes    []*replica
+	next     atomic.Uint64
+	maxLag   time.Duration
+	sticky   time.Duration
+	interval time.Duration
+
+	mu     sync.Mutex
+	writes map[uuid.UUID]time.Time // uuid.Nil — общие данные: валюты и котировки
+}
+
+type replica struct {
+	host string
+	db   *gorm.DB
+	lag  atomic.Int64 // time.Duration или lagUnknown
+}
+
+func newReplicas(log *slog.Logger, opts Options) *Replicas {
+	return &Replicas{
+		log:      log,
+		maxLag:   opts.ReplicaMaxLag,
+		sticky:   opts.ReadYourWrites,
+		interval: opts.ReplicaCheckInterval,
+		writes:   make(map[uuid.UUID]time.Time),
+	}
+}
+
+func (r *Replicas) add(host string, db *gorm.DB) {
+	node := &replica{host: host, db: db}
+	node.lag.Store(lagUnknown)
+	r.nodes = append(r.nodes, node)
+}
+
+// close закрывает пулы соединений реплик
+func (r *Replicas) close() {
+	for _, node := range r.nodes {
+		closeDB(node.db)
+	}
+}
+
+// Run проверяет отставание реплик с заданным интервалом до отмены ctx
+func (r *Replicas) Run(ctx context.Context) {
+	if r.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.check(ctx)
+			r.forget(time.Now())
+		}
+	}
+}
+
+// check измеряет отставание каждой реплики и возвращает число реплик, пригодных
+// для чтения. Недоступная реплика исключается из чтения до следующей успешной проверки.
+func (r *Replicas) check(ctx context.Context) int {
+	const op = "postgresql.Replicas.check"
+	log := r.log.With(slog.String("op", op))
+
+	available := 0
+	for _, node := range r.nodes {
+		var seconds sql.NullFloat64
+		err := node.db.WithContext(ctx).Raw(replicaLagQuery).Scan(&seconds).Error
+		if err != nil {
+			if node.lag.Swap(lagUnknown) != lagUnknown {
+				log.WarnContext(ctx, "replica unavailable", slog.String("host", node.host), sl.Err(err))
+			}
+			continue
+		}
+
+		lag := time.Duration(seconds.Float64 * float64(time.Second))
+		// Предупреждаем при переходе через порог, а не на каждой проверке
+		if previous := time.Duration(node.lag.Swap(int64(lag))); lag > r.maxLag && previous <= r.maxLag {
+			log.WarnContext(ctx, "replica lags behind", slog.String("host", node.host), slog.Duration("lag", lag))
+		}
+		if lag <= r.maxLag {
+			available++
+		}
+	}
+	return available
+}
+
+// pick возвращает следующую по кругу реплику с допустимым отставанием или nil
+func (r *Replicas) pick() *gorm.DB {
+	n := uint64(len(r.nodes))
+	start := r.next.Add(1)
+	for i := range n {
+		node := r.nodes[(start+i)%n]
+		if lag := node.lag.Load(); lag != lagUnknown && time.Duration(lag) <= r.maxLag {
+			return node.db
+		}
+	}
+	return nil
+}
+
+// wrote запоминает время записи данных пользователя или общих данных (uuid.Nil)
+func (r *Replicas) wrote(ids ...uuid.UUID) {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range ids {
+		r.writes[id] = now
+	}
+}
+
+// recent сообщает, что данные id менялись недавно и реплика может их еще не получить
+func (r *Replicas) recent(id uuid.UUID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	at, ok := r.writes[id]
+	return ok && time.Since(at) < r.sticky
+}
+
+// forget удаляет записи старше sticky
+func (r *Replicas) forget(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, at := range r.writes {
+		if now.Sub(at) >= r.sticky {
+			delete(r.writes, id)
+		}
+	}
+}
+
+// read возвращает соединение для чтения данных пользователя userID (uuid.Nil —
+// общие данные): реплику или, если подходящей нет, primary
+func (s *Storage) read(ctx context.Context, userID uuid.UUID) *gorm.DB {
+	if db := s.replica(ctx, userID); db != nil {
+		return db.WithContext(ctx)
+	}
+	return s.conn(ctx)
+}
+
+// replica выбирает реплику для чтения. Внутри транзакции и вскоре после записи
+// данных userID возвращает nil: читать нужно с primary.
+func (s *Storage) replica(ctx context.Context, userID uuid.UUID) *gorm.DB {
+	if s.replicas == nil {
+		return nil
+	}
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return nil
+	}
+	if s.replicas.recent(userID) {
+		return nil
+	}
+	return s.replicas.pick()
+}
+
+// wrote отмечает запись, после которой чтение ids идет с primary
+func (s *Storage) wrote(ids ...uuid.UUID) {
+	if s.replicas != nil {
+		s.replicas.wrote(ids...)
+	}
+}
+
+// Replicas возвращает реплики для чтения или nil, если они не настроены
+func (s *Storage) Replicas() *Replicas {
+	return s.replicas
+}
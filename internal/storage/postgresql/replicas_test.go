@@ -0,0 +1,94 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestReplicaRouting(t *testing.T) {
+	fresh, lagging := &gorm.DB{}, &gorm.DB{}
+	s := &Storage{log: discardLog, replicas: newReplicas(discardLog, Options{
+		ReplicaMaxLag:  time.Second,
+		ReadYourWrites: time.Minute,
+	})}
+	s.replicas.add("fresh", fresh)
+	s.replicas.add("lagging", lagging)
+	ctx := context.Background()
+	reader, writer := uuid.New(), uuid.New()
+
+	// До первой проверки отставание неизвестно, читаем с primary
+	if s.replica(ctx, reader) != nil {
+		t.Fatal("unchecked replica used")
+	}
+
+	s.replicas.nodes[0].lag.Store(int64(100 * time.Millisecond))
+	s.replicas.nodes[1].lag.Store(int64(5 * time.Second))
+	for range 4 {
+		if s.replica(ctx, reader) != fresh {
+			t.Fatal("lagging replica used")
+		}
+	}
+
+	// После записи пользователь читает свои данные с primary, остальные — с реплики
+	s.wrote(writer)
+	if s.replica(ctx, writer) != nil || s.replica(ctx, reader) != fresh {
+		t.Fatal("read-your-writes applied to the wrong user")
+	}
+	s.replicas.forget(time.Now().Add(time.Hour))
+	if s.replica(ctx, writer) != fresh {
+		t.Fatal("write remembered after read_your_writes")
+	}
+
+	if s.replica(context.WithValue(ctx, txKey{}, &gorm.DB{}), reader) != nil {
+		t.Fatal("replica used inside transaction")
+	}
+
+	s.replicas.nodes[0].lag.Store(lagUnknown)
+	if s.replica(ctx, reader) != nil {
+		t.Fatal("unavailable replica used")
+	}
+}
+
+// Ping проверяет реплики: недоступная исключается из чтения, а сам Ping
+// успешен, пока доступен primary
+func TestPingChecksReplicas(t *testing.T) {
+	db, _ := testDB(t)
+	// На порту 1 никто не слушает
+	cfg, err := pgx.ParseConfig("host=127.0.0.1 port=1 user=replica dbname=replica connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	broken, err := gorm.Open(postgres.New(postgres.Config{Conn: stdlib.OpenDB(*cfg)}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Storage{log: discardLog, db: db, replicas: newReplicas(discardLog, Options{ReplicaMaxLag: time.Second})}
+	// Primary отвечает на запрос отставания нулем и служит здоровой репликой
+	s.replicas.add("healthy", db)
+	s.replicas.add("broken", broken)
+	s.replicas.nodes[1].lag.Store(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if lag := s.replicas.nodes[0].lag.Load(); lag != 0 {
+		t.Fatalf("healthy replica lag = %d, want 0", lag)
+	}
+	if lag := s.replicas.nodes[1].lag.Load(); lag != lagUnknown {
+		t.Fatalf("broken replica lag = %d, want unknown", lag)
+	}
+	for range 4 {
+		if s.replica(ctx, uuid.New()) != db {
+			t.Fatal("broken replica used after Ping")
+		}
+	}
+}
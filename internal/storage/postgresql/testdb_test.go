@@ -0,0 +1,186 @@
+package postgresql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Тесты с базой работают с сервером из DSN, например:
+//
+//	TEST_STORAGE_PATH="host=localhost user=admin password=admin dbname=GRPCDB port=5432 sslmode=disable" go test ./internal/storage/...
+//
+// Без DSN тесты один раз поднимают локальный PostgreSQL (embedded-postgres) и
+// останавливают его после всех тестов пакета. Если сервер не запустился, тесты
+// с базой пропускаются, а в CI (задана переменная CI) падают.
+const testStorageEnv = "TEST_STORAGE_PATH"
+
+var discardLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+var embedded struct {
+	once sync.Once
+	db   *embeddedpostgres.EmbeddedPostgres
+	dir  string
+	dsn  string
+	err  error
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if embedded.db != nil {
+		if err := embedded.db.Stop(); err != nil {
+			fmt.Fprintf(os.Stderr, "stop embedded postgres: %v\n", err)
+		}
+	}
+	if embedded.dir != "" {
+		os.RemoveAll(embedded.dir)
+	}
+	os.Exit(code)
+}
+
+// testDSN возвращает DSN из TEST_STORAGE_PATH или запускает локальный сервер
+func testDSN(t *testing.T) string {
+	t.Helper()
+
+	if dsn := os.Getenv(testStorageEnv); dsn != "" {
+		return dsn
+	}
+	embedded.once.Do(func() {
+		embedded.dsn, embedded.err = startEmbedded()
+	})
+	if embedded.err != nil {
+		if os.Getenv("CI") != "" {
+			t.Fatalf("postgres for tests: %v", embedded.err)
+		}
+		t.Skipf("postgres for tests: %v; set %s to use an existing server", embedded.err, testStorageEnv)
+	}
+	return embedded.dsn
+}
+
+// startEmbedded запускает PostgreSQL во временном каталоге на свободном порту.
+// Бинарные файлы скачиваются при первом запуске и кешируются в ~/.embedded-postgres-go.
+func startEmbedded() (string, error) {
+	port, err := freePort()
+	if err != nil {
+		return "", err
+	}
+	embedded.dir, err = os.MkdirTemp("", "wallet-postgres-")
+	if err != nil {
+		return "", err
+	}
+
+	var log bytes.Buffer
+	db := embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().
+		Version(embeddedpostgres.V15).
+		Port(port).
+		RuntimePath(filepath.Join(embedded.dir, "runtime")).
+		DataPath(filepath.Join(embedded.dir, "data")).
+		StartTimeout(time.Minute).
+		Logger(&log))
+	if err := db.Start(); err != nil {
+		return "", fmt.Errorf("start embedded postgres: %w\n%s", err, log.Bytes())
+	}
+	embedded.db = db
+	return fmt.Sprintf("host=localhost port=%d user=postgres password=postgres dbname=postgres sslmode=disable", port), nil
+}
+
+func freePort() (uint32, error) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return uint32(l.Addr().(*net.TCPAddr).Port), nil
+}
+
+// testDB возвращает базу с миграциями и справочником валют
+func testDB(t *testing.T) (*gorm.DB, *Migrator) {
+	t.Helper()
+
+	db, m := openTestDB(t)
+	if err := seedCurrencies(db); err != nil {
+		t.Fatal(err)
+	}
+	return db, m
+}
+
+// openTestDB создает для теста отдельную схему, накатывает в нее миграции
+// и удаляет ее после теста.
+func openTestDB(t *testing.T) (*gorm.DB, *Migrator) {
+	t.Helper()
+
+	db, sqlDB := openEmptyDB(t)
+	m, err := NewMigrator(discardLog, sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return db, m
+}
+
+// openEmptyDB создает для теста отдельную пустую схему и удаляет ее после теста
+func openEmptyDB(t *testing.T) (*gorm.DB, *sql.DB) {
+	t.Helper()
+
+	dsn := testDSN(t)
+	schema := "test_" + uuid.NewString()[:8]
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+	if _, err := admin.Exec(fmt.Sprintf(`CREATE SCHEMA %q`, schema)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(fmt.Sprintf(`DROP SCHEMA %q CASCADE`, schema)); err != nil {
+			t.Errorf("drop schema %s: %v", schema, err)
+		}
+	})
+
+	cfg, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.RuntimeParams["search_path"] = schema
+	sqlDB := stdlib.OpenDB(*cfg)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, sqlDB
+}
+
+// createUser добавляет пользователя без кошельков
+func createUser(t *testing.T, db *gorm.DB) uuid.UUID {
+	t.Helper()
+
+	id := uuid.New()
+	name := id.String()[:8]
+	err := db.Exec(`INSERT INTO users (id, username, email, pass_hash, language) VALUES ($1, $2, $3, $4, $5)`,
+		id, name, name+"@example.com", []byte("hash"), "ru").Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
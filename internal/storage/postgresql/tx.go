@@ -0,0 +1,81 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"main/internal/lib/logger/sl"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Коды ошибок PostgreSQL, после которых транзакцию можно повторить целиком
+const (
+	codeSerializationFailure = "40001"
+	codeDeadlockDetected     = "40P01"
+)
+
+// Повтор транзакций при конфликте: число попыток и задержка перед первым повтором,
+// которая удваивается с каждой попыткой
+const (
+	txAttempts   = 3
+	txRetryDelay = 20 * time.Millisecond
+)
+
+type txKey struct{}
+
+// WithinTx выполняет fn в транзакции; транзакция передается через ctx, и все методы
+// хранилища, вызванные с этим ctx, работают в ней. Вложенный вызов выполняется
+// в savepoint: его ошибка откатывает только его изменения, а внешняя транзакция
+// может продолжиться. Транзакцию, прерванную из-за конфликта сериализации или
+// взаимоблокировки, WithinTx повторяет заново, поэтому fn не должна иметь побочных
+// эффектов вне базы. Отмена ctx прерывает запросы и откатывает транзакцию.
+func (s *Storage) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx).Transaction(func(sp *gorm.DB) error {
+			return fn(context.WithValue(ctx, txKey{}, sp))
+		})
+	}
+
+	delay := txRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, txKey{}, tx))
+		})
+		if err == nil || attempt == txAttempts || !retryable(err) {
+			return err
+		}
+		s.log.DebugContext(ctx, "retrying transaction",
+			slog.Int("attempt", attempt),
+			sl.Err(err),
+		)
+
+		// Случайная добавка разводит повторы конкурирующих транзакций во времени
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay + rand.N(delay)):
+		}
+		delay *= 2
+	}
+}
+
+// retryable сообщает, что транзакцию откатила база из-за конкурирующей транзакции
+func retryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == codeSerializationFailure || pgErr.Code == codeDeadlockDetected
+}
+
+// conn возвращает транзакцию из ctx или соединение с базой
+func (s *Storage) conn(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return s.db.WithContext(ctx)
+}
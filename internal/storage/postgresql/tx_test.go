@@ -0,0 +1,105 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/shopspring/decimal"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"serialization failure", &pgconn.PgError{Code: codeSerializationFailure}, true},
+		{"deadlock", &pgconn.PgError{Code: codeDeadlockDetected}, true},
+		{"wrapped", fmt.Errorf("Ошибка сохранения кошелька: %w", &pgconn.PgError{Code: codeDeadlockDetected}), true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"canceled", context.Canceled, false},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(tt.err); got != tt.want {
+				t.Fatalf("retryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// Две транзакции блокируют кошельки в разном порядке; база прерывает одну из них
+// по взаимоблокировке, и WithinTx повторяет ее после фиксации другой
+func TestWithinTxRetriesDeadlock(t *testing.T) {
+	db, _ := testDB(t)
+	s := &Storage{log: discardLog, db: db}
+	userID := createUser(t, db)
+	if err := addWallets(db, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	var attempts atomic.Int32
+	var locked sync.WaitGroup
+	locked.Add(2)
+	transfer := func(first, second string) error {
+		var once sync.Once
+		return s.WithinTx(context.Background(), func(ctx context.Context) error {
+			attempts.Add(1)
+			a, err := s.LockWallet(ctx, userID, first)
+			if err != nil {
+				return err
+			}
+			// Ждем, пока обе транзакции захватят первый кошелек
+			once.Do(func() {
+				locked.Done()
+				locked.Wait()
+			})
+			b, err := s.LockWallet(ctx, userID, second)
+			if err != nil {
+				return err
+			}
+			a.Balance = a.Balance.Add(decimal.NewFromInt(1))
+			b.Balance = b.Balance.Add(decimal.NewFromInt(1))
+			if err := s.SaveWallet(ctx, a); err != nil {
+				return err
+			}
+			return s.SaveWallet(ctx, b)
+		})
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for _, pair := range [][2]string{{"USD", "EUR"}, {"EUR", "USD"}} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- transfer(pair[0], pair[1])
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := attempts.Load(); n != 3 {
+		t.Fatalf("got %d attempts, want 3", n)
+	}
+	wallets, err := s.Wallets(context.Background(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range wallets {
+		if (w.Currency == "USD" || w.Currency == "EUR") && !w.Balance.Equal(decimal.NewFromInt(2)) {
+			t.Fatalf("wallet %s balance = %s, want 2", w.Currency, w.Balance)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"main/internal/domain/models"
+	"main/internal/outbox"
+	"main/internal/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Захват доставок на отправку: другие экземпляры не увидят их до истечения lease
+const claimDeliveriesQuery = `
+	UPDATE webhook_deliveries SET next_attempt_at = $1
+	WHERE id IN (
+		SELECT id FROM webhook_deliveries
+		WHERE status = $2 AND next_attempt_at <= $3
+		ORDER BY id
+		LIMIT $4
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING *`
+
+// enqueueWebhooks ставит события в очередь доставки подписанным webhook пользователя.
+// Вызывается в транзакции операции, поэтому доставка появляется только вместе с ней.
+func enqueueWebhooks(tx *gorm.DB, events ...models.OutboxEvent) error {
+	hooks := make(map[uuid.UUID][]models.Webhook)
+	for _, event := range events {
+		userHooks, ok := hooks[event.AggregateID]
+		if !ok {
+			if err := tx.Where("user_id = ? AND active", event.AggregateID).Find(&userHooks).Error; err != nil {
+				return fmt.Errorf("Ошибка получения webhook пользователя: %w", err)
+			}
+			hooks[event.AggregateID] = userHooks
+		}
+
+		var payload []byte
+		for _, hook := range userHooks {
+			if !hook.Accepts(event.Type) {
+				continue
+			}
+			if payload == nil {
+				var err error
+				if payload, err = outbox.Encode(event); err != nil {
+					return fmt.Errorf("Ошибка сериализации события %d: %w", event.ID, err)
+				}
+			}
+			delivery := models.WebhookDelivery{
+				WebhookID:     hook.ID,
+				UserID:        event.AggregateID,
+				EventID:       event.ID,
+				EventType:     event.Type,
+				Payload:       payload,
+				Status:        models.DeliveryPending,
+				NextAttemptAt: event.CreatedAt,
+				CreatedAt:     event.CreatedAt,
+			}
+			if err := tx.Create(&delivery).Error; err != nil {
+				return fmt.Errorf("Ошибка постановки webhook в очередь: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// SaveWebhook создает подписку
+func (s *Storage) SaveWebhook(ctx context.Context, hook models.Webhook) (models.Webhook, error) {
+	s.wrote(hook.UserID)
+	if err := s.conn(ctx).Create(&hook).Error; err != nil {
+		return models.Webhook{}, fmt.Errorf("Ошибка создания webhook: %w", err)
+	}
+	return hook, nil
+}
+
+// Webhooks возвращает подписки пользователя
+func (s *Storage) Webhooks(ctx context.Context, userID uuid.UUID) ([]models.Webhook, error) {
+	var hooks []models.Webhook
+	if err := s.read(ctx, userID).Where("user_id = ?", userID).Order("created_at").Find(&hooks).Error; err != nil {
+		return nil, fmt.Errorf("Ошибка получения webhook: %w", err)
+	}
+	return hooks, nil
+}
+
+// DeleteWebhook удаляет подписку пользователя. Недоставленные события уходят в dead letter.
+func (s *Storage) DeleteWebhook(ctx context.Context, userID uuid.UUID, id uuid.UUID) (models.Webhook, error) {
+	s.wrote(userID)
+	var hook models.Webhook
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		tx := s.conn(ctx)
+		if err := tx.First(&hook, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: %s", storage.ErrWebhookNotFound, id)
+			}
+			return fmt.Errorf("Ошибка получения webhook: %w", err)
+		}
+		err := tx.Model(&models.WebhookDelivery{}).
+			Where("webhook_id = ? AND status = ?", id, models.DeliveryPending).
+			Updates(map[string]any{"status": models.DeliveryDead, "last_error": "webhook удален"}).Error
+		if err != nil {
+			return fmt.Errorf("Ошибка обновления доставок webhook: %w", err)
+		}
+		if err := tx.Delete(&hook).Error; err != nil {
+			return fmt.Errorf("Ошибка удаления webhook: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	return hook, nil
+}
+
+// WebhookDeliveries возвращает журнал доставок пользователя, новые первыми.
+// Пустые webhookID и status не ограничивают выборку.
+func (s *Storage) WebhookDeliveries(ctx context.Context, userID uuid.UUID, webhookID uuid.UUID, status string, limit int) ([]models.WebhookDelivery, error) {
+	query := s.read(ctx, userID).Where("user_id = ?", userID)
+	if webhookID != uuid.Nil {
+		query = query.Where("webhook_id = ?", webhookID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Order("id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("Ошибка получения доставок webhook: %w", err)
+	}
+	return deliveries, nil
+}
+
+// RetryDelivery возвращает доставку в очередь со сброшенным счетчиком попыток
+func (s *Storage) RetryDelivery(ctx context.Context, userID uuid.UUID, id uint64) (models.WebhookDelivery, error) {
+	s.wrote(userID)
+	var delivery models.WebhookDelivery
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		tx := s.conn(ctx)
+		if err := tx.First(&delivery, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: %d", storage.ErrDeliveryNotFound, id)
+			}
+			return fmt.Errorf("Ошибка получения доставки webhook: %w", err)
+		}
+		var hooks int64
+		if err := tx.Model(&models.Webhook{}).Where("id = ?", delivery.WebhookID).Count(&hooks).Error; err != nil {
+			return fmt.Errorf("Ошибка получения webhook: %w", err)
+		}
+		if hooks == 0 {
+			return fmt.Errorf("%w: %s", storage.ErrWebhookNotFound, delivery.WebhookID)
+		}
+
+		delivery.Status = models.DeliveryPending
+		delivery.Attempts = 0
+		delivery.NextAttemptAt = time.Now()
+		return tx.Model(&delivery).Updates(map[string]any{
+			"status":          delivery.Status,
+			"attempts":        delivery.Attempts,
+			"next_attempt_at": delivery.NextAttemptAt,
+		}).Error
+	})
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	return delivery, nil
+}
+
+// ClaimDeliveries забирает готовые к отправке доставки. До истечения lease
+// они не выдаются повторно, поэтому несколько экземпляров не отправят одно событие одновременно.
+func (s *Storage) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookJob, error) {
+	var jobs []models.WebhookJob
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		jobs, err = claimDeliveries(s.conn(ctx), limit, lease)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func claimDeliveries(tx *gorm.DB, limit int, lease time.Duration) ([]models.WebhookJob, error) {
+	now := time.Now()
+
+	var deliveries []models.WebhookDelivery
+	err := tx.
+		Raw(claimDeliveriesQuery, now.Add(lease), models.DeliveryPending, now, limit).
+		Scan(&deliveries).Error
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка получения доставок webhook: %w", err)
+	}
+	if len(deliveries) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(deliveries))
+	for _, d := range deliveries {
+		ids = append(ids, d.WebhookID)
+	}
+	var hooks []models.Webhook
+	if err := tx.Where("id IN ?", ids).Find(&hooks).Error; err != nil {
+		return nil, fmt.Errorf("Ошибка получения webhook: %w", err)
+	}
+	byID := make(map[uuid.UUID]models.Webhook, len(hooks))
+	for _, h := range hooks {
+		byID[h.ID] = h
+	}
+
+	jobs := make([]models.WebhookJob, 0, len(deliveries))
+	for _, d := range deliveries {
+		hook, ok := byID[d.WebhookID]
+		if !ok {
+			// Подписку удалили после постановки в очередь
+			if err := tx.Model(&d).
+				Updates(map[string]any{"status": models.DeliveryDead, "last_error": "webhook удален"}).Error; err != nil {
+				return nil, fmt.Errorf("Ошибка обновления доставки webhook: %w", err)
+			}
+			continue
+		}
+		jobs = append(jobs, models.WebhookJob{Delivery: d, URL: hook.URL, Secret: hook.Secret})
+	}
+	return jobs, nil
+}
+
+// SaveAttempt сохраняет результат попытки доставки и запись журнала
+func (s *Storage) SaveAttempt(ctx context.Context, delivery models.WebhookDelivery, attempt models.WebhookAttempt) error {
+	s.wrote(delivery.UserID)
+	return s.WithinTx(ctx, func(ctx context.Context) error {
+		tx := s.conn(ctx)
+		err := tx.Model(&delivery).Updates(map[string]any{
+			"status":           delivery.Status,
+			"attempts":         delivery.Attempts,
+			"next_attempt_at":  delivery.NextAttemptAt,
+			"last_status_code": delivery.LastStatusCode,
+			"last_error":       delivery.LastError,
+			"delivered_at":     delivery.DeliveredAt,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("Ошибка обновления доставки webhook %d: %w", delivery.ID, err)
+		}
+		if err := tx.Create(&attempt).Error; err != nil {
+			return fmt.Errorf("Ошибка записи попытки доставки %d: %w", delivery.ID, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"main/internal/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Репозитории описывают хранение данных без бизнес-правил: проверка токенов,
+// лимитов и конвертация курсов остаются в сервисах. Реализации — postgresql.Storage
+// и memory.Storage; их поведение задает общий набор тестов storagetest.
+
+// Transactor выполняет fn в одной транзакции. Методы репозиториев, вызванные
+// с ctx, который получила fn, работают внутри этой транзакции. Если fn вернула
+// ошибку или запаниковала, все изменения откатываются, ошибка возвращается как есть.
+// Вложенный вызов WithinTx работает как savepoint: его ошибка откатывает только
+// его изменения. Реализация может повторить fn, если транзакцию прервал конфликт
+// с параллельной, поэтому fn не должна иметь побочных эффектов вне хранилища.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type UserRepository interface {
+	// CreateUser добавляет пользователя. Занятые имя или email — ErrUserExists.
+	CreateUser(ctx context.Context, user models.User) error
+	// User возвращает пользователя по email или ErrUserNotFound
+	User(ctx context.Context, email string) (models.User, error)
+}
+
+type WalletRepository interface {
+	// AddWalletUser создает пользователю недостающие основные кошельки во всех включенных валютах
+	AddWalletUser(ctx context.Context, userID uuid.UUID) error
+	// Wallets возвращает все кошельки пользователя, включая архивные, упорядоченные
+	// по валюте и названию; основной кошелек валюты идет первым
+	Wallets(ctx context.Context, userID uuid.UUID) ([]models.UserWallet, error)
+	// LockWallet возвращает основной кошелек пользователя в валюте, при необходимости создавая
+	// пустой, и блокирует его до конца транзакции. Валюты нет в справочнике — ErrCurrencyNotFound.
+	LockWallet(ctx context.Context, userID uuid.UUID, currency string) (models.UserWallet, error)
+	// LockWalletByID возвращает кошелек пользователя по идентификатору и блокирует его
+	// до конца транзакции. Кошелька нет или он чужой — ErrWalletNotFound.
+	LockWalletByID(ctx context.Context, userID uuid.UUID, id uuid.UUID) (models.UserWallet, error)
+	// CreateWallet создает дополнительный кошелек с названием wallet.Name. Название
+	// уникально среди неархивных кошельков пользователя без учета регистра — иначе
+	// ErrWalletExists. Валюты нет в справочнике — ErrCurrencyNotFound.
+	CreateWallet(ctx context.Context, wallet models.UserWallet) (models.UserWallet, error)
+	// SaveWallet сохраняет баланс, название и признак архива кошелька. Отрицательный
+	// баланс — ErrInsufficientFunds, занятое название — ErrWalletExists.
+	SaveWallet(ctx context.Context, wallet models.UserWallet) error
+}
+
+type CurrencyRepository interface {
+	// Currencies возвращает справочник валют, упорядоченный по коду
+	Currencies(ctx context.Context, onlyEnabled bool) ([]models.Currency, error)
+	// Currency возвращает валюту или ErrCurrencyNotFound
+	Currency(ctx context.Context, code string) (models.Currency, error)
+	// SaveCurrency добавляет или обновляет валюту; для включенной создаются недостающие кошельки
+	SaveCurrency(ctx context.Context, currency models.Currency) (models.Currency, error)
+	// SetCurrencyEnabled включает или отключает валюту; при включении создаются недостающие кошельки
+	SetCurrencyEnabled(ctx context.Context, code string, enabled bool) (models.Currency, error)
+}
+
+type RateRepository interface {
+	// Quotes возвращает сохраненные котировки, упорядоченные по паре
+	Quotes(ctx context.Context) ([]models.RateQuote, error)
+	// SaveQuotes добавляет или заменяет котировки пар и дописывает их в историю.
+	// Некорректная котировка — ErrInvalidQuote.
+	SaveQuotes(ctx context.Context, quotes []models.RateQuote) error
+	// QuotesAt возвращает котировки пар на момент at: для каждой пары последнюю
+	// сохраненную котировку не позже at, упорядоченные по паре
+	QuotesAt(ctx context.Context, at time.Time) ([]models.RateQuote, error)
+	// RatesUpdatedAt возвращает время последней котировки или нулевое время
+	RatesUpdatedAt(ctx context.Context) (time.Time, error)
+}
+
+type QuarantineRepository interface {
+	// QuarantineQuotes сохраняет задержанные котировки и заполняет их ID
+	QuarantineQuotes(ctx context.Context, quotes []models.QuarantinedQuote) error
+	// HaltedPairs возвращает пары, по которым есть ожидающие решения котировки
+	HaltedPairs(ctx context.Context) (map[[2]string]bool, error)
+	// QuarantinedQuotes возвращает котировки из карантина, новые первыми
+	QuarantinedQuotes(ctx context.Context, onlyPending bool) ([]models.QuarantinedQuote, error)
+	// ResolveQuarantine подтверждает или отклоняет ожидающую котировку (иначе ErrQuoteNotFound).
+	// Подтвержденная котировка сохраняется, остальные ожидающие по паре отклоняются.
+	ResolveQuarantine(ctx context.Context, id uint64, approve bool, resolvedBy string) (models.QuarantinedQuote, error)
+}
+
+type OutboxRepository interface {
+	// AppendEvents записывает события в outbox, заполняет их ID и ставит
+	// в очередь доставки webhook пользователя, подписанным на тип события
+	AppendEvents(ctx context.Context, events []models.OutboxEvent) error
+	// PendingEvents возвращает неопубликованные события в порядке записи, кроме
+	// перенесенных в dead letter и событий пользователей, чье более раннее событие
+	// ждет повторной попытки (NextAttemptAt в будущем)
+	PendingEvents(ctx context.Context, limit int) ([]models.OutboxEvent, error)
+	MarkPublished(ctx context.Context, ids []uint64) error
+	// MarkFailed увеличивает счетчик попыток, сохраняет ошибку и откладывает событие до retryAt
+	MarkFailed(ctx context.Context, id uint64, cause error, retryAt time.Time) error
+	// MarkDead увеличивает счетчик попыток, сохраняет ошибку и переносит событие в dead letter
+	MarkDead(ctx context.Context, id uint64, cause error) error
+}
+
+type WebhookRepository interface {
+	SaveWebhook(ctx context.Context, hook models.Webhook) (models.Webhook, error)
+	Webhooks(ctx context.Context, userID uuid.UUID) ([]models.Webhook, error)
+	// DeleteWebhook удаляет подписку (иначе ErrWebhookNotFound), ожидающие доставки уходят в dead letter
+	DeleteWebhook(ctx context.Context, userID uuid.UUID, id uuid.UUID) (models.Webhook, error)
+	// WebhookDeliveries возвращает доставки пользователя, новые первыми. Пустые webhookID и status не фильтруют.
+	WebhookDeliveries(ctx context.Context, userID uuid.UUID, webhookID uuid.UUID, status string, limit int) ([]models.WebhookDelivery, error)
+	// RetryDelivery возвращает доставку в очередь со сброшенным счетчиком попыток
+	RetryDelivery(ctx context.Context, userID uuid.UUID, id uint64) (models.WebhookDelivery, error)
+	// ClaimDeliveries забирает готовые к отправке доставки и не выдает их повторно до истечения lease
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookJob, error)
+	// SaveAttempt сохраняет результат попытки доставки и запись журнала
+	SaveAttempt(ctx context.Context, delivery models.WebhookDelivery, attempt models.WebhookAttempt) error
+}
+
+// Repository — полный набор репозиториев одного хранилища
+type Repository interface {
+	Transactor
+	UserRepository
+	WalletRepository
+	CurrencyRepository
+	RateRepository
+	QuarantineRepository
+	OutboxRepository
+	WebhookRepository
+}
@@ -3,7 +3,29 @@ package storage
 import "errors"
 
 var (
-	ErrUserNotFound       = errors.New("Пользователь не найден")
+	ErrUserNotFound       = errors.New("Пользователь не найден")
 	ErrUserExists         = errors.New("Пользователь уже существует")
 	ErrInvalidCredentials = errors.New("Неверные учетные данные")
+	ErrInvalidToken       = errors.New("Недействительный токен")
+	ErrInvalidCurrency    = errors.New("Неверная валюта")
+	ErrInvalidAmount      = errors.New("Неверная сумма")
+	ErrAmountPrecision    = errors.New("Слишком много знаков после запятой")
+	ErrAmountBelowMinimum = errors.New("Сумма меньше минимальной")
+	ErrInsufficientFunds  = errors.New("Недостаточно средств на счете")
+	ErrCurrencyNotFound   = errors.New("Валюта не найдена")
+	ErrCurrencyNotAllowed = errors.New("Операция с валютой недоступна")
+	ErrPermissionDenied   = errors.New("Недостаточно прав")
+	ErrRateNotFound       = errors.New("Курс для пары валют не найден")
+	ErrPairHalted         = errors.New("Обмен по паре остановлен до проверки курса")
+	ErrQuoteNotFound      = errors.New("Котировка в карантине не найдена")
+	ErrInvalidPair        = errors.New("Неверная пара валют")
+	ErrInvalidWebhook     = errors.New("Неверные параметры webhook")
+	ErrWebhookNotFound    = errors.New("Webhook не найден")
+	ErrDeliveryNotFound   = errors.New("Доставка webhook не найдена")
+	ErrWalletNotFound     = errors.New("Кошелек не найден")
+	ErrWalletExists       = errors.New("Кошелек с таким названием уже существует")
+	ErrWalletArchived     = errors.New("Кошелек в архиве")
+	ErrWalletNotEmpty     = errors.New("На кошельке остались средства")
+	ErrInvalidWallet      = errors.New("Неверные параметры кошелька")
+	ErrInvalidQuote       = errors.New("Неверная котировка")
 )
@@ -0,0 +1,247 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"main/internal/domain/models"
+	"main/internal/storage"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func event(userID uuid.UUID) models.OutboxEvent {
+	return models.OutboxEvent{
+		AggregateID: userID,
+		Type:        models.EventWalletCredited,
+		Payload:     []byte(`{"a": 1}`),
+		CreatedAt:   time.Now().Add(-time.Second),
+	}
+}
+
+func mustSaveWebhook(t *testing.T, r storage.Repository, userID uuid.UUID, types string) models.Webhook {
+	t.Helper()
+	hook, err := r.SaveWebhook(context.Background(), models.Webhook{
+		ID:         uuid.New(),
+		UserID:     userID,
+		URL:        "https://example.com/hook",
+		EventTypes: types,
+		Secret:     "secret",
+		Active:     true,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("SaveWebhook: %v", err)
+	}
+	return hook
+}
+
+var eventTests = []test{
+	{"Outbox", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		user := mustCreateUser(t, r)
+		events := []models.OutboxEvent{event(user.ID), event(user.ID), event(user.ID)}
+		noErr(t, r.AppendEvents(ctx, events))
+		if events[0].ID == 0 || events[0].ID >= events[1].ID || events[1].ID >= events[2].ID {
+			t.Fatalf("event IDs are not ascending: %d, %d, %d", events[0].ID, events[1].ID, events[2].ID)
+		}
+
+		pending, err := r.PendingEvents(ctx, 2)
+		noErr(t, err)
+		if len(pending) != 2 || pending[0].ID != events[0].ID || pending[1].ID != events[1].ID {
+			t.Fatalf("pending = %+v, want the first two events", pending)
+		}
+
+		noErr(t, r.MarkPublished(ctx, []uint64{events[0].ID}))
+		// Срок повторной попытки наступил — событие снова в выборке
+		retryAt := time.Now().Add(-time.Second)
+		noErr(t, r.MarkFailed(ctx, events[1].ID, errors.New(strings.Repeat("x", 2000)), retryAt))
+		noErr(t, r.MarkFailed(ctx, events[1].ID, errors.New("broker unavailable"), retryAt))
+
+		pending, err = r.PendingEvents(ctx, 10)
+		noErr(t, err)
+		if len(pending) != 2 || pending[0].ID != events[1].ID {
+			t.Fatalf("pending = %+v, want the second and third events", pending)
+		}
+		if pending[0].Attempts != 2 || pending[0].LastError != "broker unavailable" {
+			t.Fatalf("failed event = %+v, want 2 attempts and the last error", pending[0])
+		}
+	}},
+	{"OutboxErrorTruncated", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		user := mustCreateUser(t, r)
+		events := []models.OutboxEvent{event(user.ID)}
+		noErr(t, r.AppendEvents(ctx, events))
+		noErr(t, r.MarkFailed(ctx, events[0].ID, errors.New(strings.Repeat("x", 2000)), time.Now()))
+
+		pending, err := r.PendingEvents(ctx, 1)
+		noErr(t, err)
+		if len(pending) != 1 || len(pending[0].LastError) != 1000 {
+			t.Fatalf("pending = %+v, want the error truncated to 1000 bytes", pending)
+		}
+	}},
+	{"OutboxDelayedUser", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		blocked, other := mustCreateUser(t, r), mustCreateUser(t, r)
+		events := []models.OutboxEvent{event(blocked.ID), event(blocked.ID), event(blocked.ID), event(other.ID)}
+		noErr(t, r.AppendEvents(ctx, events))
+
+		// Пользователь, чье первое событие ждет повтора, не занимает пачку
+		noErr(t, r.MarkFailed(ctx, events[0].ID, errors.New("broker unavailable"), time.Now().Add(time.Hour)))
+		pending, err := r.PendingEvents(ctx, 1)
+		noErr(t, err)
+		if len(pending) != 1 || pending[0].ID != events[3].ID {
+			t.Fatalf("pending = %+v, want only the event of the other user", pending)
+		}
+
+		// Задержанное событие второго пользователя тоже не выдается
+		noErr(t, r.MarkFailed(ctx, events[3].ID, errors.New("broker unavailable"), time.Now().Add(time.Hour)))
+		pending, err = r.PendingEvents(ctx, 10)
+		noErr(t, err)
+		if len(pending) != 0 {
+			t.Fatalf("pending = %+v, want none", pending)
+		}
+
+		// Событие в dead letter больше не выдается и не задерживает следующие
+		noErr(t, r.MarkDead(ctx, events[0].ID, errors.New("rejected")))
+		pending, err = r.PendingEvents(ctx, 10)
+		noErr(t, err)
+		if len(pending) != 2 || pending[0].ID != events[1].ID || pending[1].ID != events[2].ID {
+			t.Fatalf("pending = %+v, want the second and third events of the user", pending)
+		}
+	}},
+	{"Webhooks", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		user, other := mustCreateUser(t, r), mustCreateUser(t, r)
+		first := mustSaveWebhook(t, r, user.ID, "")
+		second := mustSaveWebhook(t, r, user.ID, models.EventWalletDebited)
+		mustSaveWebhook(t, r, other.ID, "")
+
+		hooks, err := r.Webhooks(ctx, user.ID)
+		noErr(t, err)
+		if len(hooks) != 2 || hooks[0].ID != first.ID || hooks[1].ID != second.ID {
+			t.Fatalf("webhooks = %+v, want both hooks of the user in creation order", hooks)
+		}
+
+		_, err = r.DeleteWebhook(ctx, other.ID, first.ID)
+		wantErr(t, err, storage.ErrWebhookNotFound)
+		deleted, err := r.DeleteWebhook(ctx, user.ID, first.ID)
+		noErr(t, err)
+		if deleted.ID != first.ID {
+			t.Fatalf("deleted %s, want %s", deleted.ID, first.ID)
+		}
+		hooks, err = r.Webhooks(ctx, user.ID)
+		noErr(t, err)
+		if len(hooks) != 1 || hooks[0].ID != second.ID {
+			t.Fatalf("webhooks after delete = %+v", hooks)
+		}
+	}},
+	{"WebhookDeliveries", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		user := mustCreateUser(t, r)
+		all := mustSaveWebhook(t, r, user.ID, "")
+		debited := mustSaveWebhook(t, r, user.ID, models.EventWalletDebited)
+
+		// Событие доставляется только подпискам на его тип
+		noErr(t, r.AppendEvents(ctx, []models.OutboxEvent{event(user.ID), event(mustCreateUser(t, r).ID)}))
+		deliveries, err := r.WebhookDeliveries(ctx, user.ID, uuid.Nil, "", 10)
+		noErr(t, err)
+		if len(deliveries) != 1 || deliveries[0].WebhookID != all.ID || deliveries[0].EventType != models.EventWalletCredited {
+			t.Fatalf("deliveries = %+v, want one for the catch-all hook", deliveries)
+		}
+		if deliveries[0].Status != models.DeliveryPending || len(deliveries[0].Payload) == 0 {
+			t.Fatalf("delivery = %+v, want pending with payload", deliveries[0])
+		}
+
+		debit := event(user.ID)
+		debit.Type = models.EventWalletDebited
+		noErr(t, r.AppendEvents(ctx, []models.OutboxEvent{debit}))
+		deliveries, err = r.WebhookDeliveries(ctx, user.ID, uuid.Nil, "", 10)
+		noErr(t, err)
+		if len(deliveries) != 3 || deliveries[0].ID < deliveries[2].ID {
+			t.Fatalf("deliveries = %+v, want 3 with the newest first", deliveries)
+		}
+		deliveries, err = r.WebhookDeliveries(ctx, user.ID, debited.ID, "", 10)
+		noErr(t, err)
+		if len(deliveries) != 1 || deliveries[0].EventType != models.EventWalletDebited {
+			t.Fatalf("deliveries of debit hook = %+v", deliveries)
+		}
+		deliveries, err = r.WebhookDeliveries(ctx, user.ID, uuid.Nil, models.DeliveryDead, 10)
+		noErr(t, err)
+		if len(deliveries) != 0 {
+			t.Fatalf("dead deliveries = %+v", deliveries)
+		}
+		deliveries, err = r.WebhookDeliveries(ctx, user.ID, uuid.Nil, "", 1)
+		noErr(t, err)
+		if len(deliveries) != 1 {
+			t.Fatalf("got %d deliveries with limit 1", len(deliveries))
+		}
+
+		// Удаление подписки отправляет ее доставки в dead letter
+		_, err = r.DeleteWebhook(ctx, user.ID, debited.ID)
+		noErr(t, err)
+		deliveries, err = r.WebhookDeliveries(ctx, user.ID, debited.ID, models.DeliveryDead, 10)
+		noErr(t, err)
+		if len(deliveries) != 1 {
+			t.Fatalf("deliveries of deleted hook = %+v, want one dead", deliveries)
+		}
+		_, err = r.RetryDelivery(ctx, user.ID, deliveries[0].ID)
+		wantErr(t, err, storage.ErrWebhookNotFound)
+	}},
+	{"ClaimDeliveries", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		user := mustCreateUser(t, r)
+		hook := mustSaveWebhook(t, r, user.ID, "")
+		noErr(t, r.AppendEvents(ctx, []models.OutboxEvent{event(user.ID), event(user.ID)}))
+
+		jobs, err := r.ClaimDeliveries(ctx, 10, time.Minute)
+		noErr(t, err)
+		if len(jobs) != 2 || jobs[0].URL != hook.URL || jobs[0].Secret != hook.Secret {
+			t.Fatalf("jobs = %+v, want 2 jobs for the hook", jobs)
+		}
+		// До истечения lease доставки повторно не выдаются
+		again, err := r.ClaimDeliveries(ctx, 10, time.Minute)
+		noErr(t, err)
+		if len(again) != 0 {
+			t.Fatalf("claimed leased deliveries again: %+v", again)
+		}
+
+		now := time.Now()
+		delivered := jobs[0].Delivery
+		delivered.Status = models.DeliveryDelivered
+		delivered.Attempts = 1
+		delivered.LastStatusCode = 200
+		delivered.DeliveredAt = &now
+		noErr(t, r.SaveAttempt(ctx, delivered, models.WebhookAttempt{DeliveryID: delivered.ID, Attempt: 1, StatusCode: 200}))
+
+		failed := jobs[1].Delivery
+		failed.Status = models.DeliveryDead
+		failed.Attempts = 5
+		failed.LastStatusCode = 500
+		failed.LastError = "server error"
+		noErr(t, r.SaveAttempt(ctx, failed, models.WebhookAttempt{DeliveryID: failed.ID, Attempt: 5, StatusCode: 500}))
+
+		deliveries, err := r.WebhookDeliveries(ctx, user.ID, hook.ID, models.DeliveryDelivered, 10)
+		noErr(t, err)
+		if len(deliveries) != 1 || deliveries[0].Attempts != 1 || deliveries[0].LastStatusCode != 200 || deliveries[0].DeliveredAt == nil {
+			t.Fatalf("delivered = %+v", deliveries)
+		}
+
+		// Повтор из dead letter сбрасывает попытки и сразу возвращает доставку в очередь
+		retried, err := r.RetryDelivery(ctx, user.ID, failed.ID)
+		noErr(t, err)
+		if retried.Status != models.DeliveryPending || retried.Attempts != 0 {
+			t.Fatalf("retried = %+v", retried)
+		}
+		jobs, err = r.ClaimDeliveries(ctx, 10, time.Minute)
+		noErr(t, err)
+		if len(jobs) != 1 || jobs[0].Delivery.ID != failed.ID {
+			t.Fatalf("jobs after retry = %+v", jobs)
+		}
+
+		_, err = r.RetryDelivery(ctx, mustCreateUser(t, r).ID, failed.ID)
+		wantErr(t, err, storage.ErrDeliveryNotFound)
+	}},
+}
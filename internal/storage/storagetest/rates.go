@@ -0,0 +1,183 @@
+package storagetest
+
+import (
+	"context"
+	"main/internal/domain/models"
+	"main/internal/storage"
+	"testing"
+	"time"
+)
+
+func quote(base, quoteCode, bid, ask string) models.RateQuote {
+	return models.RateQuote{
+		Base:      base,
+		Quote:     quoteCode,
+		Bid:       dec(bid),
+		Ask:       dec(ask),
+		Source:    "test",
+		UpdatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func quarantined(base, quoteCode, bid string, created time.Time) models.QuarantinedQuote {
+	return models.QuarantinedQuote{
+		Base:        base,
+		Quote:       quoteCode,
+		Bid:         dec(bid),
+		Ask:         dec(bid),
+		Source:      "test",
+		PreviousMid: dec("1"),
+		Reason:      models.ReasonDeviation,
+		Status:      models.QuarantinePending,
+		CreatedAt:   created,
+	}
+}
+
+var rateTests = []test{
+	{"SaveQuotes", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		updated, err := r.RatesUpdatedAt(ctx)
+		noErr(t, err)
+		if !updated.IsZero() {
+			t.Fatalf("RatesUpdatedAt = %v without quotes", updated)
+		}
+
+		older := quote("USD", "RUB", "90", "91")
+		older.UpdatedAt = older.UpdatedAt.Add(-time.Hour)
+		noErr(t, r.SaveQuotes(ctx, []models.RateQuote{quote("USD", "EUR", "0.9", "0.91"), older}))
+		// Повторное сохранение пары заменяет котировку
+		noErr(t, r.SaveQuotes(ctx, []models.RateQuote{quote("USD", "EUR", "0.92", "0.93")}))
+
+		quotes, err := r.Quotes(ctx)
+		noErr(t, err)
+		if len(quotes) != 2 || quotes[0].Quote != "EUR" || quotes[1].Quote != "RUB" {
+			t.Fatalf("quotes = %+v, want USD/EUR, USD/RUB", quotes)
+		}
+		wantDecimal(t, "bid", quotes[0].Bid, "0.92")
+		wantDecimal(t, "ask", quotes[0].Ask, "0.93")
+
+		updated, err = r.RatesUpdatedAt(ctx)
+		noErr(t, err)
+		if !updated.Equal(quotes[0].UpdatedAt) {
+			t.Fatalf("RatesUpdatedAt = %v, want %v", updated, quotes[0].UpdatedAt)
+		}
+	}},
+	{"QuotesAt", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		day := quote("USD", "EUR", "0.8", "0.81")
+		day.UpdatedAt = day.UpdatedAt.Add(-24 * time.Hour)
+		hour := quote("USD", "EUR", "0.85", "0.86")
+		hour.UpdatedAt = hour.UpdatedAt.Add(-time.Hour)
+		rub := quote("USD", "RUB", "90", "91")
+		noErr(t, r.SaveQuotes(ctx, []models.RateQuote{day, rub}))
+		noErr(t, r.SaveQuotes(ctx, []models.RateQuote{hour}))
+		// Повторное сохранение той же котировки не дублирует историю
+		noErr(t, r.SaveQuotes(ctx, []models.RateQuote{hour}))
+
+		quotes, err := r.QuotesAt(ctx, hour.UpdatedAt.Add(-time.Minute))
+		noErr(t, err)
+		if len(quotes) != 1 || quotes[0].Quote != "EUR" {
+			t.Fatalf("quotes = %+v, want USD/EUR a day ago", quotes)
+		}
+		wantDecimal(t, "bid", quotes[0].Bid, "0.8")
+
+		quotes, err = r.QuotesAt(ctx, rub.UpdatedAt)
+		noErr(t, err)
+		if len(quotes) != 2 || quotes[0].Quote != "EUR" || quotes[1].Quote != "RUB" {
+			t.Fatalf("quotes = %+v, want USD/EUR, USD/RUB", quotes)
+		}
+		wantDecimal(t, "bid", quotes[0].Bid, "0.85")
+
+		quotes, err = r.QuotesAt(ctx, day.UpdatedAt.Add(-time.Second))
+		noErr(t, err)
+		if len(quotes) != 0 {
+			t.Fatalf("quotes = %+v before the first quote", quotes)
+		}
+	}},
+	{"SaveQuotesRejectsInvalid", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		for _, q := range []models.RateQuote{
+			quote("USD", "EUR", "0", "1"),
+			quote("USD", "EUR", "-1", "-1"),
+			quote("USD", "EUR", "1.1", "1"),
+			quote("USD", "USD", "1", "1"),
+		} {
+			err := r.SaveQuotes(ctx, []models.RateQuote{q})
+			wantErr(t, err, storage.ErrInvalidQuote)
+		}
+	}},
+	{"Quarantine", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		now := time.Now().UTC().Truncate(time.Second)
+		quotes := []models.QuarantinedQuote{
+			quarantined("USD", "EUR", "2", now.Add(-time.Minute)),
+			quarantined("USD", "EUR", "3", now),
+			quarantined("USD", "RUB", "200", now),
+		}
+		noErr(t, r.QuarantineQuotes(ctx, quotes))
+		for _, q := range quotes {
+			if q.ID == 0 {
+				t.Fatal("QuarantineQuotes did not assign IDs")
+			}
+		}
+
+		halted, err := r.HaltedPairs(ctx)
+		noErr(t, err)
+		if len(halted) != 2 || !halted[[2]string{"USD", "EUR"}] || !halted[[2]string{"USD", "RUB"}] {
+			t.Fatalf("halted = %v, want USD/EUR and USD/RUB", halted)
+		}
+
+		list, err := r.QuarantinedQuotes(ctx, true)
+		noErr(t, err)
+		if len(list) != 3 || list[2].ID != quotes[0].ID {
+			t.Fatalf("quarantined = %+v, want the oldest last", list)
+		}
+
+		// Подтверждение применяет котировку и отклоняет остальные по паре
+		resolved, err := r.ResolveQuarantine(ctx, quotes[1].ID, true, "admin@example.com")
+		noErr(t, err)
+		if resolved.Status != models.QuarantineApproved || resolved.ResolvedBy != "admin@example.com" || resolved.ResolvedAt == nil {
+			t.Fatalf("resolved = %+v", resolved)
+		}
+		applied, err := r.Quotes(ctx)
+		noErr(t, err)
+		if len(applied) != 1 || !applied[0].Bid.Equal(dec("3")) {
+			t.Fatalf("quotes after approval = %+v, want USD/EUR at 3", applied)
+		}
+
+		pending, err := r.QuarantinedQuotes(ctx, true)
+		noErr(t, err)
+		if len(pending) != 1 || pending[0].ID != quotes[2].ID {
+			t.Fatalf("pending = %+v, want only USD/RUB", pending)
+		}
+		all, err := r.QuarantinedQuotes(ctx, false)
+		noErr(t, err)
+		for _, q := range all {
+			if q.ID == quotes[0].ID && q.Status != models.QuarantineRejected {
+				t.Fatalf("older USD/EUR quote status = %q, want rejected", q.Status)
+			}
+		}
+
+		// Отклонение не меняет курсы
+		rejected, err := r.ResolveQuarantine(ctx, quotes[2].ID, false, "admin@example.com")
+		noErr(t, err)
+		if rejected.Status != models.QuarantineRejected {
+			t.Fatalf("status = %q, want rejected", rejected.Status)
+		}
+		applied, err = r.Quotes(ctx)
+		noErr(t, err)
+		if len(applied) != 1 {
+			t.Fatalf("rejected quote was applied: %+v", applied)
+		}
+		halted, err = r.HaltedPairs(ctx)
+		noErr(t, err)
+		if len(halted) != 0 {
+			t.Fatalf("halted = %v after resolution", halted)
+		}
+
+		_, err = r.ResolveQuarantine(ctx, quotes[2].ID, true, "admin@example.com")
+		wantErr(t, err, storage.ErrQuoteNotFound)
+		_, err = r.ResolveQuarantine(ctx, 1<<40, true, "admin@example.com")
+		wantErr(t, err, storage.ErrQuoteNotFound)
+	}},
+}
@@ -0,0 +1,122 @@
+// Package storagetest — общий набор тестов, который задает поведение реализаций
+// storage.Repository. Каждое правило хранилища описано здесь один раз и проверяется
+// и для хранилища в памяти, и для PostgreSQL.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"main/internal/domain/models"
+	"main/internal/storage"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Factory возвращает пустое хранилище: без пользователей, валют и котировок
+type Factory func(t *testing.T) storage.Repository
+
+type test struct {
+	name string
+	fn   func(t *testing.T, r storage.Repository)
+}
+
+// Run запускает набор тестов; для каждого теста создается новое хранилище
+func Run(t *testing.T, newRepo Factory) {
+	var tests []test
+	tests = append(tests, userTests...)
+	tests = append(tests, walletTests...)
+	tests = append(tests, txTests...)
+	tests = append(tests, rateTests...)
+	tests = append(tests, eventTests...)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepo(t))
+		})
+	}
+}
+
+func fiat(code string) models.Currency {
+	return models.Currency{
+		Code:            code,
+		Name:            code,
+		Decimals:        2,
+		Enabled:         true,
+		DepositEnabled:  true,
+		WithdrawEnabled: true,
+		ExchangeEnabled: true,
+		Kind:            models.KindFiat,
+		MinAmount:       decimal.RequireFromString("0.01"),
+	}
+}
+
+func mustSaveCurrency(t *testing.T, r storage.Repository, c models.Currency) {
+	t.Helper()
+	if _, err := r.SaveCurrency(context.Background(), c); err != nil {
+		t.Fatalf("SaveCurrency(%s): %v", c.Code, err)
+	}
+}
+
+func newUser() models.User {
+	id := uuid.New()
+	name := id.String()[:8]
+	return models.User{
+		ID:       id,
+		Username: name,
+		Email:    name + "@example.com",
+		PassHash: []byte("hash"),
+		Language: "ru",
+	}
+}
+
+func mustCreateUser(t *testing.T, r storage.Repository) models.User {
+	t.Helper()
+	user := newUser()
+	if err := r.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return user
+}
+
+// mustWallet возвращает основной кошелек пользователя в валюте
+func mustWallet(t *testing.T, r storage.Repository, userID uuid.UUID, currency string) models.UserWallet {
+	t.Helper()
+	wallets, err := r.Wallets(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("Wallets: %v", err)
+	}
+	for _, w := range wallets {
+		if w.Currency == currency && w.Main() {
+			return w
+		}
+	}
+	t.Fatalf("no %s wallet for user %s", currency, userID)
+	return models.UserWallet{}
+}
+
+func wantErr(t *testing.T, err, want error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Fatalf("got error %v, want %v", err, want)
+	}
+}
+
+func noErr(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func wantDecimal(t *testing.T, what string, got decimal.Decimal, want string) {
+	t.Helper()
+	if !got.Equal(dec(want)) {
+		t.Fatalf("%s = %s, want %s", what, got, want)
+	}
+}
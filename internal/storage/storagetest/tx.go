@@ -0,0 +1,216 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"main/internal/domain/models"
+	"main/internal/storage"
+	"sync"
+	"testing"
+)
+
+var errAbort = errors.New("abort")
+
+var txTests = []test{
+	{"TxCommit", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		mustSaveCurrency(t, r, fiat("USD"))
+		user := newUser()
+
+		noErr(t, r.WithinTx(ctx, func(ctx context.Context) error {
+			if err := r.CreateUser(ctx, user); err != nil {
+				return err
+			}
+			if err := r.AddWalletUser(ctx, user.ID); err != nil {
+				return err
+			}
+			// Внутри транзакции видны ее собственные изменения
+			wallet, err := r.LockWallet(ctx, user.ID, "USD")
+			if err != nil {
+				return err
+			}
+			wallet.Balance = dec("5")
+			return r.SaveWallet(ctx, wallet)
+		}))
+
+		_, err := r.User(ctx, user.Email)
+		noErr(t, err)
+		wantDecimal(t, "balance", mustWallet(t, r, user.ID, "USD").Balance, "5")
+	}},
+	{"TxRollback", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		mustSaveCurrency(t, r, fiat("USD"))
+		owner := mustCreateUser(t, r)
+		wallet, err := r.LockWallet(ctx, owner.ID, "USD")
+		noErr(t, err)
+		user := newUser()
+
+		err = r.WithinTx(ctx, func(ctx context.Context) error {
+			if err := r.CreateUser(ctx, user); err != nil {
+				return err
+			}
+			wallet.Balance = dec("100")
+			if err := r.SaveWallet(ctx, wallet); err != nil {
+				return err
+			}
+			if err := r.AppendEvents(ctx, []models.OutboxEvent{event(owner.ID)}); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		wantErr(t, err, errAbort)
+
+		_, err = r.User(ctx, user.Email)
+		wantErr(t, err, storage.ErrUserNotFound)
+		wantDecimal(t, "balance", mustWallet(t, r, owner.ID, "USD").Balance, "0")
+		events, err := r.PendingEvents(ctx, 10)
+		noErr(t, err)
+		if len(events) != 0 {
+			t.Fatalf("rolled back events are visible: %+v", events)
+		}
+	}},
+	{"TxRollbackOnPanic", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		user := newUser()
+
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("panic was not propagated")
+				}
+			}()
+			_ = r.WithinTx(ctx, func(ctx context.Context) error {
+				if err := r.CreateUser(ctx, user); err != nil {
+					return err
+				}
+				panic("boom")
+			})
+		}()
+
+		_, err := r.User(ctx, user.Email)
+		wantErr(t, err, storage.ErrUserNotFound)
+	}},
+	{"TxNestedJoinsOuter", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		inner, outer := newUser(), newUser()
+
+		err := r.WithinTx(ctx, func(ctx context.Context) error {
+			if err := r.CreateUser(ctx, outer); err != nil {
+				return err
+			}
+			if err := r.WithinTx(ctx, func(ctx context.Context) error {
+				return r.CreateUser(ctx, inner)
+			}); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		wantErr(t, err, errAbort)
+
+		for _, u := range []models.User{inner, outer} {
+			_, err := r.User(ctx, u.Email)
+			wantErr(t, err, storage.ErrUserNotFound)
+		}
+	}},
+	{"TxNestedSavepoint", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		first, inner, last := newUser(), newUser(), newUser()
+		duplicate := newUser()
+		duplicate.Email = first.Email
+
+		noErr(t, r.WithinTx(ctx, func(ctx context.Context) error {
+			if err := r.CreateUser(ctx, first); err != nil {
+				return err
+			}
+			// Ошибка вложенной транзакции откатывает только ее изменения
+			err := r.WithinTx(ctx, func(ctx context.Context) error {
+				if err := r.CreateUser(ctx, inner); err != nil {
+					return err
+				}
+				return errAbort
+			})
+			wantErr(t, err, errAbort)
+			// Транзакция остается рабочей и после ошибки запроса внутри savepoint
+			err = r.WithinTx(ctx, func(ctx context.Context) error {
+				return r.CreateUser(ctx, duplicate)
+			})
+			wantErr(t, err, storage.ErrUserExists)
+			return r.CreateUser(ctx, last)
+		}))
+
+		for _, u := range []models.User{first, last} {
+			_, err := r.User(ctx, u.Email)
+			noErr(t, err)
+		}
+		_, err := r.User(ctx, inner.Email)
+		wantErr(t, err, storage.ErrUserNotFound)
+	}},
+	{"FailedWriteIsAtomic", func(t *testing.T, r storage.Repository) {
+		// Ошибка отдельного метода вне транзакции не оставляет частичных изменений
+		ctx := context.Background()
+		err := r.SaveQuotes(ctx, []models.RateQuote{
+			quote("USD", "EUR", "0.9", "0.91"),
+			quote("USD", "RUB", "0", "0"),
+		})
+		wantErr(t, err, storage.ErrInvalidQuote)
+
+		quotes, err := r.Quotes(ctx)
+		noErr(t, err)
+		if len(quotes) != 0 {
+			t.Fatalf("partial batch saved: %+v", quotes)
+		}
+	}},
+	{"TxCanceledContext", func(t *testing.T, r storage.Repository) {
+		ctx, cancel := context.WithCancel(context.Background())
+		user := newUser()
+
+		err := r.WithinTx(ctx, func(ctx context.Context) error {
+			if err := r.CreateUser(ctx, user); err != nil {
+				return err
+			}
+			cancel()
+			return nil
+		})
+		if err == nil {
+			t.Fatal("transaction with canceled context committed")
+		}
+
+		_, err = r.User(context.Background(), user.Email)
+		wantErr(t, err, storage.ErrUserNotFound)
+	}},
+	{"TxLockWalletSerializesUpdates", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		mustSaveCurrency(t, r, fiat("USD"))
+		user := mustCreateUser(t, r)
+
+		const workers = 10
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- r.WithinTx(ctx, func(ctx context.Context) error {
+					wallet, err := r.LockWallet(ctx, user.ID, "USD")
+					if err != nil {
+						return err
+					}
+					wallet.Balance = wallet.Balance.Add(dec("1"))
+					return r.SaveWallet(ctx, wallet)
+				})
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			noErr(t, err)
+		}
+
+		wallets, err := r.Wallets(ctx, user.ID)
+		noErr(t, err)
+		if len(wallets) != 1 {
+			t.Fatalf("got %d wallets, want 1", len(wallets))
+		}
+		wantDecimal(t, "balance", wallets[0].Balance, "10")
+	}},
+}
@@ -0,0 +1,247 @@
+package storagetest
+
+import (
+	"context"
+	"main/internal/domain/models"
+	"main/internal/storage"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+var userTests = []test{
+	{"CreateUser", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		user := newUser()
+		noErr(t, r.CreateUser(ctx, user))
+
+		got, err := r.User(ctx, user.Email)
+		noErr(t, err)
+		if got.ID != user.ID || got.Username != user.Username || got.Language != user.Language {
+			t.Fatalf("got %+v, want %+v", got, user)
+		}
+		if got.Role != models.RoleUser {
+			t.Fatalf("role = %q, want %q", got.Role, models.RoleUser)
+		}
+		if string(got.PassHash) != string(user.PassHash) {
+			t.Fatalf("pass hash = %q, want %q", got.PassHash, user.PassHash)
+		}
+	}},
+	{"CreateUserDuplicate", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		user := mustCreateUser(t, r)
+
+		sameEmail := newUser()
+		sameEmail.Email = user.Email
+		wantErr(t, r.CreateUser(ctx, sameEmail), storage.ErrUserExists)
+
+		sameName := newUser()
+		sameName.Username = user.Username
+		wantErr(t, r.CreateUser(ctx, sameName), storage.ErrUserExists)
+	}},
+	{"UserNotFound", func(t *testing.T, r storage.Repository) {
+		_, err := r.User(context.Background(), "nobody@example.com")
+		wantErr(t, err, storage.ErrUserNotFound)
+	}},
+}
+
+var walletTests = []test{
+	{"AddWalletUser", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		mustSaveCurrency(t, r, fiat("USD"))
+		mustSaveCurrency(t, r, fiat("EUR"))
+		disabled := fiat("RUB")
+		disabled.Enabled = false
+		mustSaveCurrency(t, r, disabled)
+		user := mustCreateUser(t, r)
+
+		noErr(t, r.AddWalletUser(ctx, user.ID))
+		// Повторный вызов не создает дубликатов
+		noErr(t, r.AddWalletUser(ctx, user.ID))
+
+		wallets, err := r.Wallets(ctx, user.ID)
+		noErr(t, err)
+		var codes []string
+		for _, w := range wallets {
+			if w.UserID != user.ID || !w.Balance.IsZero() {
+				t.Fatalf("unexpected wallet %+v", w)
+			}
+			codes = append(codes, w.Currency)
+		}
+		if !slices.Equal(codes, []string{"EUR", "USD"}) {
+			t.Fatalf("wallets = %v, want [EUR USD]", codes)
+		}
+
+		wantErr(t, r.AddWalletUser(ctx, uuid.New()), storage.ErrUserNotFound)
+	}},
+	{"LockWalletCreatesWallet", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		mustSaveCurrency(t, r, fiat("USD"))
+		user := mustCreateUser(t, r)
+
+		var first, second models.UserWallet
+		noErr(t, r.WithinTx(ctx, func(ctx context.Context) error {
+			var err error
+			first, err = r.LockWallet(ctx, user.ID, "USD")
+			return err
+		}))
+		second, err := r.LockWallet(ctx, user.ID, "USD")
+		noErr(t, err)
+		if first.ID != second.ID || first.Currency != "USD" || !first.Balance.IsZero() {
+			t.Fatalf("got %+v and %+v, want the same empty USD wallet", first, second)
+		}
+
+		_, err = r.LockWallet(ctx, user.ID, "XXX")
+		wantErr(t, err, storage.ErrCurrencyNotFound)
+		_, err = r.LockWallet(ctx, uuid.New(), "USD")
+		wantErr(t, err, storage.ErrUserNotFound)
+	}},
+	{"SaveWallet", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		mustSaveCurrency(t, r, fiat("USD"))
+		user := mustCreateUser(t, r)
+		wallet, err := r.LockWallet(ctx, user.ID, "USD")
+		noErr(t, err)
+
+		wallet.Balance = dec("10.5")
+		noErr(t, r.SaveWallet(ctx, wallet))
+		wantDecimal(t, "balance", mustWallet(t, r, user.ID, "USD").Balance, "10.5")
+
+		wallet.Balance = dec("-0.01")
+		wantErr(t, r.SaveWallet(ctx, wallet), storage.ErrInsufficientFunds)
+		wantDecimal(t, "balance", mustWallet(t, r, user.ID, "USD").Balance, "10.5")
+
+		wallet.ID = uuid.New()
+		wallet.Balance = dec("1")
+		wantErr(t, r.SaveWallet(ctx, wallet), storage.ErrWalletNotFound)
+	}},
+	{"NamedWallets", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		mustSaveCurrency(t, r, fiat("USD"))
+		user := mustCreateUser(t, r)
+		noErr(t, r.AddWalletUser(ctx, user.ID))
+		mainWallet := mustWallet(t, r, user.ID, "USD")
+
+		savings, err := r.CreateWallet(ctx, models.UserWallet{UserID: user.ID, Currency: "USD", Name: "Savings"})
+		noErr(t, err)
+		if savings.ID == uuid.Nil || savings.Main() || !savings.Balance.IsZero() {
+			t.Fatalf("created wallet %+v", savings)
+		}
+		_, err = r.CreateWallet(ctx, models.UserWallet{UserID: user.ID, Currency: "USD", Name: "SAVINGS"})
+		wantErr(t, err, storage.ErrWalletExists)
+		travel, err := r.CreateWallet(ctx, models.UserWallet{UserID: user.ID, Currency: "USD", Name: "Travel"})
+		noErr(t, err)
+
+		// Дополнительные кошельки не мешают основному
+		noErr(t, r.AddWalletUser(ctx, user.ID))
+		locked, err := r.LockWallet(ctx, user.ID, "USD")
+		noErr(t, err)
+		if locked.ID != mainWallet.ID {
+			t.Fatalf("LockWallet returned %+v, want the main wallet", locked)
+		}
+		wallets, err := r.Wallets(ctx, user.ID)
+		noErr(t, err)
+		var names []string
+		for _, w := range wallets {
+			names = append(names, w.Name)
+		}
+		if !slices.Equal(names, []string{"", "Savings", "Travel"}) {
+			t.Fatalf("wallets = %q, want the main wallet, Savings and Travel", names)
+		}
+
+		got, err := r.LockWalletByID(ctx, user.ID, savings.ID)
+		noErr(t, err)
+		if got.Name != "Savings" || got.Currency != "USD" {
+			t.Fatalf("LockWalletByID = %+v", got)
+		}
+		_, err = r.LockWalletByID(ctx, mustCreateUser(t, r).ID, savings.ID)
+		wantErr(t, err, storage.ErrWalletNotFound)
+
+		// Занятое название нельзя взять при переименовании, но после архивации оно свободно
+		travel.Name = "savings"
+		wantErr(t, r.SaveWallet(ctx, travel), storage.ErrWalletExists)
+		savings.Archived = true
+		noErr(t, r.SaveWallet(ctx, savings))
+		noErr(t, r.SaveWallet(ctx, travel))
+		got, err = r.LockWalletByID(ctx, user.ID, savings.ID)
+		noErr(t, err)
+		if !got.Archived || got.Name != "Savings" {
+			t.Fatalf("archived wallet = %+v", got)
+		}
+
+		_, err = r.CreateWallet(ctx, models.UserWallet{UserID: user.ID, Currency: "XXX", Name: "Unknown"})
+		wantErr(t, err, storage.ErrCurrencyNotFound)
+		_, err = r.CreateWallet(ctx, models.UserWallet{UserID: uuid.New(), Currency: "USD", Name: "Orphan"})
+		wantErr(t, err, storage.ErrUserNotFound)
+	}},
+	{"SaveCurrency", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		btc := models.Currency{Code: "BTC", Name: "Bitcoin", Decimals: 8, Enabled: true, ExchangeEnabled: true,
+			Kind: models.KindCrypto, MinAmount: dec("0.00001"), ProviderID: "bitcoin"}
+		saved, err := r.SaveCurrency(ctx, btc)
+		noErr(t, err)
+		if saved.Code != "BTC" || saved.Decimals != 8 || saved.DepositEnabled || !saved.ExchangeEnabled || saved.ProviderID != "bitcoin" {
+			t.Fatalf("got %+v, want %+v", saved, btc)
+		}
+		wantDecimal(t, "min amount", saved.MinAmount, "0.00001")
+
+		btc.Name = "Bitcoin Core"
+		_, err = r.SaveCurrency(ctx, btc)
+		noErr(t, err)
+		got, err := r.Currency(ctx, "BTC")
+		noErr(t, err)
+		if got.Name != "Bitcoin Core" {
+			t.Fatalf("name = %q after update", got.Name)
+		}
+
+		_, err = r.Currency(ctx, "XXX")
+		wantErr(t, err, storage.ErrCurrencyNotFound)
+	}},
+	{"Currencies", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		mustSaveCurrency(t, r, fiat("USD"))
+		mustSaveCurrency(t, r, fiat("EUR"))
+		_, err := r.SetCurrencyEnabled(ctx, "USD", false)
+		noErr(t, err)
+
+		all, err := r.Currencies(ctx, false)
+		noErr(t, err)
+		enabled, err := r.Currencies(ctx, true)
+		noErr(t, err)
+		if len(all) != 2 || all[0].Code != "EUR" || all[1].Code != "USD" {
+			t.Fatalf("all currencies = %+v, want EUR, USD", all)
+		}
+		if len(enabled) != 1 || enabled[0].Code != "EUR" {
+			t.Fatalf("enabled currencies = %+v, want EUR", enabled)
+		}
+
+		_, err = r.SetCurrencyEnabled(ctx, "XXX", true)
+		wantErr(t, err, storage.ErrCurrencyNotFound)
+	}},
+	{"EnableCurrencyBackfillsWallets", func(t *testing.T, r storage.Repository) {
+		ctx := context.Background()
+		mustSaveCurrency(t, r, fiat("USD"))
+		user := mustCreateUser(t, r)
+		noErr(t, r.AddWalletUser(ctx, user.ID))
+
+		eur := fiat("EUR")
+		eur.Enabled = false
+		mustSaveCurrency(t, r, eur)
+		wallets, err := r.Wallets(ctx, user.ID)
+		noErr(t, err)
+		if len(wallets) != 1 {
+			t.Fatalf("disabled currency got wallets: %+v", wallets)
+		}
+
+		got, err := r.SetCurrencyEnabled(ctx, "EUR", true)
+		noErr(t, err)
+		if !got.Enabled {
+			t.Fatal("currency is not enabled")
+		}
+		mustWallet(t, r, user.ID, "EUR")
+
+		mustSaveCurrency(t, r, fiat("GBP"))
+		mustWallet(t, r, user.ID, "GBP")
+	}},
+}
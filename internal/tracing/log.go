@@ -0,0 +1,36 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LogHandler добавляет trace_id и span_id к записям, залогированным
+// с контекстом (InfoContext и т.п.), если в нем есть спан.
+type LogHandler struct {
+	slog.Handler
+}
+
+func NewLogHandler(h slog.Handler) *LogHandler {
+	return &LogHandler{Handler: h}
+}
+
+func (h *LogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithGroup(name)}
+}
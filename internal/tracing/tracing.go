@@ -0,0 +1,96 @@
+// Package tracing настраивает OpenTelemetry: провайдер трассировок,
+// экспортер и распространение контекста между сервисами.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Имя инструментирования для спанов сервиса
+const tracerName = "gw-exchanger"
+
+// Экспортеры
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Options — параметры трассировки
+type Options struct {
+	Exporter    string  // none, stdout, otlp
+	Endpoint    string  // Адрес OTLP/gRPC коллектора, host:port
+	Insecure    bool    // Подключаться к коллектору без TLS
+	SampleRatio float64 // Доля трассируемых запросов, 1 — все
+	ServiceName string
+}
+
+// Setup устанавливает глобальный провайдер трассировок и возвращает функцию,
+// которая выгружает накопленные спаны при остановке.
+// При Exporter = none спаны не создаются, но контекст трассировки клиентов передается дальше.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	const op = "tracing.Setup"
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch opts.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		clientOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(opts.Endpoint)}
+		if opts.Insecure {
+			clientOpts = append(clientOpts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, clientOpts...)
+	default:
+		return nil, fmt.Errorf("%s: unknown exporter %q", op, opts.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(opts.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Start создает дочерний спан операции
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, opts...)
+}
+
+// Fail отмечает спан ошибкой
+func Fail(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,131 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"main/internal/tracing"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// record устанавливает глобальный провайдер, который запоминает завершенные спаны
+func record(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name     string
+		exporter string
+		wantErr  bool
+	}{
+		{"default", "", false},
+		{"none", tracing.ExporterNone, false},
+		{"unknown", "jaeger", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shutdown, err := tracing.Setup(context.Background(), tracing.Options{Exporter: tt.exporter})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Setup succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := shutdown(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// Без экспортера контекст трассировки клиента все равно передается дальше
+func TestSetupPropagates(t *testing.T) {
+	if _, err := tracing.Setup(context.Background(), tracing.Options{Exporter: tracing.ExporterNone}); err != nil {
+		t.Fatal(err)
+	}
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	carrier := propagation.MapCarrier{"traceparent": traceparent}
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), carrier)
+
+	out := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, out)
+	if out["traceparent"] != traceparent {
+		t.Fatalf("traceparent = %q, want %q", out["traceparent"], traceparent)
+	}
+}
+
+func TestStartFail(t *testing.T) {
+	recorder := record(t)
+
+	ctx, parent := tracing.Start(context.Background(), "exchange.ExchangeCurrency")
+	_, child := tracing.Start(ctx, "postgresql.Exchange")
+	tracing.Fail(child, errors.New("insufficient funds"))
+	child.End()
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("spans = %d, want 2", len(spans))
+	}
+	failed, root := spans[0], spans[1]
+	if failed.Name() != "postgresql.Exchange" || failed.Parent().SpanID() != root.SpanContext().SpanID() {
+		t.Fatalf("child span %q is not a child of %q", failed.Name(), root.Name())
+	}
+	if failed.Status().Code != codes.Error || failed.Status().Description != "insufficient funds" {
+		t.Fatalf("status = %+v, want error", failed.Status())
+	}
+	if len(failed.Events()) != 1 || failed.Events()[0].Name != "exception" {
+		t.Fatalf("events = %+v, want recorded error", failed.Events())
+	}
+	if root.Status().Code != codes.Unset {
+		t.Fatalf("parent status = %+v, want unset", root.Status())
+	}
+}
+
+func TestLogHandler(t *testing.T) {
+	record(t)
+	var buf bytes.Buffer
+	log := slog.New(tracing.NewLogHandler(slog.NewJSONHandler(&buf, nil))).With(slog.String("op", "test"))
+
+	entry := func() map[string]any {
+		t.Helper()
+		var m map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+			t.Fatal(err)
+		}
+		buf.Reset()
+		return m
+	}
+
+	// Без спана в контексте идентификаторы не добавляются
+	log.InfoContext(context.Background(), "no span")
+	if m := entry(); m["trace_id"] != nil || m["op"] != "test" {
+		t.Fatalf("entry = %v, want op without trace_id", m)
+	}
+
+	ctx, span := tracing.Start(context.Background(), "op")
+	defer span.End()
+	// Обработчик остается LogHandler после With
+	log.InfoContext(ctx, "with span")
+	m := entry()
+	if m["trace_id"] != span.SpanContext().TraceID().String() || m["span_id"] != span.SpanContext().SpanID().String() {
+		t.Fatalf("entry = %v, want trace_id %s and span_id %s", m, span.SpanContext().TraceID(), span.SpanContext().SpanID())
+	}
+}
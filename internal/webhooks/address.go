@@ -0,0 +1,71 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// ErrForbiddenAddress — получатель находится во внутренней сети
+var ErrForbiddenAddress = errors.New("адрес получателя webhook недоступен")
+
+// Диапазоны, которых нет среди методов netip.Addr: "эта сеть" и адреса CGNAT
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// PublicAddr сообщает, можно ли отправлять webhook на адрес ip.
+// Запрещены loopback, link-local, частные, multicast и неуказанные адреса,
+// чтобы через webhook нельзя было обратиться к сервисам внутренней сети.
+func PublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, p := range forbiddenPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// newClient создает HTTP-клиент доставки. Адрес проверяется при каждом соединении,
+// уже после разрешения имени, поэтому DNS-запись, сменившаяся после проверки URL,
+// не приведет во внутреннюю сеть. Прокси из окружения не используется: иначе
+// проверялся бы адрес прокси, а не получателя. Редиректы не выполняются —
+// ответ 3xx считается неудачной доставкой.
+func newClient(timeout time.Duration, allow func(netip.Addr) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+			}
+			if !allow(addr.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr.Addr())
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: otelhttp.NewTransport(transport),
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,109 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"main/internal/domain/models"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"0.1.2.3", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := PublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Fatalf("PublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+// memStore отдает одну доставку и запоминает результат попытки
+type memStore struct {
+	job     models.WebhookJob
+	claimed bool
+	saved   models.WebhookAttempt
+}
+
+func (s *memStore) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookJob, error) {
+	if s.claimed {
+		return nil, nil
+	}
+	s.claimed = true
+	return []models.WebhookJob{s.job}, nil
+}
+
+func (s *memStore) SaveAttempt(ctx context.Context, delivery models.WebhookDelivery, attempt models.WebhookAttempt) error {
+	s.saved = attempt
+	return nil
+}
+
+// Клиент по умолчанию не соединяется с loopback, даже если URL прошел проверку
+func TestDispatcherRefusesInternalAddress(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits.Add(1) }))
+	defer srv.Close()
+
+	for _, target := range []string{srv.URL, strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)} {
+		store := &memStore{job: models.WebhookJob{URL: target, Delivery: models.WebhookDelivery{ID: 1, Payload: []byte(`{}`)}}}
+		d := NewDispatcher(slog.New(slog.NewTextHandler(io.Discard, nil)), store, nil, Options{})
+		if _, err := d.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(store.saved.Error, ErrForbiddenAddress.Error()) {
+			t.Fatalf("%s: attempt error = %q, want forbidden address", target, store.saved.Error)
+		}
+	}
+	if hits.Load() != 0 {
+		t.Fatalf("receiver was called %d times", hits.Load())
+	}
+}
+
+func TestClientRefusesRedirect(t *testing.T) {
+	var redirected atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hook", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/internal", http.StatusTemporaryRedirect)
+	})
+	mux.HandleFunc("/internal", func(w http.ResponseWriter, r *http.Request) { redirected.Add(1) })
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := newClient(time.Second, func(netip.Addr) bool { return true })
+	resp, err := client.Post(srv.URL+"/hook", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTemporaryRedirect || redirected.Load() != 0 {
+		t.Fatalf("status = %d, redirected = %d; want 307 without following", resp.StatusCode, redirected.Load())
+	}
+}
@@ -0,0 +1,205 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"main/internal/domain/models"
+	"main/internal/lib/logger/sl"
+	"main/internal/lib/text"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Длина сохраняемого текста ошибки, символов
+const maxErrorLength = 1000
+
+// Store — хранилище очереди доставок
+type Store interface {
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookJob, error)
+	SaveAttempt(ctx context.Context, delivery models.WebhookDelivery, attempt models.WebhookAttempt) error
+}
+
+// Options — параметры доставки
+type Options struct {
+	Interval    time.Duration // Период опроса очереди
+	Timeout     time.Duration // Таймаут одного запроса
+	MaxAttempts int           // После стольких неудач доставка уходит в dead letter
+	BackoffBase time.Duration // Задержка перед второй попыткой, далее удваивается
+	BackoffMax  time.Duration // Максимальная задержка между попытками
+	BatchSize   int
+}
+
+func (o Options) withDefaults() Options {
+	if o.Interval <= 0 {
+		o.Interval = time.Second
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 8
+	}
+	if o.BackoffBase <= 0 {
+		o.BackoffBase = 10 * time.Second
+	}
+	if o.BackoffMax <= 0 {
+		o.BackoffMax = time.Hour
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 50
+	}
+	return o
+}
+
+// Dispatcher отправляет webhook из очереди доставок
+type Dispatcher struct {
+	log    *slog.Logger
+	store  Store
+	client *http.Client
+	opts   Options
+}
+
+// NewDispatcher создает рассыльщика. Если client не передан, используется клиент,
+// который не соединяется с адресами внутренней сети и не следует редиректам.
+func NewDispatcher(log *slog.Logger, store Store, client *http.Client, opts Options) *Dispatcher {
+	opts = opts.withDefaults()
+	if client == nil {
+		client = newClient(opts.Timeout, PublicAddr)
+	}
+	return &Dispatcher{log: log, store: store, client: client, opts: opts}
+}
+
+// Run обрабатывает очередь до отмены ctx
+func (d *Dispatcher) Run(ctx context.Context) {
+	const op = "webhooks.Dispatcher.Run"
+	log := d.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(d.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for {
+			n, err := d.Flush(ctx)
+			if err != nil {
+				log.Error("failed to dispatch webhooks", sl.Err(err))
+				break
+			}
+			if n < d.opts.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+	}
+}
+
+// Flush отправляет одну пачку доставок и возвращает ее размер
+func (d *Dispatcher) Flush(ctx context.Context) (int, error) {
+	// Lease покрывает отправку всей пачки, чтобы другой экземпляр не взял те же доставки
+	lease := d.opts.Timeout*time.Duration(d.opts.BatchSize) + d.opts.Interval
+	jobs, err := d.store.ClaimDeliveries(ctx, d.opts.BatchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, job := range jobs {
+		delivery, attempt := d.deliver(ctx, job)
+		if err := d.store.SaveAttempt(ctx, delivery, attempt); err != nil {
+			return len(jobs), err
+		}
+	}
+	return len(jobs), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, job models.WebhookJob) (models.WebhookDelivery, models.WebhookAttempt) {
+	const op = "webhooks.Dispatcher.deliver"
+	log := d.log.With(
+		slog.String("op", op),
+		slog.Uint64("delivery_id", job.Delivery.ID),
+		slog.String("event_type", job.Delivery.EventType),
+	)
+
+	delivery := job.Delivery
+	delivery.Attempts++
+	started := time.Now()
+
+	code, err := d.send(ctx, job)
+
+	attempt := models.WebhookAttempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts,
+		StatusCode: code,
+		DurationMs: time.Since(started).Milliseconds(),
+		CreatedAt:  started,
+	}
+	delivery.LastStatusCode = code
+
+	if err == nil {
+		now := time.Now()
+		delivery.Status = models.DeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		log.Debug("webhook delivered", slog.Int("attempt", delivery.Attempts))
+		return delivery, attempt
+	}
+
+	attempt.Error = text.Truncate(err.Error(), maxErrorLength)
+	delivery.LastError = attempt.Error
+	if delivery.Attempts >= d.opts.MaxAttempts {
+		delivery.Status = models.DeliveryDead
+		log.Warn("webhook moved to dead letter", slog.Int("attempts", delivery.Attempts), sl.Err(err))
+		return delivery, attempt
+	}
+
+	delivery.Status = models.DeliveryPending
+	delivery.NextAttemptAt = time.Now().Add(d.backoff(delivery.Attempts))
+	log.Info("webhook delivery failed", slog.Int("attempt", delivery.Attempts), sl.Err(err))
+	return delivery, attempt
+}
+
+func (d *Dispatcher) send(ctx context.Context, job models.WebhookJob) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(job.Delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, job.Delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(job.Delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(job.Secret, timestamp, job.Delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("получатель вернул статус %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff — экспоненциальная задержка с джиттером ±20%, не больше BackoffMax
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.opts.BackoffBase
+	for i := 1; i < attempt && delay < d.opts.BackoffMax; i++ {
+		delay *= 2
+	}
+	jitter := time.Duration(float64(delay) * (rand.Float64()*0.4 - 0.2))
+	return min(delay+jitter, d.opts.BackoffMax)
+}
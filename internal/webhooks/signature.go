@@ -0,0 +1,31 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Заголовки запроса webhook
+const (
+	HeaderSignature = "X-Webhook-Signature" // sha256=<hex HMAC-SHA256>
+	HeaderTimestamp = "X-Webhook-Timestamp" // unix, секунды
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+)
+
+// Sign подписывает тело запроса: HMAC-SHA256(secret, "<timestamp>.<body>").
+// Метка времени входит в подпись, чтобы получатель мог отбрасывать старые повторы.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись запроса на стороне получателя
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,6 @@
+// Package proto содержит описание gRPC API и сгенерированный из него код.
+package proto
+
+//go:generate protoc -I . --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative user/user.proto
+//go:generate protoc -I . --grpc-gateway_out=. --grpc-gateway_opt=paths=source_relative,grpc_api_configuration=user/gateway.yaml user/user.proto
+//go:generate protoc -I . --openapiv2_out=. --openapiv2_opt=grpc_api_configuration=user/gateway.yaml,openapi_naming_strategy=simple user/user.proto
@@ -0,0 +1,8 @@
+package proto
+
+import _ "embed"
+
+// OpenAPI — спецификация REST gateway, сгенерированная protoc-gen-openapiv2 из user/user.proto
+//
+//go:embed user/user.swagger.json
+var OpenAPI []byte
@@ -0,0 +1,1407 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.2
+// source: user/user.proto
+
+package user
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	WebhookService_CreateWebhook_FullMethodName  = "/user.WebhookService/CreateWebhook"
+	WebhookService_ListWebhooks_FullMethodName   = "/user.WebhookService/ListWebhooks"
+	WebhookService_DeleteWebhook_FullMethodName  = "/user.WebhookService/DeleteWebhook"
+	WebhookService_ListDeliveries_FullMethodName = "/user.WebhookService/ListDeliveries"
+	WebhookService_RetryDelivery_FullMethodName  = "/user.WebhookService/RetryDelivery"
+)
+
+// WebhookServiceClient is the client API for WebhookService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Webhook-уведомления о событиях счета владельца токена
+type WebhookServiceClient interface {
+	// Подписка URL на события (WalletCredited, WalletDebited, CurrencyExchanged)
+	CreateWebhook(ctx context.Context, in *CreateWebhookRequest, opts ...grpc.CallOption) (*WebhookResponse, error)
+	// Подписки пользователя
+	ListWebhooks(ctx context.Context, in *ListWebhooksRequest, opts ...grpc.CallOption) (*ListWebhooksResponse, error)
+	// Удаление подписки
+	DeleteWebhook(ctx context.Context, in *WebhookRequest, opts ...grpc.CallOption) (*WebhookResponse, error)
+	// Журнал доставок
+	ListDeliveries(ctx context.Context, in *ListDeliveriesRequest, opts ...grpc.CallOption) (*ListDeliveriesResponse, error)
+	// Повторная отправка доставки, в том числе из dead letter
+	RetryDelivery(ctx context.Context, in *RetryDeliveryRequest, opts ...grpc.CallOption) (*WebhookDelivery, error)
+}
+
+type webhookServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWebhookServiceClient(cc grpc.ClientConnInterface) WebhookServiceClient {
+	return &webhookServiceClient{cc}
+}
+
+func (c *webhookServiceClient) CreateWebhook(ctx context.Context, in *CreateWebhookRequest, opts ...grpc.CallOption) (*WebhookResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WebhookResponse)
+	err := c.cc.Invoke(ctx, WebhookService_CreateWebhook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *webhookServiceClient) ListWebhooks(ctx context.Context, in *ListWebhooksRequest, opts ...grpc.CallOption) (*ListWebhooksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWebhooksResponse)
+	err := c.cc.Invoke(ctx, WebhookService_ListWebhooks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *webhookServiceClient) DeleteWebhook(ctx context.Context, in *WebhookRequest, opts ...grpc.CallOption) (*WebhookResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WebhookResponse)
+	err := c.cc.Invoke(ctx, WebhookService_DeleteWebhook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *webhookServiceClient) ListDeliveries(ctx context.Context, in *ListDeliveriesRequest, opts ...grpc.CallOption) (*ListDeliveriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDeliveriesResponse)
+	err := c.cc.Invoke(ctx, WebhookService_ListDeliveries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *webhookServiceClient) RetryDelivery(ctx context.Context, in *RetryDeliveryRequest, opts ...grpc.CallOption) (*WebhookDelivery, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WebhookDelivery)
+	err := c.cc.Invoke(ctx, WebhookService_RetryDelivery_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// WebhookServiceServer is the server API for WebhookService service.
+// All implementations must embed UnimplementedWebhookServiceServer
+// for forward compatibility.
+//
+// Webhook-уведомления о событиях счета владельца токена
+type WebhookServiceServer interface {
+	// Подписка URL на события (WalletCredited, WalletDebited, CurrencyExchanged)
+	CreateWebhook(context.Context, *CreateWebhookRequest) (*WebhookResponse, error)
+	// Подписки пользователя
+	ListWebhooks(context.Context, *ListWebhooksRequest) (*ListWebhooksResponse, error)
+	// Удаление подписки
+	DeleteWebhook(context.Context, *WebhookRequest) (*WebhookResponse, error)
+	// Журнал доставок
+	ListDeliveries(context.Context, *ListDeliveriesRequest) (*ListDeliveriesResponse, error)
+	// Повторная отправка доставки, в том числе из dead letter
+	RetryDelivery(context.Context, *RetryDeliveryRequest) (*WebhookDelivery, error)
+	mustEmbedUnimplementedWebhookServiceServer()
+}
+
+// UnimplementedWebhookServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedWebhookServiceServer struct{}
+
+func (UnimplementedWebhookServiceServer) CreateWebhook(context.Context, *CreateWebhookRequest) (*WebhookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateWebhook not implemented")
+}
+func (UnimplementedWebhookServiceServer) ListWebhooks(context.Context, *ListWebhooksRequest) (*ListWebhooksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWebhooks not implemented")
+}
+func (UnimplementedWebhookServiceServer) DeleteWebhook(context.Context, *WebhookRequest) (*WebhookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteWebhook not implemented")
+}
+func (UnimplementedWebhookServiceServer) ListDeliveries(context.Context, *ListDeliveriesRequest) (*ListDeliveriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDeliveries not implemented")
+}
+func (UnimplementedWebhookServiceServer) RetryDelivery(context.Context, *RetryDeliveryRequest) (*WebhookDelivery, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetryDelivery not implemented")
+}
+func (UnimplementedWebhookServiceServer) mustEmbedUnimplementedWebhookServiceServer() {}
+func (UnimplementedWebhookServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeWebhookServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to WebhookServiceServer will
+// result in compilation errors.
+type UnsafeWebhookServiceServer interface {
+	mustEmbedUnimplementedWebhookServiceServer()
+}
+
+func RegisterWebhookServiceServer(s grpc.ServiceRegistrar, srv WebhookServiceServer) {
+	// If the following call pancis, it indicates UnimplementedWebhookServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&WebhookService_ServiceDesc, srv)
+}
+
+func _WebhookService_CreateWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateWebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WebhookServiceServer).CreateWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WebhookService_CreateWebhook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WebhookServiceServer).CreateWebhook(ctx, req.(*CreateWebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WebhookService_ListWebhooks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWebhooksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WebhookServiceServer).ListWebhooks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WebhookService_ListWebhooks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WebhookServiceServer).ListWebhooks(ctx, req.(*ListWebhooksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WebhookService_DeleteWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WebhookServiceServer).DeleteWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WebhookService_DeleteWebhook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WebhookServiceServer).DeleteWebhook(ctx, req.(*WebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WebhookService_ListDeliveries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeliveriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WebhookServiceServer).ListDeliveries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WebhookService_ListDeliveries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WebhookServiceServer).ListDeliveries(ctx, req.(*ListDeliveriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WebhookService_RetryDelivery_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetryDeliveryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WebhookServiceServer).RetryDelivery(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WebhookService_RetryDelivery_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WebhookServiceServer).RetryDelivery(ctx, req.(*RetryDeliveryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// WebhookService_ServiceDesc is the grpc.ServiceDesc for WebhookService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var WebhookService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "user.WebhookService",
+	HandlerType: (*WebhookServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateWebhook",
+			Handler:    _WebhookService_CreateWebhook_Handler,
+		},
+		{
+			MethodName: "ListWebhooks",
+			Handler:    _WebhookService_ListWebhooks_Handler,
+		},
+		{
+			MethodName: "DeleteWebhook",
+			Handler:    _WebhookService_DeleteWebhook_Handler,
+		},
+		{
+			MethodName: "ListDeliveries",
+			Handler:    _WebhookService_ListDeliveries_Handler,
+		},
+		{
+			MethodName: "RetryDelivery",
+			Handler:    _WebhookService_RetryDelivery_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "user/user.proto",
+}
+
+const (
+	ExchangeService_GetExchangeRates_FullMethodName = "/user.ExchangeService/GetExchangeRates"
+	ExchangeService_ExchangeCurrency_FullMethodName = "/user.ExchangeService/ExchangeCurrency"
+	ExchangeService_ListCurrencies_FullMethodName   = "/user.ExchangeService/ListCurrencies"
+	ExchangeService_GetQuote_FullMethodName         = "/user.ExchangeService/GetQuote"
+	ExchangeService_SubscribeRates_FullMethodName   = "/user.ExchangeService/SubscribeRates"
+)
+
+// ExchangeServiceClient is the client API for ExchangeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Определение сервиса
+type ExchangeServiceClient interface {
+	// Получение курсов обмена всех валют
+	GetExchangeRates(ctx context.Context, in *RatesRequest, opts ...grpc.CallOption) (*ExchangeRatesResponse, error)
+	// Обмен валют
+	ExchangeCurrency(ctx context.Context, in *ExchangeRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
+	// Список доступных валют (без авторизации)
+	ListCurrencies(ctx context.Context, in *ListCurrenciesRequest, opts ...grpc.CallOption) (*ListCurrenciesResponse, error)
+	// Курс пары валют (bid/ask) и путь расчета
+	GetQuote(ctx context.Context, in *QuoteRequest, opts ...grpc.CallOption) (*PairQuote, error)
+	// Поток курсов: текущие значения, затем изменения после каждого обновления
+	SubscribeRates(ctx context.Context, in *SubscribeRatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RatesEvent], error)
+}
+
+type exchangeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewExchangeServiceClient(cc grpc.ClientConnInterface) ExchangeServiceClient {
+	return &exchangeServiceClient{cc}
+}
+
+func (c *exchangeServiceClient) GetExchangeRates(ctx context.Context, in *RatesRequest, opts ...grpc.CallOption) (*ExchangeRatesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExchangeRatesResponse)
+	err := c.cc.Invoke(ctx, ExchangeService_GetExchangeRates_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exchangeServiceClient) ExchangeCurrency(ctx context.Context, in *ExchangeRequest, opts ...grpc.CallOption) (*TransactionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransactionResponse)
+	err := c.cc.Invoke(ctx, ExchangeService_ExchangeCurrency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exchangeServiceClient) ListCurrencies(ctx context.Context, in *ListCurrenciesRequest, opts ...grpc.CallOption) (*ListCurrenciesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCurrenciesResponse)
+	err := c.cc.Invoke(ctx, ExchangeService_ListCurrencies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exchangeServiceClient) GetQuote(ctx context.Context, in *QuoteRequest, opts ...grpc.CallOption) (*PairQuote, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PairQuote)
+	err := c.cc.Invoke(ctx, ExchangeService_GetQuote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exchangeServiceClient) SubscribeRates(ctx context.Context, in *SubscribeRatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RatesEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ExchangeService_ServiceDesc.Streams[0], ExchangeService_SubscribeRates_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRatesRequest, RatesEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ExchangeService_SubscribeRatesClient = grpc.ServerStreamingClient[RatesEvent]
+
+// ExchangeServiceServer is the server API for ExchangeService service.
+// All implementations must embed UnimplementedExchangeServiceServer
+// for forward compatibility.
+//
+// Определение сервиса
+type ExchangeServiceServer interface {
+	// Получение курсов обмена всех валют
+	GetExchangeRates(context.Context, *RatesRequest) (*ExchangeRatesResponse, error)
+	// Обмен валют
+	ExchangeCurrency(context.Context, *ExchangeRequest) (*TransactionResponse, error)
+	// Список доступных валют (без авторизации)
+	ListCurrencies(context.Context, *ListCurrenciesRequest) (*ListCurrenciesResponse, error)
+	// Курс пары валют (bid/ask) и путь расчета
+	GetQuote(context.Context, *QuoteRequest) (*PairQuote, error)
+	// Поток курсов: текущие значения, затем изменения после каждого обновления
+	SubscribeRates(*SubscribeRatesRequest, grpc.ServerStreamingServer[RatesEvent]) error
+	mustEmbedUnimplementedExchangeServiceServer()
+}
+
+// UnimplementedExchangeServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedExchangeServiceServer struct{}
+
+func (UnimplementedExchangeServiceServer) GetExchangeRates(context.Context, *RatesRequest) (*ExchangeRatesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetExchangeRates not implemented")
+}
+func (UnimplementedExchangeServiceServer) ExchangeCurrency(context.Context, *ExchangeRequest) (*TransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExchangeCurrency not implemented")
+}
+func (UnimplementedExchangeServiceServer) ListCurrencies(context.Context, *ListCurrenciesRequest) (*ListCurrenciesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCurrencies not implemented")
+}
+func (UnimplementedExchangeServiceServer) GetQuote(context.Context, *QuoteRequest) (*PairQuote, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetQuote not implemented")
+}
+func (UnimplementedExchangeServiceServer) SubscribeRates(*SubscribeRatesRequest, grpc.ServerStreamingServer[RatesEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeRates not implemented")
+}
+func (UnimplementedExchangeServiceServer) mustEmbedUnimplementedExchangeServiceServer() {}
+func (UnimplementedExchangeServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeExchangeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ExchangeServiceServer will
+// result in compilation errors.
+type UnsafeExchangeServiceServer interface {
+	mustEmbedUnimplementedExchangeServiceServer()
+}
+
+func RegisterExchangeServiceServer(s grpc.ServiceRegistrar, srv ExchangeServiceServer) {
+	// If the following call pancis, it indicates UnimplementedExchangeServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ExchangeService_ServiceDesc, srv)
+}
+
+func _ExchangeService_GetExchangeRates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RatesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExchangeServiceServer).GetExchangeRates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExchangeService_GetExchangeRates_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExchangeServiceServer).GetExchangeRates(ctx, req.(*RatesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExchangeService_ExchangeCurrency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExchangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExchangeServiceServer).ExchangeCurrency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExchangeService_ExchangeCurrency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExchangeServiceServer).ExchangeCurrency(ctx, req.(*ExchangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExchangeService_ListCurrencies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCurrenciesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExchangeServiceServer).ListCurrencies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExchangeService_ListCurrencies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExchangeServiceServer).ListCurrencies(ctx, req.(*ListCurrenciesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExchangeService_GetQuote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QuoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExchangeServiceServer).GetQuote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExchangeService_GetQuote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExchangeServiceServer).GetQuote(ctx, req.(*QuoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExchangeService_SubscribeRates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRatesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ExchangeServiceServer).SubscribeRates(m, &grpc.GenericServerStream[SubscribeRatesRequest, RatesEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ExchangeService_SubscribeRatesServer = grpc.ServerStreamingServer[RatesEvent]
+
+// ExchangeService_ServiceDesc is the grpc.ServiceDesc for ExchangeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ExchangeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "user.ExchangeService",
+	HandlerType: (*ExchangeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetExchangeRates",
+			Handler:    _ExchangeService_GetExchangeRates_Handler,
+		},
+		{
+			MethodName: "ExchangeCurrency",
+			Handler:    _ExchangeService_ExchangeCurrency_Handler,
+		},
+		{
+			MethodName: "ListCurrencies",
+			Handler:    _ExchangeService_ListCurrencies_Handler,
+		},
+		{
+			MethodName: "GetQuote",
+			Handler:    _ExchangeService_GetQuote_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeRates",
+			Handler:       _ExchangeService_SubscribeRates_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "user/user.proto",
+}
+
+const (
+	AdminService_AddCurrency_FullMethodName             = "/user.AdminService/AddCurrency"
+	AdminService_DisableCurrency_FullMethodName         = "/user.AdminService/DisableCurrency"
+	AdminService_EnableCurrency_FullMethodName          = "/user.AdminService/EnableCurrency"
+	AdminService_ListQuarantinedQuotes_FullMethodName   = "/user.AdminService/ListQuarantinedQuotes"
+	AdminService_ResolveQuarantinedQuote_FullMethodName = "/user.AdminService/ResolveQuarantinedQuote"
+)
+
+// AdminServiceClient is the client API for AdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Администрирование справочника валют (только для роли admin)
+type AdminServiceClient interface {
+	// Добавление или обновление валюты (валюта включается)
+	AddCurrency(ctx context.Context, in *AddCurrencyRequest, opts ...grpc.CallOption) (*CurrencyResponse, error)
+	// Отключение валюты
+	DisableCurrency(ctx context.Context, in *CurrencyStatusRequest, opts ...grpc.CallOption) (*CurrencyResponse, error)
+	// Повторное включение валюты
+	EnableCurrency(ctx context.Context, in *CurrencyStatusRequest, opts ...grpc.CallOption) (*CurrencyResponse, error)
+	// Котировки, задержанные проверками курсов
+	ListQuarantinedQuotes(ctx context.Context, in *QuarantineListRequest, opts ...grpc.CallOption) (*QuarantineListResponse, error)
+	// Подтверждение или отклонение задержанной котировки (снимает остановку обмена по паре)
+	ResolveQuarantinedQuote(ctx context.Context, in *ResolveQuoteRequest, opts ...grpc.CallOption) (*ResolveQuoteResponse, error)
+}
+
+type adminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminServiceClient(cc grpc.ClientConnInterface) AdminServiceClient {
+	return &adminServiceClient{cc}
+}
+
+func (c *adminServiceClient) AddCurrency(ctx context.Context, in *AddCurrencyRequest, opts ...grpc.CallOption) (*CurrencyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CurrencyResponse)
+	err := c.cc.Invoke(ctx, AdminService_AddCurrency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) DisableCurrency(ctx context.Context, in *CurrencyStatusRequest, opts ...grpc.CallOption) (*CurrencyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CurrencyResponse)
+	err := c.cc.Invoke(ctx, AdminService_DisableCurrency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) EnableCurrency(ctx context.Context, in *CurrencyStatusRequest, opts ...grpc.CallOption) (*CurrencyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CurrencyResponse)
+	err := c.cc.Invoke(ctx, AdminService_EnableCurrency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ListQuarantinedQuotes(ctx context.Context, in *QuarantineListRequest, opts ...grpc.CallOption) (*QuarantineListResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QuarantineListResponse)
+	err := c.cc.Invoke(ctx, AdminService_ListQuarantinedQuotes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ResolveQuarantinedQuote(ctx context.Context, in *ResolveQuoteRequest, opts ...grpc.CallOption) (*ResolveQuoteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResolveQuoteResponse)
+	err := c.cc.Invoke(ctx, AdminService_ResolveQuarantinedQuote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServiceServer is the server API for AdminService service.
+// All implementations must embed UnimplementedAdminServiceServer
+// for forward compatibility.
+//
+// Администрирование справочника валют (только для роли admin)
+type AdminServiceServer interface {
+	// Добавление или обновление валюты (валюта включается)
+	AddCurrency(context.Context, *AddCurrencyRequest) (*CurrencyResponse, error)
+	// Отключение валюты
+	DisableCurrency(context.Context, *CurrencyStatusRequest) (*CurrencyResponse, error)
+	// Повторное включение валюты
+	EnableCurrency(context.Context, *CurrencyStatusRequest) (*CurrencyResponse, error)
+	// Котировки, задержанные проверками курсов
+	ListQuarantinedQuotes(context.Context, *QuarantineListRequest) (*QuarantineListResponse, error)
+	// Подтверждение или отклонение задержанной котировки (снимает остановку обмена по паре)
+	ResolveQuarantinedQuote(context.Context, *ResolveQuoteRequest) (*ResolveQuoteResponse, error)
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+// UnimplementedAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAdminServiceServer struct{}
+
+func (UnimplementedAdminServiceServer) AddCurrency(context.Context, *AddCurrencyRequest) (*CurrencyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddCurrency not implemented")
+}
+func (UnimplementedAdminServiceServer) DisableCurrency(context.Context, *CurrencyStatusRequest) (*CurrencyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisableCurrency not implemented")
+}
+func (UnimplementedAdminServiceServer) EnableCurrency(context.Context, *CurrencyStatusRequest) (*CurrencyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnableCurrency not implemented")
+}
+func (UnimplementedAdminServiceServer) ListQuarantinedQuotes(context.Context, *QuarantineListRequest) (*QuarantineListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListQuarantinedQuotes not implemented")
+}
+func (UnimplementedAdminServiceServer) ResolveQuarantinedQuote(context.Context, *ResolveQuoteRequest) (*ResolveQuoteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResolveQuarantinedQuote not implemented")
+}
+func (UnimplementedAdminServiceServer) mustEmbedUnimplementedAdminServiceServer() {}
+func (UnimplementedAdminServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServiceServer will
+// result in compilation errors.
+type UnsafeAdminServiceServer interface {
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+func RegisterAdminServiceServer(s grpc.ServiceRegistrar, srv AdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AdminService_ServiceDesc, srv)
+}
+
+func _AdminService_AddCurrency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddCurrencyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).AddCurrency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_AddCurrency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).AddCurrency(ctx, req.(*AddCurrencyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_DisableCurrency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CurrencyStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).DisableCurrency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_DisableCurrency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).DisableCurrency(ctx, req.(*CurrencyStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_EnableCurrency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CurrencyStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).EnableCurrency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_EnableCurrency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).EnableCurrency(ctx, req.(*CurrencyStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ListQuarantinedQuotes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QuarantineListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListQuarantinedQuotes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ListQuarantinedQuotes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListQuarantinedQuotes(ctx, req.(*QuarantineListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ResolveQuarantinedQuote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResolveQuoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ResolveQuarantinedQuote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ResolveQuarantinedQuote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ResolveQuarantinedQuote(ctx, req.(*ResolveQuoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AdminService_ServiceDesc is the grpc.ServiceDesc for AdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "user.AdminService",
+	HandlerType: (*AdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddCurrency",
+			Handler:    _AdminService_AddCurrency_Handler,
+		},
+		{
+			MethodName: "DisableCurrency",
+			Handler:    _AdminService_DisableCurrency_Handler,
+		},
+		{
+			MethodName: "EnableCurrency",
+			Handler:    _AdminService_EnableCurrency_Handler,
+		},
+		{
+			MethodName: "ListQuarantinedQuotes",
+			Handler:    _AdminService_ListQuarantinedQuotes_Handler,
+		},
+		{
+			MethodName: "ResolveQuarantinedQuote",
+			Handler:    _AdminService_ResolveQuarantinedQuote_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "user/user.proto",
+}
+
+const (
+	Auth_RegisterUser_FullMethodName = "/user.Auth/RegisterUser"
+	Auth_LoginUser_FullMethodName    = "/user.Auth/LoginUser"
+)
+
+// AuthClient is the client API for Auth service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Auth сервиса
+type AuthClient interface {
+	// Регистрация пользователя
+	RegisterUser(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error)
+	// Авторизация пользователя
+	LoginUser(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error)
+}
+
+type authClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAuthClient(cc grpc.ClientConnInterface) AuthClient {
+	return &authClient{cc}
+}
+
+func (c *authClient) RegisterUser(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterResponse)
+	err := c.cc.Invoke(ctx, Auth_RegisterUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) LoginUser(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LoginResponse)
+	err := c.cc.Invoke(ctx, Auth_LoginUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AuthServer is the server API for Auth service.
+// All implementations must embed UnimplementedAuthServer
+// for forward compatibility.
+//
+// Auth сервиса
+type AuthServer interface {
+	// Регистрация пользователя
+	RegisterUser(context.Context, *RegisterRequest) (*RegisterResponse, error)
+	// Авторизация пользователя
+	LoginUser(context.Context, *LoginRequest) (*LoginResponse, error)
+	mustEmbedUnimplementedAuthServer()
+}
+
+// UnimplementedAuthServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAuthServer struct{}
+
+func (UnimplementedAuthServer) RegisterUser(context.Context, *RegisterRequest) (*RegisterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterUser not implemented")
+}
+func (UnimplementedAuthServer) LoginUser(context.Context, *LoginRequest) (*LoginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LoginUser not implemented")
+}
+func (UnimplementedAuthServer) mustEmbedUnimplementedAuthServer() {}
+func (UnimplementedAuthServer) testEmbeddedByValue()              {}
+
+// UnsafeAuthServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AuthServer will
+// result in compilation errors.
+type UnsafeAuthServer interface {
+	mustEmbedUnimplementedAuthServer()
+}
+
+func RegisterAuthServer(s grpc.ServiceRegistrar, srv AuthServer) {
+	// If the following call pancis, it indicates UnimplementedAuthServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Auth_ServiceDesc, srv)
+}
+
+func _Auth_RegisterUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).RegisterUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Auth_RegisterUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).RegisterUser(ctx, req.(*RegisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_LoginUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).LoginUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Auth_LoginUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).LoginUser(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Auth_ServiceDesc is the grpc.ServiceDesc for Auth service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Auth_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "user.Auth",
+	HandlerType: (*AuthServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RegisterUser",
+			Handler:    _Auth_RegisterUser_Handler,
+		},
+		{
+			MethodName: "LoginUser",
+			Handler:    _Auth_LoginUser_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "user/user.proto",
+}
+
+const (
+	FinancialService_GetBalance_FullMethodName    = "/user.FinancialService/GetBalance"
+	FinancialService_Deposit_FullMethodName       = "/user.FinancialService/Deposit"
+	FinancialService_Withdraw_FullMethodName      = "/user.FinancialService/Withdraw"
+	FinancialService_WatchBalance_FullMethodName  = "/user.FinancialService/WatchBalance"
+	FinancialService_CreateWallet_FullMethodName  = "/user.FinancialService/CreateWallet"
+	FinancialService_RenameWallet_FullMethodName  = "/user.FinancialService/RenameWallet"
+	FinancialService_ArchiveWallet_FullMethodName = "/user.FinancialService/ArchiveWallet"
+	FinancialService_Transfer_FullMethodName      = "/user.FinancialService/Transfer"
+	FinancialService_GetPortfolio_FullMethodName  = "/user.FinancialService/GetPortfolio"
+)
+
+// FinancialServiceClient is the client API for FinancialService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Финансовые операции (Баланс, пополнение, вывод)
+type FinancialServiceClient interface {
+	// Получение баланса пользователя
+	GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*BalanceResponse, error)
+	// Пополнение счета
+	Deposit(ctx context.Context, in *DepositRequest, opts ...grpc.CallOption) (*WithdrawDepositResponse, error)
+	// Вывод средств
+	Withdraw(ctx context.Context, in *WithdrawRequest, opts ...grpc.CallOption) (*WithdrawDepositResponse, error)
+	// Поток изменений баланса пользователя
+	WatchBalance(ctx context.Context, in *WatchBalanceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BalanceEvent], error)
+	// Создание дополнительного кошелька с названием
+	CreateWallet(ctx context.Context, in *CreateWalletRequest, opts ...grpc.CallOption) (*WalletResponse, error)
+	// Переименование дополнительного кошелька
+	RenameWallet(ctx context.Context, in *RenameWalletRequest, opts ...grpc.CallOption) (*WalletResponse, error)
+	// Перенос пустого дополнительного кошелька в архив
+	ArchiveWallet(ctx context.Context, in *WalletRequest, opts ...grpc.CallOption) (*WalletResponse, error)
+	// Перевод между кошельками пользователя в одной валюте
+	Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransferResponse, error)
+	// Оценка всех кошельков в отчетной валюте по текущим или историческим курсам
+	GetPortfolio(ctx context.Context, in *PortfolioRequest, opts ...grpc.CallOption) (*PortfolioResponse, error)
+}
+
+type financialServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFinancialServiceClient(cc grpc.ClientConnInterface) FinancialServiceClient {
+	return &financialServiceClient{cc}
+}
+
+func (c *financialServiceClient) GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*BalanceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BalanceResponse)
+	err := c.cc.Invoke(ctx, FinancialService_GetBalance_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *financialServiceClient) Deposit(ctx context.Context, in *DepositRequest, opts ...grpc.CallOption) (*WithdrawDepositResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WithdrawDepositResponse)
+	err := c.cc.Invoke(ctx, FinancialService_Deposit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *financialServiceClient) Withdraw(ctx context.Context, in *WithdrawRequest, opts ...grpc.CallOption) (*WithdrawDepositResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WithdrawDepositResponse)
+	err := c.cc.Invoke(ctx, FinancialService_Withdraw_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *financialServiceClient) WatchBalance(ctx context.Context, in *WatchBalanceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BalanceEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FinancialService_ServiceDesc.Streams[0], FinancialService_WatchBalance_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchBalanceRequest, BalanceEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FinancialService_WatchBalanceClient = grpc.ServerStreamingClient[BalanceEvent]
+
+func (c *financialServiceClient) CreateWallet(ctx context.Context, in *CreateWalletRequest, opts ...grpc.CallOption) (*WalletResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WalletResponse)
+	err := c.cc.Invoke(ctx, FinancialService_CreateWallet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *financialServiceClient) RenameWallet(ctx context.Context, in *RenameWalletRequest, opts ...grpc.CallOption) (*WalletResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WalletResponse)
+	err := c.cc.Invoke(ctx, FinancialService_RenameWallet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *financialServiceClient) ArchiveWallet(ctx context.Context, in *WalletRequest, opts ...grpc.CallOption) (*WalletResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WalletResponse)
+	err := c.cc.Invoke(ctx, FinancialService_ArchiveWallet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *financialServiceClient) Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransferResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransferResponse)
+	err := c.cc.Invoke(ctx, FinancialService_Transfer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *financialServiceClient) GetPortfolio(ctx context.Context, in *PortfolioRequest, opts ...grpc.CallOption) (*PortfolioResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PortfolioResponse)
+	err := c.cc.Invoke(ctx, FinancialService_GetPortfolio_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FinancialServiceServer is the server API for FinancialService service.
+// All implementations must embed UnimplementedFinancialServiceServer
+// for forward compatibility.
+//
+// Финансовые операции (Баланс, пополнение, вывод)
+type FinancialServiceServer interface {
+	// Получение баланса пользователя
+	GetBalance(context.Context, *GetBalanceRequest) (*BalanceResponse, error)
+	// Пополнение счета
+	Deposit(context.Context, *DepositRequest) (*WithdrawDepositResponse, error)
+	// Вывод средств
+	Withdraw(context.Context, *WithdrawRequest) (*WithdrawDepositResponse, error)
+	// Поток изменений баланса пользователя
+	WatchBalance(*WatchBalanceRequest, grpc.ServerStreamingServer[BalanceEvent]) error
+	// Создание дополнительного кошелька с названием
+	CreateWallet(context.Context, *CreateWalletRequest) (*WalletResponse, error)
+	// Переименование дополнительного кошелька
+	RenameWallet(context.Context, *RenameWalletRequest) (*WalletResponse, error)
+	// Перенос пустого дополнительного кошелька в архив
+	ArchiveWallet(context.Context, *WalletRequest) (*WalletResponse, error)
+	// Перевод между кошельками пользователя в одной валюте
+	Transfer(context.Context, *TransferRequest) (*TransferResponse, error)
+	// Оценка всех кошельков в отчетной валюте по текущим или историческим курсам
+	GetPortfolio(context.Context, *PortfolioRequest) (*PortfolioResponse, error)
+	mustEmbedUnimplementedFinancialServiceServer()
+}
+
+// UnimplementedFinancialServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedFinancialServiceServer struct{}
+
+func (UnimplementedFinancialServiceServer) GetBalance(context.Context, *GetBalanceRequest) (*BalanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalance not implemented")
+}
+func (UnimplementedFinancialServiceServer) Deposit(context.Context, *DepositRequest) (*WithdrawDepositResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Deposit not implemented")
+}
+func (UnimplementedFinancialServiceServer) Withdraw(context.Context, *WithdrawRequest) (*WithdrawDepositResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Withdraw not implemented")
+}
+func (UnimplementedFinancialServiceServer) WatchBalance(*WatchBalanceRequest, grpc.ServerStreamingServer[BalanceEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchBalance not implemented")
+}
+func (UnimplementedFinancialServiceServer) CreateWallet(context.Context, *CreateWalletRequest) (*WalletResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateWallet not implemented")
+}
+func (UnimplementedFinancialServiceServer) RenameWallet(context.Context, *RenameWalletRequest) (*WalletResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenameWallet not implemented")
+}
+func (UnimplementedFinancialServiceServer) ArchiveWallet(context.Context, *WalletRequest) (*WalletResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ArchiveWallet not implemented")
+}
+func (UnimplementedFinancialServiceServer) Transfer(context.Context, *TransferRequest) (*TransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Transfer not implemented")
+}
+func (UnimplementedFinancialServiceServer) GetPortfolio(context.Context, *PortfolioRequest) (*PortfolioResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPortfolio not implemented")
+}
+func (UnimplementedFinancialServiceServer) mustEmbedUnimplementedFinancialServiceServer() {}
+func (UnimplementedFinancialServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeFinancialServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FinancialServiceServer will
+// result in compilation errors.
+type UnsafeFinancialServiceServer interface {
+	mustEmbedUnimplementedFinancialServiceServer()
+}
+
+func RegisterFinancialServiceServer(s grpc.ServiceRegistrar, srv FinancialServiceServer) {
+	// If the following call pancis, it indicates UnimplementedFinancialServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&FinancialService_ServiceDesc, srv)
+}
+
+func _FinancialService_GetBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FinancialServiceServer).GetBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FinancialService_GetBalance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FinancialServiceServer).GetBalance(ctx, req.(*GetBalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FinancialService_Deposit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DepositRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FinancialServiceServer).Deposit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FinancialService_Deposit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FinancialServiceServer).Deposit(ctx, req.(*DepositRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FinancialService_Withdraw_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WithdrawRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FinancialServiceServer).Withdraw(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FinancialService_Withdraw_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FinancialServiceServer).Withdraw(ctx, req.(*WithdrawRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FinancialService_WatchBalance_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchBalanceRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FinancialServiceServer).WatchBalance(m, &grpc.GenericServerStream[WatchBalanceRequest, BalanceEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FinancialService_WatchBalanceServer = grpc.ServerStreamingServer[BalanceEvent]
+
+func _FinancialService_CreateWallet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateWalletRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FinancialServiceServer).CreateWallet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FinancialService_CreateWallet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FinancialServiceServer).CreateWallet(ctx, req.(*CreateWalletRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FinancialService_RenameWallet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameWalletRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FinancialServiceServer).RenameWallet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FinancialService_RenameWallet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FinancialServiceServer).RenameWallet(ctx, req.(*RenameWalletRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FinancialService_ArchiveWallet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WalletRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FinancialServiceServer).ArchiveWallet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FinancialService_ArchiveWallet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FinancialServiceServer).ArchiveWallet(ctx, req.(*WalletRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FinancialService_Transfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FinancialServiceServer).Transfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FinancialService_Transfer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FinancialServiceServer).Transfer(ctx, req.(*TransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FinancialService_GetPortfolio_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PortfolioRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FinancialServiceServer).GetPortfolio(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FinancialService_GetPortfolio_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FinancialServiceServer).GetPortfolio(ctx, req.(*PortfolioRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// FinancialService_ServiceDesc is the grpc.ServiceDesc for FinancialService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FinancialService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "user.FinancialService",
+	HandlerType: (*FinancialServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBalance",
+			Handler:    _FinancialService_GetBalance_Handler,
+		},
+		{
+			MethodName: "Deposit",
+			Handler:    _FinancialService_Deposit_Handler,
+		},
+		{
+			MethodName: "Withdraw",
+			Handler:    _FinancialService_Withdraw_Handler,
+		},
+		{
+			MethodName: "CreateWallet",
+			Handler:    _FinancialService_CreateWallet_Handler,
+		},
+		{
+			MethodName: "RenameWallet",
+			Handler:    _FinancialService_RenameWallet_Handler,
+		},
+		{
+			MethodName: "ArchiveWallet",
+			Handler:    _FinancialService_ArchiveWallet_Handler,
+		},
+		{
+			MethodName: "Transfer",
+			Handler:    _FinancialService_Transfer_Handler,
+		},
+		{
+			MethodName: "GetPortfolio",
+			Handler:    _FinancialService_GetPortfolio_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchBalance",
+			Handler:       _FinancialService_WatchBalance_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "user/user.proto",
+}